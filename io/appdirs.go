@@ -0,0 +1,120 @@
+package io
+
+import (
+	"io/fs"
+
+	"github.com/cjlapao/common-go-helpers/io/paths"
+)
+
+// AppDirs holds the standard per user directories of an application
+type AppDirs struct {
+	Config string
+	Cache  string
+	Data   string
+	State  string
+	Log    string
+}
+
+// AppDirsOptions customises ResolveAppDirs, Environment defaults to the
+// process environment and Mode, used when Create is set, to 0700
+type AppDirsOptions struct {
+	Environment paths.Environment
+	Create      bool
+	Mode        fs.FileMode
+}
+
+// ResolveAppDirs returns the standard directories of appName for the
+// operating system reported by fileIo: the XDG Base Directory variables on
+// Linux and other unix systems, ~/Library on macOS and %APPDATA% and
+// %LOCALAPPDATA% on Windows. When Create is set the directories are created
+// with fileIo.EnsureDir.
+func ResolveAppDirs(fileIo FileIo, appName string, options ...AppDirsOptions) (AppDirs, error) {
+	opts := AppDirsOptions{}
+	if len(options) == 1 {
+		opts = options[0]
+	}
+	if opts.Environment == nil {
+		opts.Environment = paths.OsEnvironment{}
+	}
+	if opts.Mode == 0 {
+		opts.Mode = 0o700
+	}
+
+	home, err := opts.Environment.HomeDir()
+	if err != nil {
+		return AppDirs{}, NewFileIoError(BackendDefault, "ResolveAppDirs", appName, err)
+	}
+
+	var dirs AppDirs
+	switch operatingSystem := fileIo.GetOperatingSystem(); operatingSystem {
+	case WindowsOs:
+		dirs = windowsAppDirs(opts.Environment, home, appName)
+	case MacOs:
+		dirs = macAppDirs(home, appName)
+	default:
+		dirs = xdgAppDirs(opts.Environment, home, appName)
+	}
+
+	if opts.Create {
+		for _, dir := range []string{dirs.Config, dirs.Cache, dirs.Data, dirs.State, dirs.Log} {
+			if err := fileIo.EnsureDir(dir, opts.Mode); err != nil {
+				return AppDirs{}, err
+			}
+		}
+	}
+
+	return dirs, nil
+}
+
+func xdgAppDirs(env paths.Environment, home, appName string) AppDirs {
+	flavour := paths.Posix
+	base := func(variable string, fallback ...string) string {
+		// the specification says relative paths must be ignored
+		if value, ok := env.LookupEnv(variable); ok && flavour.IsAbs(value) {
+			return flavour.Join(value, appName)
+		}
+		elements := append([]string{home}, fallback...)
+		return flavour.Join(append(elements, appName)...)
+	}
+
+	state := base("XDG_STATE_HOME", ".local", "state")
+	return AppDirs{
+		Config: base("XDG_CONFIG_HOME", ".config"),
+		Cache:  base("XDG_CACHE_HOME", ".cache"),
+		Data:   base("XDG_DATA_HOME", ".local", "share"),
+		State:  state,
+		Log:    flavour.Join(state, "log"),
+	}
+}
+
+func macAppDirs(home, appName string) AppDirs {
+	flavour := paths.Posix
+	support := flavour.Join(home, "Library", "Application Support", appName)
+	return AppDirs{
+		Config: support,
+		Cache:  flavour.Join(home, "Library", "Caches", appName),
+		Data:   support,
+		State:  support,
+		Log:    flavour.Join(home, "Library", "Logs", appName),
+	}
+}
+
+func windowsAppDirs(env paths.Environment, home, appName string) AppDirs {
+	flavour := paths.Windows
+	folder := func(variable string, fallback ...string) string {
+		if value, ok := env.LookupEnv(variable); ok && value != "" {
+			return value
+		}
+		return flavour.Join(append([]string{home}, fallback...)...)
+	}
+
+	roaming := flavour.Join(folder("APPDATA", "AppData", "Roaming"), appName)
+	local := flavour.Join(folder("LOCALAPPDATA", "AppData", "Local"), appName)
+	return AppDirs{
+		Config: roaming,
+		Cache:  flavour.Join(local, "Cache"),
+		Data:   local,
+		State:  flavour.Join(local, "State"),
+		Log:    flavour.Join(local, "Logs"),
+	}
+}
@@ -0,0 +1,105 @@
+package io
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/cjlapao/common-go-helpers/io/paths"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveAppDirs(t *testing.T) {
+	t.Run("Linux Defaults", func(t *testing.T) {
+		env := paths.StaticEnvironment{Home: "/home/me"}
+
+		dirs, err := ResolveAppDirs(Default(WithOperatingSystem(LinuxOs)), "tool", AppDirsOptions{Environment: env})
+		assert.NoError(t, err)
+		assert.Equal(t, AppDirs{
+			Config: "/home/me/.config/tool",
+			Cache:  "/home/me/.cache/tool",
+			Data:   "/home/me/.local/share/tool",
+			State:  "/home/me/.local/state/tool",
+			Log:    "/home/me/.local/state/tool/log",
+		}, dirs)
+	})
+
+	t.Run("Linux XDG Overrides", func(t *testing.T) {
+		env := paths.StaticEnvironment{
+			Home: "/home/me",
+			Variables: map[string]string{
+				"XDG_CONFIG_HOME": "/etc/xdg",
+				"XDG_CACHE_HOME":  "/var/cache",
+				"XDG_DATA_HOME":   "relative/is/ignored",
+				"XDG_STATE_HOME":  "/var/state",
+			},
+		}
+
+		dirs, err := ResolveAppDirs(Default(WithOperatingSystem(LinuxOs)), "tool", AppDirsOptions{Environment: env})
+		assert.NoError(t, err)
+		assert.Equal(t, "/etc/xdg/tool", dirs.Config)
+		assert.Equal(t, "/var/cache/tool", dirs.Cache)
+		assert.Equal(t, "/home/me/.local/share/tool", dirs.Data)
+		assert.Equal(t, "/var/state/tool", dirs.State)
+		assert.Equal(t, "/var/state/tool/log", dirs.Log)
+	})
+
+	t.Run("Mac OS", func(t *testing.T) {
+		env := paths.StaticEnvironment{Home: "/Users/me"}
+
+		dirs, err := ResolveAppDirs(Default(WithOperatingSystem(MacOs)), "tool", AppDirsOptions{Environment: env})
+		assert.NoError(t, err)
+		assert.Equal(t, AppDirs{
+			Config: "/Users/me/Library/Application Support/tool",
+			Cache:  "/Users/me/Library/Caches/tool",
+			Data:   "/Users/me/Library/Application Support/tool",
+			State:  "/Users/me/Library/Application Support/tool",
+			Log:    "/Users/me/Library/Logs/tool",
+		}, dirs)
+	})
+
+	t.Run("Windows OS", func(t *testing.T) {
+		env := paths.StaticEnvironment{
+			Home: `C:\Users\me`,
+			Variables: map[string]string{
+				"APPDATA":      `D:\Roaming`,
+				"LOCALAPPDATA": `D:\Local`,
+			},
+		}
+
+		dirs, err := ResolveAppDirs(Default(WithOperatingSystem(WindowsOs)), "tool", AppDirsOptions{Environment: env})
+		assert.NoError(t, err)
+		assert.Equal(t, AppDirs{
+			Config: `D:\Roaming\tool`,
+			Cache:  `D:\Local\tool\Cache`,
+			Data:   `D:\Local\tool`,
+			State:  `D:\Local\tool\State`,
+			Log:    `D:\Local\tool\Logs`,
+		}, dirs)
+	})
+
+	t.Run("Windows OS Without Variables", func(t *testing.T) {
+		env := paths.StaticEnvironment{Home: `C:\Users\me`}
+
+		dirs, err := ResolveAppDirs(Default(WithOperatingSystem(WindowsOs)), "tool", AppDirsOptions{Environment: env})
+		assert.NoError(t, err)
+		assert.Equal(t, `C:\Users\me\AppData\Roaming\tool`, dirs.Config)
+		assert.Equal(t, `C:\Users\me\AppData\Local\tool`, dirs.Data)
+	})
+
+	t.Run("No Home Directory", func(t *testing.T) {
+
+		_, err := ResolveAppDirs(Default(WithOperatingSystem(LinuxOs)), "tool", AppDirsOptions{Environment: paths.StaticEnvironment{}})
+		assert.Error(t, err)
+	})
+
+	t.Run("Create Directories", func(t *testing.T) {
+		home := t.TempDir()
+		env := paths.StaticEnvironment{Home: filepath.ToSlash(home)}
+
+		dirs, err := ResolveAppDirs(Default(), "tool", AppDirsOptions{Environment: env, Create: true})
+		assert.NoError(t, err)
+		for _, dir := range []string{dirs.Config, dirs.Cache, dirs.Data, dirs.State, dirs.Log} {
+			assertDirMode(t, dir, 0o700)
+		}
+	})
+}
@@ -0,0 +1,508 @@
+package io
+
+import (
+	"container/list"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultCacheSize is the number of bytes a CachingFileIo keeps when no
+// other size is given
+const DefaultCacheSize = 32 << 20
+
+// cacheEntryOverhead is the size charged for an entry on top of its content
+const cacheEntryOverhead = 128
+
+// CacheOption configures a CachingFileIo
+type CacheOption func(*CachingFileIo)
+
+// WithCacheSize caps the cached content, plus a small overhead per entry,
+// at maxBytes, evicting the least recently used entries first
+func WithCacheSize(maxBytes int64) CacheOption {
+	return func(f *CachingFileIo) {
+		f.maxBytes = maxBytes
+	}
+}
+
+// WithCacheTTL expires entries ttl after they were cached, entries never
+// expire when ttl is zero
+func WithCacheTTL(ttl time.Duration) CacheOption {
+	return func(f *CachingFileIo) {
+		f.ttl = ttl
+	}
+}
+
+// WithMtimeRevalidation checks the modification time and size of a file on
+// the backend before serving its cached content or checksum, so changes made
+// behind the cache are noticed at the cost of a FileInfo call
+func WithMtimeRevalidation() CacheOption {
+	return func(f *CachingFileIo) {
+		f.revalidate = true
+	}
+}
+
+// CacheStats counts how a CachingFileIo served its reads
+type CacheStats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+	Entries   int
+	Bytes     int64
+}
+
+// CachingFileIo wraps a FileIo and caches the results of ReadFile, FileInfo,
+// FileExists and Checksum. ReadBufferedFile is served from cached content
+// when there is some. Writes, moves and deletes made through the cache
+// invalidate the paths they touch, directories included, changes made
+// directly on the backend are only seen once entries expire or, with
+// WithMtimeRevalidation, once the file modification time changes.
+type CachingFileIo struct {
+	fileIo     FileIo
+	maxBytes   int64
+	ttl        time.Duration
+	revalidate bool
+	now        func() time.Time
+
+	mu         sync.Mutex
+	lru        *list.List
+	entries    map[cacheKey]*list.Element
+	byPath     map[string]map[cacheKey]bool
+	bytes      int64
+	generation uint64
+	stats      CacheStats
+}
+
+type cacheKind int
+
+const (
+	cacheContent cacheKind = iota
+	cacheInfo
+	cacheExists
+	cacheChecksum
+)
+
+type cacheKey struct {
+	kind   cacheKind
+	path   string
+	method ChecksumMethod
+}
+
+type cacheEntry struct {
+	key      cacheKey
+	content  []byte
+	info     os.FileInfo
+	exists   bool
+	checksum string
+	size     int64
+	expires  time.Time
+	stamped  bool
+	modTime  time.Time
+	fileSize int64
+}
+
+// NewCachingFileIo returns a FileIo caching the reads made on fileIo
+func NewCachingFileIo(fileIo FileIo, options ...CacheOption) *CachingFileIo {
+	result := &CachingFileIo{
+		fileIo:   fileIo,
+		maxBytes: DefaultCacheSize,
+		now:      time.Now,
+		lru:      list.New(),
+		entries:  map[cacheKey]*list.Element{},
+		byPath:   map[string]map[cacheKey]bool{},
+	}
+
+	for _, option := range options {
+		option(result)
+	}
+
+	return result
+}
+
+// Stats returns the cache counters and current size
+func (f *CachingFileIo) Stats() CacheStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stats := f.stats
+	stats.Entries = f.lru.Len()
+	stats.Bytes = f.bytes
+	return stats
+}
+
+// Invalidate drops everything cached for path and the paths beneath it, and
+// the existence and info of its parents, which may be created or changed
+// along with it
+func (f *CachingFileIo) Invalidate(path string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.generation++
+	flavour := f.fileIo.GetOperatingSystem().PathFlavour()
+	path = flavour.Clean(path)
+	for cached, keys := range f.byPath {
+		beneath := isWithin(flavour, path, cached)
+		if !beneath && !isWithin(flavour, cached, path) {
+			continue
+		}
+		for key := range keys {
+			if beneath || key.kind == cacheExists || key.kind == cacheInfo {
+				f.remove(f.entries[key])
+			}
+		}
+	}
+}
+
+// Purge drops every cached entry
+func (f *CachingFileIo) Purge() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.generation++
+	f.lru.Init()
+	f.entries = map[cacheKey]*list.Element{}
+	f.byPath = map[string]map[cacheKey]bool{}
+	f.bytes = 0
+}
+
+func (f *CachingFileIo) GetOperatingSystem() OperatingSystem {
+	return f.fileIo.GetOperatingSystem()
+}
+
+func (f *CachingFileIo) FileExists(path string) bool {
+	key := f.key(cacheExists, path, 0)
+	if entry, ok := f.cached(key); ok {
+		return entry.exists
+	}
+
+	generation := f.currentGeneration()
+	exists := f.fileIo.FileExists(path)
+	f.store(generation, &cacheEntry{key: key, exists: exists})
+	return exists
+}
+
+func (f *CachingFileIo) DirExists(folderPath string) bool {
+	return f.fileIo.DirExists(folderPath)
+}
+
+func (f *CachingFileIo) CreateDir(folderPath string, mode fs.FileMode) error {
+	defer f.Invalidate(folderPath)
+	return f.fileIo.CreateDir(folderPath, mode)
+}
+
+func (f *CachingFileIo) CreateDirAll(folderPath string, mode fs.FileMode, parentMode ...fs.FileMode) error {
+	defer f.Invalidate(folderPath)
+	return f.fileIo.CreateDirAll(folderPath, mode, parentMode...)
+}
+
+func (f *CachingFileIo) EnsureDir(folderPath string, mode fs.FileMode) error {
+	defer f.Invalidate(folderPath)
+	return f.fileIo.EnsureDir(folderPath, mode)
+}
+
+func (f *CachingFileIo) GetExecutionPath() string {
+	return f.fileIo.GetExecutionPath()
+}
+
+func (f *CachingFileIo) ToOsPath(path string) string {
+	return f.fileIo.ToOsPath(path)
+}
+
+func (f *CachingFileIo) GetOsPathSeparator() string {
+	return f.fileIo.GetOsPathSeparator()
+}
+
+func (f *CachingFileIo) ReadFile(path string) ([]byte, error) {
+	key := f.key(cacheContent, path, 0)
+	if entry, ok := f.cached(key); ok {
+		return append([]byte{}, entry.content...), nil
+	}
+
+	generation := f.currentGeneration()
+	entry := &cacheEntry{key: key}
+	if err := f.stamp(entry); err != nil {
+		return nil, err
+	}
+
+	content, err := f.fileIo.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.content = append([]byte{}, content...)
+	entry.size = int64(len(content))
+	f.store(generation, entry)
+	return content, nil
+}
+
+func (f *CachingFileIo) ReadBufferedFile(path string, from, to int) ([]byte, error) {
+	if entry, ok := f.cached(f.key(cacheContent, path, 0)); ok {
+		buffer, err := readRange(entry.content, from, to)
+		if err != nil {
+			return nil, NewFileIoError(BackendCache, "ReadBufferedFile", path, err)
+		}
+		return append([]byte{}, buffer...), nil
+	}
+
+	return f.fileIo.ReadBufferedFile(path, from, to)
+}
+
+func (f *CachingFileIo) WriteFile(path string, data []byte, mode os.FileMode) error {
+	defer f.Invalidate(path)
+	return f.fileIo.WriteFile(path, data, mode)
+}
+
+func (f *CachingFileIo) WriteBufferedFile(path string, data []byte, bufferSize int, mode os.FileMode) error {
+	defer f.Invalidate(path)
+	return f.fileIo.WriteBufferedFile(path, data, bufferSize, mode)
+}
+
+func (f *CachingFileIo) ReadDir(path string) ([]fs.DirEntry, error) {
+	return f.fileIo.ReadDir(path)
+}
+
+func (f *CachingFileIo) JoinPath(parts ...string) string {
+	return f.fileIo.JoinPath(parts...)
+}
+
+func (f *CachingFileIo) CopyFile(source, destination string) error {
+	defer f.Invalidate(destination)
+	return f.fileIo.CopyFile(source, destination)
+}
+
+func (f *CachingFileIo) DeleteFile(path string) error {
+	defer f.Invalidate(path)
+	return f.fileIo.DeleteFile(path)
+}
+
+func (f *CachingFileIo) Move(source, destination string, policy ...OverwritePolicy) error {
+	defer f.Invalidate(destination)
+	defer f.Invalidate(source)
+	return f.fileIo.Move(source, destination, policy...)
+}
+
+func (f *CachingFileIo) CopyDir(source, destination string) error {
+	defer f.Invalidate(destination)
+	return f.fileIo.CopyDir(source, destination)
+}
+
+func (f *CachingFileIo) DeleteDir(path string) error {
+	defer f.Invalidate(path)
+	return f.fileIo.DeleteDir(path)
+}
+
+func (f *CachingFileIo) Checksum(path string, method ChecksumMethod) (string, error) {
+	key := f.key(cacheChecksum, path, method)
+	if entry, ok := f.cached(key); ok {
+		return entry.checksum, nil
+	}
+
+	generation := f.currentGeneration()
+	entry := &cacheEntry{key: key}
+	if err := f.stamp(entry); err != nil {
+		return "", err
+	}
+
+	checksum, err := f.fileIo.Checksum(path, method)
+	if err != nil {
+		return "", err
+	}
+
+	entry.checksum = checksum
+	f.store(generation, entry)
+	return checksum, nil
+}
+
+func (f *CachingFileIo) FileInfo(path string) (os.FileInfo, error) {
+	key := f.key(cacheInfo, path, 0)
+	if entry, ok := f.cached(key); ok {
+		return entry.info, nil
+	}
+
+	generation := f.currentGeneration()
+	info, err := f.fileIo.FileInfo(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f.store(generation, &cacheEntry{key: key, info: info})
+	return info, nil
+}
+
+func (f *CachingFileIo) TempDir(dir, pattern string) (string, CleanupFunc, error) {
+	path, cleanup, err := f.fileIo.TempDir(dir, pattern)
+	if err != nil {
+		return "", nil, err
+	}
+
+	f.Invalidate(path)
+	return path, f.invalidating(path, cleanup), nil
+}
+
+func (f *CachingFileIo) TempFile(dir, pattern string) (string, CleanupFunc, error) {
+	path, cleanup, err := f.fileIo.TempFile(dir, pattern)
+	if err != nil {
+		return "", nil, err
+	}
+
+	f.Invalidate(path)
+	return path, f.invalidating(path, cleanup), nil
+}
+
+// OpenFile opens path on the backend, streams are never cached
+func (f *CachingFileIo) OpenFile(path string) (io.ReadCloser, error) {
+	return OpenReader(f.fileIo, path)
+}
+
+// CreateFile creates path for writing, path is invalidated when the file is
+// created and again when the writer is closed
+func (f *CachingFileIo) CreateFile(path string, mode fs.FileMode) (io.WriteCloser, error) {
+	defer f.Invalidate(path)
+
+	writer, err := CreateWriter(f.fileIo, path, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	return &invalidatingWriter{WriteCloser: writer, invalidate: func() { f.Invalidate(path) }}, nil
+}
+
+func (f *CachingFileIo) key(kind cacheKind, path string, method ChecksumMethod) cacheKey {
+	return cacheKey{
+		kind:   kind,
+		path:   f.fileIo.GetOperatingSystem().PathFlavour().Clean(path),
+		method: method,
+	}
+}
+
+// cached returns the live entry for key, revalidating content and
+// checksums against the backend modification time when asked to
+func (f *CachingFileIo) cached(key cacheKey) (*cacheEntry, bool) {
+	f.mu.Lock()
+	entry, ok := f.lookup(key)
+	f.mu.Unlock()
+
+	if ok && f.revalidate && entry.stamped {
+		info, err := f.fileIo.FileInfo(key.path)
+		if err != nil || !info.ModTime().Equal(entry.modTime) || info.Size() != entry.fileSize {
+			f.Invalidate(key.path)
+			ok = false
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if ok {
+		f.stats.Hits++
+	} else {
+		f.stats.Misses++
+	}
+
+	return entry, ok
+}
+
+// lookup returns the entry for key unless it expired, callers hold mu
+func (f *CachingFileIo) lookup(key cacheKey) (*cacheEntry, bool) {
+	element, ok := f.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*cacheEntry)
+	if !entry.expires.IsZero() && !f.now().Before(entry.expires) {
+		f.remove(element)
+		return nil, false
+	}
+
+	f.lru.MoveToFront(element)
+	return entry, true
+}
+
+// stamp records the modification time and size of the file entry caches,
+// taken before it is read so a concurrent change is noticed on the next
+// revalidation
+func (f *CachingFileIo) stamp(entry *cacheEntry) error {
+	if !f.revalidate {
+		return nil
+	}
+
+	info, err := f.fileIo.FileInfo(entry.key.path)
+	if err != nil {
+		return err
+	}
+
+	entry.stamped = true
+	entry.modTime = info.ModTime()
+	entry.fileSize = info.Size()
+	return nil
+}
+
+func (f *CachingFileIo) currentGeneration() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.generation
+}
+
+// store caches entry unless something was invalidated since generation,
+// evicting the least recently used entries to make room
+func (f *CachingFileIo) store(generation uint64, entry *cacheEntry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	entry.size += cacheEntryOverhead + int64(len(entry.key.path))
+	if generation != f.generation || entry.size > f.maxBytes {
+		return
+	}
+
+	if f.ttl > 0 {
+		entry.expires = f.now().Add(f.ttl)
+	}
+
+	if existing, ok := f.entries[entry.key]; ok {
+		f.remove(existing)
+	}
+
+	f.entries[entry.key] = f.lru.PushFront(entry)
+	if f.byPath[entry.key.path] == nil {
+		f.byPath[entry.key.path] = map[cacheKey]bool{}
+	}
+	f.byPath[entry.key.path][entry.key] = true
+	f.bytes += entry.size
+
+	for f.bytes > f.maxBytes {
+		f.remove(f.lru.Back())
+		f.stats.Evictions++
+	}
+}
+
+// remove drops element from the cache, callers hold mu
+func (f *CachingFileIo) remove(element *list.Element) {
+	entry := f.lru.Remove(element).(*cacheEntry)
+	delete(f.entries, entry.key)
+	delete(f.byPath[entry.key.path], entry.key)
+	if len(f.byPath[entry.key.path]) == 0 {
+		delete(f.byPath, entry.key.path)
+	}
+	f.bytes -= entry.size
+}
+
+func (f *CachingFileIo) invalidating(path string, cleanup CleanupFunc) CleanupFunc {
+	return func() error {
+		defer f.Invalidate(path)
+		return cleanup()
+	}
+}
+
+// invalidatingWriter calls invalidate once the file it writes is closed
+type invalidatingWriter struct {
+	io.WriteCloser
+	invalidate func()
+}
+
+func (w *invalidatingWriter) Close() error {
+	defer w.invalidate()
+	return w.WriteCloser.Close()
+}
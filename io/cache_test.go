@@ -0,0 +1,206 @@
+package io
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestCache returns a cache over a memory backend whose calls are counted
+// in the returned sink
+func newTestCache(t *testing.T, options ...CacheOption) (*MemoryFileIo, *MemoryMetricsSink, *CachingFileIo) {
+	memory := NewMemoryFileIo()
+	assert.NoError(t, memory.CreateDir("/config", 0o755))
+	assert.NoError(t, memory.WriteFile("/config/app.yaml", []byte("name: app"), 0o644))
+	sink := NewMemoryMetricsSink()
+	cache := NewCachingFileIo(NewInstrumentedFileIo(memory, WithMetricsSink(sink)), options...)
+
+	return memory, sink, cache
+}
+
+func backendCalls(sink *MemoryMetricsSink, method string) int64 {
+	return sink.Snapshot()[method].Calls
+}
+
+func TestCachingFileIo_ReadThrough(t *testing.T) {
+	_, sink, cache := newTestCache(t)
+
+	for i := 0; i < 3; i++ {
+		content, err := cache.ReadFile("/config/app.yaml")
+		assert.NoError(t, err)
+		assert.Equal(t, "name: app", string(content))
+		content[0] = 'X'
+
+		info, err := cache.FileInfo("/config/app.yaml")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(9), info.Size())
+
+		assert.True(t, cache.FileExists("/config/app.yaml"))
+		assert.False(t, cache.FileExists("/config/missing.yaml"))
+
+		checksum, err := cache.Checksum("/config/app.yaml", ChecksumMD5)
+		assert.NoError(t, err)
+		assert.Len(t, checksum, 32)
+	}
+
+	assert.Equal(t, int64(1), backendCalls(sink, "ReadFile"))
+	assert.Equal(t, int64(1), backendCalls(sink, "FileInfo"))
+	assert.Equal(t, int64(2), backendCalls(sink, "FileExists"))
+	assert.Equal(t, int64(1), backendCalls(sink, "Checksum"))
+
+	partial, err := cache.ReadBufferedFile("/config/app.yaml", 6, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "app", string(partial))
+	assert.Zero(t, backendCalls(sink, "ReadBufferedFile"))
+
+	stats := cache.Stats()
+	assert.Equal(t, int64(11), stats.Hits)
+	assert.Equal(t, int64(5), stats.Misses)
+	assert.Equal(t, 5, stats.Entries)
+
+	_, err = cache.ReadFile("/config/missing.yaml")
+	assert.Error(t, err)
+	_, err = cache.ReadFile("/config/missing.yaml")
+	assert.Error(t, err)
+	assert.Equal(t, int64(3), backendCalls(sink, "ReadFile"))
+}
+
+func TestCachingFileIo_Invalidation(t *testing.T) {
+	memory, sink, cache := newTestCache(t)
+
+	_, _ = cache.ReadFile("/config/app.yaml")
+	assert.False(t, cache.FileExists("/config/new.yaml"))
+
+	assert.NoError(t, cache.WriteFile("/config/app.yaml", []byte("name: changed"), 0o644))
+	content, _ := cache.ReadFile("/config/app.yaml")
+	assert.Equal(t, "name: changed", string(content))
+
+	assert.NoError(t, cache.CopyFile("/config/app.yaml", "/config/new.yaml"))
+	assert.True(t, cache.FileExists("/config/new.yaml"))
+
+	writer, err := cache.CreateFile("/config/app.yaml", 0o644)
+	assert.NoError(t, err)
+	_, _ = cache.ReadFile("/config/app.yaml")
+	_, _ = io.WriteString(writer, "name: streamed")
+	assert.NoError(t, writer.Close())
+	content, _ = cache.ReadFile("/config/app.yaml")
+	assert.Equal(t, "name: streamed", string(content))
+
+	assert.NoError(t, cache.Move("/config", "/moved"))
+	assert.False(t, cache.FileExists("/config/app.yaml"))
+	_, err = cache.ReadFile("/config/app.yaml")
+	assert.Error(t, err)
+
+	_, _ = cache.ReadFile("/moved/app.yaml")
+	assert.NoError(t, cache.DeleteDir("/moved"))
+	_, err = cache.FileInfo("/moved/app.yaml")
+	assert.Error(t, err)
+
+	assert.NoError(t, memory.WriteFile("/direct.txt", []byte("one"), 0o644))
+	_, _ = cache.ReadFile("/direct.txt")
+	assert.NoError(t, memory.WriteFile("/direct.txt", []byte("two"), 0o644))
+	content, _ = cache.ReadFile("/direct.txt")
+	assert.Equal(t, "one", string(content))
+	cache.Invalidate("/direct.txt")
+	content, _ = cache.ReadFile("/direct.txt")
+	assert.Equal(t, "two", string(content))
+
+	cache.Purge()
+	assert.Zero(t, cache.Stats().Entries)
+	assert.Positive(t, backendCalls(sink, "ReadFile"))
+}
+
+func TestCachingFileIo_InvalidatesParents(t *testing.T) {
+	memory := NewMemoryFileIo()
+	cache := NewCachingFileIo(memory)
+
+	assert.False(t, cache.FileExists("/a"))
+	assert.NoError(t, cache.CreateDirAll("/a/b/c", 0o755))
+	assert.True(t, cache.FileExists("/a"))
+	assert.True(t, cache.FileExists("/a/b"))
+
+	_, err := cache.FileInfo("/a/b")
+	assert.NoError(t, err)
+	assert.NoError(t, memory.WriteFile("/a/b/c/data.txt", []byte("data"), 0o644))
+	_, _ = cache.ReadFile("/a/b/c/data.txt")
+
+	misses := cache.Stats().Misses
+	assert.NoError(t, cache.WriteFile("/a/b/c/other.txt", []byte("other"), 0o644))
+	_, err = cache.FileInfo("/a/b")
+	assert.NoError(t, err)
+	assert.True(t, cache.FileExists("/a"))
+	_, _ = cache.ReadFile("/a/b/c/data.txt")
+	assert.Equal(t, misses+2, cache.Stats().Misses)
+
+	assert.False(t, cache.FileExists("/copy"))
+	assert.NoError(t, cache.CopyDir("/a/b", "/copy/b"))
+	assert.True(t, cache.FileExists("/copy"))
+
+	assert.False(t, cache.FileExists("/moved"))
+	assert.NoError(t, cache.CreateDir("/moved", 0o755))
+	assert.False(t, cache.FileExists("/moved/b"))
+	assert.NoError(t, cache.Move("/copy/b", "/moved/b"))
+	assert.True(t, cache.FileExists("/moved/b"))
+}
+
+func TestCachingFileIo_LRU(t *testing.T) {
+	memory, sink, cache := newTestCache(t, WithCacheSize(3*(100+cacheEntryOverhead+7)))
+	for _, name := range []string{"/a.txt", "/b.txt", "/c.txt", "/d.txt", "/e.txt"} {
+		assert.NoError(t, memory.WriteFile(name, make([]byte, 100), 0o644))
+	}
+
+	_, _ = cache.ReadFile("/a.txt")
+	_, _ = cache.ReadFile("/b.txt")
+	_, _ = cache.ReadFile("/c.txt")
+	_, _ = cache.ReadFile("/a.txt")
+	_, _ = cache.ReadFile("/d.txt")
+	assert.Equal(t, int64(4), backendCalls(sink, "ReadFile"))
+
+	stats := cache.Stats()
+	assert.Equal(t, 3, stats.Entries)
+	assert.Equal(t, int64(1), stats.Evictions)
+	assert.LessOrEqual(t, stats.Bytes, int64(3*(100+cacheEntryOverhead+7)))
+
+	_, _ = cache.ReadFile("/a.txt")
+	assert.Equal(t, int64(4), backendCalls(sink, "ReadFile"))
+	_, _ = cache.ReadFile("/b.txt")
+	assert.Equal(t, int64(5), backendCalls(sink, "ReadFile"))
+
+	assert.NoError(t, memory.WriteFile("/huge.txt", make([]byte, 4096), 0o644))
+	_, _ = cache.ReadFile("/huge.txt")
+	_, _ = cache.ReadFile("/huge.txt")
+	assert.Equal(t, int64(7), backendCalls(sink, "ReadFile"))
+}
+
+func TestCachingFileIo_TTL(t *testing.T) {
+	_, sink, cache := newTestCache(t, WithCacheTTL(time.Minute))
+	current := time.Unix(0, 0)
+	cache.now = func() time.Time { return current }
+
+	_, _ = cache.ReadFile("/config/app.yaml")
+	current = current.Add(59 * time.Second)
+	_, _ = cache.ReadFile("/config/app.yaml")
+	assert.Equal(t, int64(1), backendCalls(sink, "ReadFile"))
+
+	current = current.Add(time.Second)
+	_, _ = cache.ReadFile("/config/app.yaml")
+	assert.Equal(t, int64(2), backendCalls(sink, "ReadFile"))
+}
+
+func TestCachingFileIo_MtimeRevalidation(t *testing.T) {
+	memory, sink, cache := newTestCache(t, WithMtimeRevalidation())
+
+	content, _ := cache.ReadFile("/config/app.yaml")
+	assert.Equal(t, "name: app", string(content))
+	content, _ = cache.ReadFile("/config/app.yaml")
+	assert.Equal(t, "name: app", string(content))
+	assert.Equal(t, int64(1), backendCalls(sink, "ReadFile"))
+
+	time.Sleep(10 * time.Millisecond)
+	assert.NoError(t, memory.WriteFile("/config/app.yaml", []byte("name: edited"), 0o644))
+	content, _ = cache.ReadFile("/config/app.yaml")
+	assert.Equal(t, "name: edited", string(content))
+	assert.Equal(t, int64(2), backendCalls(sink, "ReadFile"))
+}
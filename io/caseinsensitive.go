@@ -0,0 +1,289 @@
+package io
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// CaseInsensitiveFileIo wraps a FileIo, usually Default() on Linux, and
+// emulates the case-insensitive, case-preserving paths of macOS and Windows.
+// Paths are resolved against the existing entries whatever their case, names
+// keep the case they were created with, and writing a file or moving an entry
+// onto a name that only differs in case from an existing entry fails with
+// ErrCaseConflict. Every lookup lists the parent directories, it is meant to
+// catch case bugs in tests rather than to be fast.
+type CaseInsensitiveFileIo struct {
+	fileIo FileIo
+}
+
+// NewCaseInsensitiveFileIo returns a FileIo with case-insensitive paths on top
+// of fileIo
+func NewCaseInsensitiveFileIo(fileIo FileIo) CaseInsensitiveFileIo {
+	return CaseInsensitiveFileIo{fileIo: fileIo}
+}
+
+func (f CaseInsensitiveFileIo) GetOperatingSystem() OperatingSystem {
+	return f.fileIo.GetOperatingSystem()
+}
+
+func (f CaseInsensitiveFileIo) FileExists(path string) bool {
+	resolved, _ := f.resolve(path)
+	return f.fileIo.FileExists(resolved)
+}
+
+func (f CaseInsensitiveFileIo) DirExists(folderPath string) bool {
+	resolved, _ := f.resolve(folderPath)
+	return f.fileIo.DirExists(resolved)
+}
+
+func (f CaseInsensitiveFileIo) CreateDir(folderPath string, mode fs.FileMode) error {
+	resolved, err := f.writable("CreateDir", folderPath)
+	if err != nil {
+		return err
+	}
+
+	return f.fileIo.CreateDir(resolved, mode)
+}
+
+func (f CaseInsensitiveFileIo) CreateDirAll(folderPath string, mode fs.FileMode, parentMode ...fs.FileMode) error {
+	resolved, err := f.writable("CreateDirAll", folderPath)
+	if err != nil {
+		return err
+	}
+
+	return f.fileIo.CreateDirAll(resolved, mode, parentMode...)
+}
+
+func (f CaseInsensitiveFileIo) EnsureDir(folderPath string, mode fs.FileMode) error {
+	resolved, err := f.writable("EnsureDir", folderPath)
+	if err != nil {
+		return err
+	}
+
+	return f.fileIo.EnsureDir(resolved, mode)
+}
+
+func (f CaseInsensitiveFileIo) GetExecutionPath() string {
+	return f.fileIo.GetExecutionPath()
+}
+
+func (f CaseInsensitiveFileIo) ToOsPath(path string) string {
+	return f.fileIo.ToOsPath(path)
+}
+
+func (f CaseInsensitiveFileIo) GetOsPathSeparator() string {
+	return f.fileIo.GetOsPathSeparator()
+}
+
+func (f CaseInsensitiveFileIo) ReadFile(path string) ([]byte, error) {
+	resolved, _ := f.resolve(path)
+	return f.fileIo.ReadFile(resolved)
+}
+
+func (f CaseInsensitiveFileIo) ReadBufferedFile(path string, from, to int) ([]byte, error) {
+	resolved, _ := f.resolve(path)
+	return f.fileIo.ReadBufferedFile(resolved, from, to)
+}
+
+func (f CaseInsensitiveFileIo) WriteFile(path string, data []byte, mode os.FileMode) error {
+	resolved, err := f.writable("WriteFile", path)
+	if err != nil {
+		return err
+	}
+
+	return f.fileIo.WriteFile(resolved, data, mode)
+}
+
+func (f CaseInsensitiveFileIo) WriteBufferedFile(path string, data []byte, bufferSize int, mode os.FileMode) error {
+	resolved, err := f.writable("WriteBufferedFile", path)
+	if err != nil {
+		return err
+	}
+
+	return f.fileIo.WriteBufferedFile(resolved, data, bufferSize, mode)
+}
+
+func (f CaseInsensitiveFileIo) ReadDir(path string) ([]fs.DirEntry, error) {
+	resolved, _ := f.resolve(path)
+	return f.fileIo.ReadDir(resolved)
+}
+
+func (f CaseInsensitiveFileIo) JoinPath(parts ...string) string {
+	return f.fileIo.JoinPath(parts...)
+}
+
+func (f CaseInsensitiveFileIo) CopyFile(source, destination string) error {
+	resolvedSource, _ := f.resolve(source)
+	resolvedDestination, err := f.writable("CopyFile", destination)
+	if err != nil {
+		return err
+	}
+
+	return f.fileIo.CopyFile(resolvedSource, resolvedDestination)
+}
+
+func (f CaseInsensitiveFileIo) DeleteFile(path string) error {
+	resolved, _ := f.resolve(path)
+	return f.fileIo.DeleteFile(resolved)
+}
+
+// Move renames source to destination. When both name the same entry only the
+// case of its name changes, like a rename on macOS or Windows.
+func (f CaseInsensitiveFileIo) Move(source, destination string, policy ...OverwritePolicy) error {
+	overwrite, err := overwritePolicy(policy)
+	if err != nil {
+		return NewFileIoLinkError(BackendCaseInsensitive, "Move", source, destination, err)
+	}
+
+	flavour := f.fileIo.GetOperatingSystem().PathFlavour()
+	resolvedSource, _ := f.resolve(source)
+	resolvedDestination, exists := f.resolve(destination)
+
+	if exists && resolvedSource == resolvedDestination {
+		renamed := flavour.Join(flavour.Dir(resolvedSource), flavour.Base(flavour.Clean(destination)))
+		if renamed == resolvedSource {
+			return nil
+		}
+		return f.fileIo.Move(resolvedSource, renamed, overwrite)
+	}
+
+	if exists && overwrite != OverwriteMerge && flavour.Base(resolvedDestination) != flavour.Base(flavour.Clean(destination)) {
+		return NewFileIoLinkError(BackendCaseInsensitive, "Move", source, destination, ErrCaseConflict)
+	}
+
+	return f.fileIo.Move(resolvedSource, resolvedDestination, overwrite)
+}
+
+// CopyDir copies source entry by entry, so the names copied into an existing
+// destination are checked for case conflicts
+func (f CaseInsensitiveFileIo) CopyDir(source, destination string) error {
+	info, err := f.FileInfo(source)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return NewFileIoLinkError(BackendCaseInsensitive, "CopyDir", source, destination, ErrNotDirectory)
+	}
+
+	if err := f.CreateDirAll(destination, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	entries, err := f.ReadDir(source)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		sourcePath := f.JoinPath(source, entry.Name())
+		destinationPath := f.JoinPath(destination, entry.Name())
+		if entry.IsDir() {
+			err = f.CopyDir(sourcePath, destinationPath)
+		} else {
+			err = f.CopyFile(sourcePath, destinationPath)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f CaseInsensitiveFileIo) DeleteDir(path string) error {
+	resolved, _ := f.resolve(path)
+	return f.fileIo.DeleteDir(resolved)
+}
+
+func (f CaseInsensitiveFileIo) Checksum(path string, method ChecksumMethod) (string, error) {
+	resolved, _ := f.resolve(path)
+	return f.fileIo.Checksum(resolved, method)
+}
+
+func (f CaseInsensitiveFileIo) FileInfo(path string) (os.FileInfo, error) {
+	resolved, _ := f.resolve(path)
+	return f.fileIo.FileInfo(resolved)
+}
+
+func (f CaseInsensitiveFileIo) TempDir(dir, pattern string) (string, CleanupFunc, error) {
+	if dir != "" {
+		dir, _ = f.resolve(dir)
+	}
+
+	return f.fileIo.TempDir(dir, pattern)
+}
+
+func (f CaseInsensitiveFileIo) TempFile(dir, pattern string) (string, CleanupFunc, error) {
+	if dir != "" {
+		dir, _ = f.resolve(dir)
+	}
+
+	return f.fileIo.TempFile(dir, pattern)
+}
+
+func (f CaseInsensitiveFileIo) OpenFile(path string) (io.ReadCloser, error) {
+	resolved, _ := f.resolve(path)
+	return OpenReader(f.fileIo, resolved)
+}
+
+func (f CaseInsensitiveFileIo) CreateFile(path string, mode fs.FileMode) (io.WriteCloser, error) {
+	resolved, err := f.writable("CreateFile", path)
+	if err != nil {
+		return nil, err
+	}
+
+	return CreateWriter(f.fileIo, resolved, mode)
+}
+
+// resolve returns path with the case of the entries existing on the backend,
+// the missing part keeps the case it was given in, and whether path exists
+func (f CaseInsensitiveFileIo) resolve(path string) (string, bool) {
+	flavour := f.fileIo.GetOperatingSystem().PathFlavour()
+	path = flavour.Clean(path)
+	if f.fileIo.FileExists(path) {
+		return path, true
+	}
+
+	parent := flavour.Dir(path)
+	if parent == path {
+		return path, false
+	}
+
+	name := flavour.Base(path)
+	resolvedParent, exists := f.resolve(parent)
+	if !exists {
+		return flavour.Join(resolvedParent, name), false
+	}
+
+	entries, err := f.fileIo.ReadDir(resolvedParent)
+	if err == nil {
+		for _, entry := range entries {
+			if foldCase(entry.Name()) == foldCase(name) {
+				return flavour.Join(resolvedParent, entry.Name()), true
+			}
+		}
+	}
+
+	return flavour.Join(resolvedParent, name), false
+}
+
+// writable resolves a file or directory about to be created, it fails with ErrCaseConflict
+// when an existing entry has the same name in a different case
+func (f CaseInsensitiveFileIo) writable(op, path string) (string, error) {
+	flavour := f.fileIo.GetOperatingSystem().PathFlavour()
+	resolved, exists := f.resolve(path)
+	if exists && flavour.Base(resolved) != flavour.Base(flavour.Clean(path)) {
+		return "", NewFileIoError(BackendCaseInsensitive, op, path, ErrCaseConflict)
+	}
+
+	return resolved, nil
+}
+
+// foldCase returns the form paths are compared in when case is ignored, every
+// case-insensitive lookup goes through it so names fold one way
+func foldCase(path string) string {
+	return strings.ToLower(path)
+}
@@ -0,0 +1,147 @@
+package io
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func caseInsensitiveBackends(t *testing.T) map[string]func() (FileIo, string) {
+	return map[string]func() (FileIo, string){
+		"decorator": func() (FileIo, string) {
+			return NewCaseInsensitiveFileIo(Default()), t.TempDir()
+		},
+		"memory": func() (FileIo, string) {
+			return NewMemoryFileIo(WithCaseInsensitivePaths()), "/"
+		},
+	}
+}
+
+func TestCaseInsensitive_Lookups(t *testing.T) {
+	for name, backend := range caseInsensitiveBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			fileIo, root := backend()
+			join := func(parts ...string) string {
+				return fileIo.JoinPath(append([]string{root}, parts...)...)
+			}
+
+			assert.NoError(t, fileIo.CreateDirAll(join("Config", "Apps"), 0o755))
+			assert.NoError(t, fileIo.WriteFile(join("config", "APPS", "Settings.json"), []byte("{}"), 0o644))
+
+			assert.True(t, fileIo.FileExists(join("CONFIG", "apps", "settings.JSON")))
+			assert.True(t, fileIo.DirExists(join("config", "apps")))
+			content, err := fileIo.ReadFile(join("CONFIG", "Apps", "SETTINGS.json"))
+			assert.NoError(t, err)
+			assert.Equal(t, "{}", string(content))
+
+			info, err := fileIo.FileInfo(join("config", "apps", "settings.json"))
+			assert.NoError(t, err)
+			assert.Equal(t, "Settings.json", info.Name())
+
+			// both backends fold names the same way, the long s only matches itself
+			assert.NoError(t, fileIo.WriteFile(join("Config", "s.txt"), []byte("s"), 0o644))
+			assert.False(t, fileIo.FileExists(join("Config", "\u017f.txt")))
+			assert.True(t, fileIo.FileExists(join("Config", "S.txt")))
+			assert.NoError(t, fileIo.DeleteFile(join("Config", "s.txt")))
+
+			entries, err := fileIo.ReadDir(join("CONFIG"))
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"Apps"}, entryNames(entries))
+			entries, err = fileIo.ReadDir(join("config", "apps"))
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"Settings.json"}, entryNames(entries))
+
+			assert.NoError(t, fileIo.DeleteFile(join("CONFIG", "APPS", "SETTINGS.JSON")))
+			assert.False(t, fileIo.FileExists(join("Config", "Apps", "Settings.json")))
+			assert.NoError(t, fileIo.DeleteDir(join("config")))
+			assert.False(t, fileIo.DirExists(join("Config")))
+		})
+	}
+}
+
+func TestCaseInsensitive_Conflicts(t *testing.T) {
+	for name, backend := range caseInsensitiveBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			fileIo, root := backend()
+			join := func(parts ...string) string {
+				return fileIo.JoinPath(append([]string{root}, parts...)...)
+			}
+
+			assert.NoError(t, fileIo.CreateDir(join("Data"), 0o755))
+			assert.NoError(t, fileIo.WriteFile(join("Data", "Report.txt"), []byte("one"), 0o644))
+
+			err := fileIo.WriteFile(join("data", "report.txt"), []byte("two"), 0o644)
+			assert.ErrorIs(t, err, ErrCaseConflict)
+			assert.ErrorIs(t, err, fs.ErrExist)
+			_, err = CreateWriter(fileIo, join("Data", "REPORT.txt"), 0o644)
+			assert.ErrorIs(t, err, ErrCaseConflict)
+			assert.ErrorIs(t, fileIo.CreateDir(join("DATA"), 0o755), ErrCaseConflict)
+			assert.ErrorIs(t, fileIo.CreateDirAll(join("DATA"), 0o755), ErrCaseConflict)
+			assert.ErrorIs(t, fileIo.EnsureDir(join("DATA"), 0o755), ErrCaseConflict)
+			assert.ErrorIs(t, fileIo.CreateDir(join("Data"), 0o755), fs.ErrExist)
+			assert.NoError(t, fileIo.EnsureDir(join("Data"), 0o755))
+
+			assert.NoError(t, fileIo.WriteFile(join("DATA", "Report.txt"), []byte("three"), 0o644))
+			assert.NoError(t, fileIo.CreateDirAll(join("data", "Archive"), 0o755))
+			entries, err := fileIo.ReadDir(join("Data"))
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"Archive", "Report.txt"}, entryNames(entries))
+
+			assert.NoError(t, fileIo.WriteFile(join("notes.txt"), []byte("notes"), 0o644))
+			assert.ErrorIs(t, fileIo.CopyFile(join("notes.txt"), join("data", "report.TXT")), ErrCaseConflict)
+			assert.ErrorIs(t, fileIo.Move(join("notes.txt"), join("data", "REPORT.txt"), OverwriteReplace), ErrCaseConflict)
+			assert.ErrorIs(t, fileIo.Move(join("notes.txt"), join("data", "REPORT.txt"), OverwriteReplace, OverwriteMerge), ErrTooManyPolicies)
+
+			assert.NoError(t, fileIo.CreateDirAll(join("Backup"), 0o755))
+			assert.NoError(t, fileIo.WriteFile(join("Backup", "report.txt"), []byte("backup"), 0o644))
+			assert.ErrorIs(t, fileIo.CopyDir(join("backup"), join("data")), ErrCaseConflict)
+
+			content, err := fileIo.ReadFile(join("data", "report.txt"))
+			assert.NoError(t, err)
+			assert.Equal(t, "three", string(content))
+		})
+	}
+}
+
+func TestCaseInsensitive_CaseOnlyRename(t *testing.T) {
+	for name, backend := range caseInsensitiveBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			fileIo, root := backend()
+			join := func(parts ...string) string {
+				return fileIo.JoinPath(append([]string{root}, parts...)...)
+			}
+
+			assert.NoError(t, fileIo.CreateDirAll(join("src", "lib"), 0o755))
+			assert.NoError(t, fileIo.WriteFile(join("src", "lib", "readme.md"), []byte("docs"), 0o644))
+
+			assert.NoError(t, fileIo.Move(join("SRC", "lib", "readme.md"), join("src", "lib", "README.md")))
+			entries, err := fileIo.ReadDir(join("src", "lib"))
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"README.md"}, entryNames(entries))
+
+			assert.NoError(t, fileIo.Move(join("src"), join("Src")))
+			entries, err = fileIo.ReadDir(root)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"Src"}, entryNames(entries))
+
+			content, err := fileIo.ReadFile(join("src", "LIB", "readme.MD"))
+			assert.NoError(t, err)
+			assert.Equal(t, "docs", string(content))
+			info, err := fileIo.FileInfo(join("SRC", "lib", "readme.md"))
+			assert.NoError(t, err)
+			assert.Equal(t, "README.md", info.Name())
+		})
+	}
+}
+
+func TestMemoryFileIo_CaseSensitiveByDefault(t *testing.T) {
+	memory := NewMemoryFileIo()
+	assert.NoError(t, memory.WriteFile("/Readme.md", []byte("upper"), 0o644))
+	assert.NoError(t, memory.WriteFile("/readme.md", []byte("lower"), 0o644))
+
+	assert.False(t, memory.FileExists("/README.md"))
+	entries, err := memory.ReadDir("/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Readme.md", "readme.md"}, entryNames(entries))
+}
@@ -0,0 +1,229 @@
+package io
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"time"
+)
+
+// CassetteVersion is the format version written in new cassettes
+const CassetteVersion = 1
+
+// Cassette holds the FileIo calls captured by a RecordingFileIo, in call
+// order, so a ReplayFileIo can serve them again. It is saved as JSON.
+type Cassette struct {
+	Version         int             `json:"version"`
+	OperatingSystem OperatingSystem `json:"operatingSystem"`
+	ExecutionPath   string          `json:"executionPath,omitempty"`
+	Calls           []CassetteCall  `json:"calls"`
+}
+
+// CassetteCall is one recorded call, Args holds the JSON array of its
+// arguments
+type CassetteCall struct {
+	Method string          `json:"method"`
+	Args   json.RawMessage `json:"args"`
+	Result CassetteResult  `json:"result"`
+}
+
+// CassetteResult holds what a recorded call returned, only the fields used
+// by its method are set
+type CassetteResult struct {
+	Bool    bool               `json:"bool,omitempty"`
+	String  string             `json:"string,omitempty"`
+	Data    []byte             `json:"data,omitempty"`
+	Info    *CassetteFileInfo  `json:"info,omitempty"`
+	Entries []CassetteFileInfo `json:"entries,omitempty"`
+	Error   *CassetteError     `json:"error,omitempty"`
+	// CloseError is the error returned when closing a recorded stream, Error
+	// being the error of opening it
+	CloseError *CassetteError `json:"closeError,omitempty"`
+}
+
+// CassetteFileInfo is a recorded fs.FileInfo, it also serves as the
+// fs.DirEntry of a replayed ReadDir
+type CassetteFileInfo struct {
+	FileName    string      `json:"name"`
+	FileSize    int64       `json:"size"`
+	FileMode    fs.FileMode `json:"mode"`
+	FileModTime time.Time   `json:"modTime"`
+}
+
+func (i CassetteFileInfo) Name() string               { return i.FileName }
+func (i CassetteFileInfo) Size() int64                { return i.FileSize }
+func (i CassetteFileInfo) Mode() fs.FileMode          { return i.FileMode }
+func (i CassetteFileInfo) ModTime() time.Time         { return i.FileModTime }
+func (i CassetteFileInfo) IsDir() bool                { return i.FileMode.IsDir() }
+func (i CassetteFileInfo) Sys() any                   { return nil }
+func (i CassetteFileInfo) Type() fs.FileMode          { return i.FileMode.Type() }
+func (i CassetteFileInfo) Info() (fs.FileInfo, error) { return i, nil }
+
+// CassetteError is a recorded error. Kind names the package or fs sentinel it
+// matched so the replayed error still satisfies errors.Is, and the FileIoError
+// details are kept when there were some.
+type CassetteError struct {
+	Message     string `json:"message"`
+	Kind        string `json:"kind,omitempty"`
+	Op          string `json:"op,omitempty"`
+	Path        string `json:"path,omitempty"`
+	Destination string `json:"destination,omitempty"`
+	Backend     string `json:"backend,omitempty"`
+}
+
+// cassetteErrorKinds are checked in order, more specific sentinels first
+var cassetteErrorKinds = []struct {
+	kind string
+	err  error
+}{
+	{"read-only", ErrReadOnly},
+	{"case-conflict", ErrCaseConflict},
+	{"quota-exceeded", ErrQuotaExceeded},
+	{"path-escapes-root", ErrPathEscapesRoot},
+	{"unexpected-call", ErrUnexpectedCall},
+	{"not-encrypted", ErrNotEncrypted},
+	{"unknown-key", ErrUnknownKey},
+	{"authentication-failed", ErrAuthenticationFailed},
+	{"is-directory", ErrIsDirectory},
+	{"not-directory", ErrNotDirectory},
+	{"invalid-range", ErrInvalidRange},
+	{"invalid-checksum-method", ErrInvalidChecksumMethod},
+	{"locked", ErrLocked},
+	{"incompatible-mode", ErrIncompatibleMode},
+	{"verification-failed", ErrVerificationFailed},
+	{"not-exist", fs.ErrNotExist},
+	{"exist", fs.ErrExist},
+	{"permission", fs.ErrPermission},
+	{"closed", fs.ErrClosed},
+	{"invalid", fs.ErrInvalid},
+}
+
+// LoadCassette reads a cassette saved at path on fileIo
+func LoadCassette(fileIo FileIo, path string) (*Cassette, error) {
+	content, err := fileIo.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cassette Cassette
+	if err := json.Unmarshal(content, &cassette); err != nil {
+		return nil, NewFileIoError(BackendReplay, "LoadCassette", path, err)
+	}
+
+	// Save indents the arguments too, they are matched in their compact form
+	for i, call := range cassette.Calls {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, call.Args); err != nil {
+			return nil, NewFileIoError(BackendReplay, "LoadCassette", path, err)
+		}
+		cassette.Calls[i].Args = compact.Bytes()
+	}
+
+	return &cassette, nil
+}
+
+// Save writes the cassette as indented JSON at path on fileIo
+func (c *Cassette) Save(fileIo FileIo, path string) error {
+	content, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return NewFileIoError(BackendReplay, "SaveCassette", path, err)
+	}
+
+	return fileIo.WriteFile(path, append(content, '\n'), 0o644)
+}
+
+func newCassetteFileInfo(info fs.FileInfo) *CassetteFileInfo {
+	if info == nil {
+		return nil
+	}
+
+	return &CassetteFileInfo{
+		FileName:    info.Name(),
+		FileSize:    info.Size(),
+		FileMode:    info.Mode(),
+		FileModTime: info.ModTime(),
+	}
+}
+
+func newCassetteError(err error) *CassetteError {
+	if err == nil {
+		return nil
+	}
+
+	result := &CassetteError{Message: err.Error()}
+	var fileIoErr *FileIoError
+	if errors.As(err, &fileIoErr) {
+		result.Op = fileIoErr.Op
+		result.Path = fileIoErr.Path
+		result.Destination = fileIoErr.Destination
+		result.Backend = fileIoErr.Backend
+		if fileIoErr.Err != nil {
+			result.Message = fileIoErr.Err.Error()
+		}
+	}
+
+	for _, candidate := range cassetteErrorKinds {
+		if errors.Is(err, candidate.err) {
+			result.Kind = candidate.kind
+			break
+		}
+	}
+
+	return result
+}
+
+// err rebuilds the recorded error
+func (e *CassetteError) err() error {
+	if e == nil {
+		return nil
+	}
+
+	cause := &replayedError{message: e.Message}
+	for _, candidate := range cassetteErrorKinds {
+		if candidate.kind == e.Kind {
+			cause.kind = candidate.err
+			break
+		}
+	}
+
+	if e.Op == "" {
+		return cause
+	}
+
+	return &FileIoError{
+		Op:          e.Op,
+		Path:        e.Path,
+		Destination: e.Destination,
+		Backend:     e.Backend,
+		Err:         cause,
+	}
+}
+
+// replayedError carries a recorded message and the sentinel it matched
+type replayedError struct {
+	message string
+	kind    error
+}
+
+func (e *replayedError) Error() string {
+	return e.message
+}
+
+func (e *replayedError) Unwrap() error {
+	return e.kind
+}
+
+// encodeArgs returns the JSON array used to record and match call arguments
+func encodeArgs(args ...any) json.RawMessage {
+	if args == nil {
+		args = []any{}
+	}
+
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return json.RawMessage("null")
+	}
+
+	return encoded
+}
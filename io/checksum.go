@@ -1,5 +1,14 @@
 package io
 
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+)
+
 type ChecksumMethod int
 
 const (
@@ -7,3 +16,31 @@ const (
 	ChecksumSHA1
 	ChecksumSHA256
 )
+
+func newChecksumHash(method ChecksumMethod) (hash.Hash, error) {
+	switch method {
+	case ChecksumMD5:
+		return md5.New(), nil
+	case ChecksumSHA1:
+		return sha1.New(), nil
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	default:
+		return nil, ErrInvalidChecksumMethod
+	}
+}
+
+// checksumReader returns the hex encoded checksum of everything read from
+// reader
+func checksumReader(reader io.Reader, method ChecksumMethod) (string, error) {
+	hash, err := newChecksumHash(method)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := io.Copy(hash, reader); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
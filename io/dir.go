@@ -0,0 +1,90 @@
+package io
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// CreateDirAll creates folderPath and any missing parents. The leaf gets mode
+// and created parents get parentMode, which defaults to mode. Modes are applied
+// exactly, ignoring the process umask, and existing directories are left as is.
+func (f DefaultFileIo) CreateDirAll(folderPath string, mode fs.FileMode, parentMode ...fs.FileMode) error {
+	parent := mode
+	if len(parentMode) == 1 {
+		parent = parentMode[0]
+	}
+
+	missing, err := missingDirs(filepath.Clean(folderPath))
+	if err != nil {
+		return NewFileIoError(BackendDefault, "CreateDirAll", folderPath, err)
+	}
+
+	for i := len(missing) - 1; i >= 0; i-- {
+		dirMode := parent
+		if i == 0 {
+			dirMode = mode
+		}
+
+		if err := os.Mkdir(missing[i], dirMode); err != nil {
+			if info, statErr := os.Stat(missing[i]); statErr == nil && info.IsDir() {
+				continue
+			}
+			return NewFileIoError(BackendDefault, "CreateDirAll", missing[i], err)
+		}
+
+		if err := os.Chmod(missing[i], dirMode); err != nil {
+			return NewFileIoError(BackendDefault, "CreateDirAll", missing[i], err)
+		}
+	}
+
+	return nil
+}
+
+// EnsureDir makes sure folderPath is a directory holding at least the
+// permission bits in mode, creating it and its parents when missing
+func (f DefaultFileIo) EnsureDir(folderPath string, mode fs.FileMode) error {
+	info, err := os.Stat(folderPath)
+	if err != nil {
+		return f.CreateDirAll(folderPath, mode)
+	}
+
+	if !info.IsDir() {
+		return NewFileIoError(BackendDefault, "EnsureDir", folderPath, ErrNotDirectory)
+	}
+
+	if info.Mode().Perm()&mode.Perm() != mode.Perm() {
+		return NewFileIoError(BackendDefault, "EnsureDir", folderPath, ErrIncompatibleMode)
+	}
+
+	return nil
+}
+
+// missingDirs walks up from path and returns the directories that do not
+// exist yet, deepest first, failing if an existing component is not a directory
+func missingDirs(path string) ([]string, error) {
+	missing := []string{}
+	current := path
+	for {
+		info, err := os.Stat(current)
+		if err == nil {
+			if !info.IsDir() {
+				return nil, NewFileIoError(BackendDefault, "CreateDirAll", current, ErrNotDirectory)
+			}
+			return missing, nil
+		}
+
+		if !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, syscall.ENOTDIR) {
+			return nil, err
+		}
+
+		missing = append(missing, current)
+		parent := filepath.Dir(current)
+		if parent == current {
+			return missing, nil
+		}
+		current = parent
+	}
+}
@@ -0,0 +1,120 @@
+package io
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func assertDirMode(t *testing.T, path string, expected os.FileMode) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.True(t, info.IsDir())
+	assert.Equal(t, expected, info.Mode().Perm())
+}
+
+func TestCreateDirAll(t *testing.T) {
+	t.Run("Create Nested Directories", func(t *testing.T) {
+		defaultClient := Default()
+		root := t.TempDir()
+		leaf := filepath.Join(root, "a", "b", "c")
+
+		err := defaultClient.CreateDirAll(leaf, 0o700, 0o755)
+		assert.NoError(t, err)
+		assertDirMode(t, filepath.Join(root, "a"), 0o755)
+		assertDirMode(t, filepath.Join(root, "a", "b"), 0o755)
+		assertDirMode(t, leaf, 0o700)
+	})
+
+	t.Run("Parent Mode Defaults To Mode", func(t *testing.T) {
+		defaultClient := Default()
+		root := t.TempDir()
+		leaf := filepath.Join(root, "a", "b")
+
+		err := defaultClient.CreateDirAll(leaf, 0o750)
+		assert.NoError(t, err)
+		assertDirMode(t, filepath.Join(root, "a"), 0o750)
+		assertDirMode(t, leaf, 0o750)
+	})
+
+	t.Run("Mode Ignores Umask", func(t *testing.T) {
+		defaultClient := Default()
+		leaf := filepath.Join(t.TempDir(), "shared")
+
+		err := defaultClient.CreateDirAll(leaf, 0o777)
+		assert.NoError(t, err)
+		assertDirMode(t, leaf, 0o777)
+	})
+
+	t.Run("Existing Directory", func(t *testing.T) {
+		defaultClient := Default()
+		root := t.TempDir()
+		assert.NoError(t, os.Mkdir(filepath.Join(root, "a"), 0o755))
+
+		err := defaultClient.CreateDirAll(filepath.Join(root, "a"), 0o700)
+		assert.NoError(t, err)
+		assertDirMode(t, filepath.Join(root, "a"), 0o755)
+	})
+
+	t.Run("Path Component Is A File", func(t *testing.T) {
+		defaultClient := Default()
+		root := t.TempDir()
+		file := filepath.Join(root, "file")
+		assert.NoError(t, os.WriteFile(file, []byte("data"), 0o644))
+
+		err := defaultClient.CreateDirAll(filepath.Join(file, "a", "b"), 0o755)
+		assert.ErrorIs(t, err, ErrNotDirectory)
+		var fileIoErr *FileIoError
+		assert.ErrorAs(t, err, &fileIoErr)
+		assert.Equal(t, file, fileIoErr.Path)
+	})
+}
+
+func TestEnsureDir(t *testing.T) {
+	t.Run("Creates Missing Directory", func(t *testing.T) {
+		defaultClient := Default()
+		leaf := filepath.Join(t.TempDir(), "a", "b")
+
+		err := defaultClient.EnsureDir(leaf, 0o700)
+		assert.NoError(t, err)
+		assertDirMode(t, leaf, 0o700)
+	})
+
+	t.Run("Idempotent With Compatible Mode", func(t *testing.T) {
+		defaultClient := Default()
+		leaf := filepath.Join(t.TempDir(), "a")
+
+		assert.NoError(t, defaultClient.EnsureDir(leaf, 0o755))
+		assert.NoError(t, defaultClient.EnsureDir(leaf, 0o755))
+		assert.NoError(t, defaultClient.EnsureDir(leaf, 0o700))
+	})
+
+	t.Run("Incompatible Mode", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("permission bits are not enforced on windows")
+		}
+
+		defaultClient := Default()
+		leaf := filepath.Join(t.TempDir(), "a")
+
+		assert.NoError(t, defaultClient.EnsureDir(leaf, 0o700))
+		err := defaultClient.EnsureDir(leaf, 0o755)
+		assert.ErrorIs(t, err, ErrIncompatibleMode)
+	})
+
+	t.Run("Existing File", func(t *testing.T) {
+		defaultClient := Default()
+		file := filepath.Join(t.TempDir(), "file")
+		assert.NoError(t, os.WriteFile(file, []byte("data"), 0o644))
+
+		err := defaultClient.EnsureDir(file, 0o755)
+		assert.ErrorIs(t, err, ErrNotDirectory)
+	})
+}
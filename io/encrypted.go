@@ -0,0 +1,755 @@
+package io
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+)
+
+// DefaultEncryptionChunkSize is the plaintext size of the chunks a file is
+// encrypted in when no other size is given
+const DefaultEncryptionChunkSize = 64 << 10
+
+// Encrypted file layout: the header holds the magic, the format version, the
+// chunk size, a random salt, a random nonce prefix and the id of the key, it
+// is followed by the sealed chunks. Every file is sealed with its own key,
+// derived from the key ring key and the salt, so nonces never repeat across
+// files. Each chunk nonce is the prefix, the chunk counter and a flag set on
+// the last chunk, and the header is authenticated with every chunk, so
+// reordered, truncated or extended content fails to decrypt.
+const (
+	encryptionMagic   = "CGHE"
+	encryptionVersion = 2
+	maxKeyIDLength    = math.MaxUint8
+	maxChunkSize      = 16 << 20
+	saltSize          = 32
+	noncePrefixSize   = 7
+	gcmTagSize        = 16
+	fixedHeaderSize   = len(encryptionMagic) + 1 + 4 + saltSize + noncePrefixSize + 1
+)
+
+// fileKeyInfo binds the derived file keys to this format
+const fileKeyInfo = "cghe file key v2"
+
+// EncryptionOption configures an EncryptedFileIo
+type EncryptionOption func(*EncryptedFileIo)
+
+// WithChunkSize sets the plaintext size of the chunks new files are encrypted
+// in, values outside of 1 byte to 16MiB are ignored. Files keep the chunk
+// size they were written with.
+func WithChunkSize(size int) EncryptionOption {
+	return func(f *EncryptedFileIo) {
+		if size > 0 && size <= maxChunkSize {
+			f.chunkSize = size
+		}
+	}
+}
+
+// EncryptedFileIo wraps a FileIo and encrypts file contents at rest with
+// AES-256-GCM. Content is encrypted in chunks so streams never hold a whole
+// file in memory, and each file records the id of its key so keys can be
+// rotated while older files stay readable. Content that was tampered with
+// fails with ErrAuthenticationFailed. CopyFile, CopyDir and Move copy the
+// encrypted content as is, and ReadDir reports the encrypted sizes while
+// FileInfo reports the plaintext one.
+type EncryptedFileIo struct {
+	fileIo    FileIo
+	keys      KeyProvider
+	chunkSize int
+	random    io.Reader
+}
+
+// NewEncryptedFileIo returns a FileIo encrypting the files of fileIo with the
+// keys of keys
+func NewEncryptedFileIo(fileIo FileIo, keys KeyProvider, options ...EncryptionOption) EncryptedFileIo {
+	result := EncryptedFileIo{
+		fileIo:    fileIo,
+		keys:      keys,
+		chunkSize: DefaultEncryptionChunkSize,
+		random:    rand.Reader,
+	}
+
+	for _, option := range options {
+		option(&result)
+	}
+
+	return result
+}
+
+// KeyID returns the id of the key path was encrypted with
+func (f EncryptedFileIo) KeyID(path string) (string, error) {
+	header, err := f.header("KeyID", path)
+	if err != nil {
+		return "", err
+	}
+
+	return header.keyID, nil
+}
+
+// Rotate re-encrypts path with the current key when it was encrypted with an
+// older one. The new content is written to a temporary directory next to
+// path, so it keeps the mode of path, and then renamed over it.
+func (f EncryptedFileIo) Rotate(path string) error {
+	id, err := f.KeyID(path)
+	if err != nil {
+		return err
+	}
+
+	current, _, err := f.keys.CurrentKey()
+	if err != nil {
+		return NewFileIoError(BackendEncrypted, "Rotate", path, err)
+	}
+	if id == current {
+		return nil
+	}
+
+	info, err := f.fileIo.FileInfo(path)
+	if err != nil {
+		return err
+	}
+
+	reader, err := f.decrypt("Rotate", path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	flavour := f.fileIo.GetOperatingSystem().PathFlavour()
+	dir, cleanup, err := f.fileIo.TempDir(flavour.Dir(path), "."+flavour.Base(path)+".rotating-*")
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	temporary := flavour.Join(dir, flavour.Base(path))
+	writer, err := CreateWriter(f.fileIo, temporary, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	encrypting, err := f.encrypt("Rotate", temporary, writer)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(encrypting, reader); err != nil {
+		encrypting.Close()
+		return NewFileIoError(BackendEncrypted, "Rotate", path, err)
+	}
+	if err := encrypting.Close(); err != nil {
+		return err
+	}
+
+	return f.fileIo.Move(temporary, path, OverwriteReplace)
+}
+
+func (f EncryptedFileIo) GetOperatingSystem() OperatingSystem {
+	return f.fileIo.GetOperatingSystem()
+}
+
+func (f EncryptedFileIo) FileExists(path string) bool {
+	return f.fileIo.FileExists(path)
+}
+
+func (f EncryptedFileIo) DirExists(folderPath string) bool {
+	return f.fileIo.DirExists(folderPath)
+}
+
+func (f EncryptedFileIo) CreateDir(folderPath string, mode fs.FileMode) error {
+	return f.fileIo.CreateDir(folderPath, mode)
+}
+
+func (f EncryptedFileIo) CreateDirAll(folderPath string, mode fs.FileMode, parentMode ...fs.FileMode) error {
+	return f.fileIo.CreateDirAll(folderPath, mode, parentMode...)
+}
+
+func (f EncryptedFileIo) EnsureDir(folderPath string, mode fs.FileMode) error {
+	return f.fileIo.EnsureDir(folderPath, mode)
+}
+
+func (f EncryptedFileIo) GetExecutionPath() string {
+	return f.fileIo.GetExecutionPath()
+}
+
+func (f EncryptedFileIo) ToOsPath(path string) string {
+	return f.fileIo.ToOsPath(path)
+}
+
+func (f EncryptedFileIo) GetOsPathSeparator() string {
+	return f.fileIo.GetOsPathSeparator()
+}
+
+func (f EncryptedFileIo) ReadFile(path string) ([]byte, error) {
+	reader, err := f.decrypt("ReadFile", path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, NewFileIoError(BackendEncrypted, "ReadFile", path, err)
+	}
+
+	return content, nil
+}
+
+// ReadBufferedFile decrypts path up to to, the chunks before from are still
+// decrypted to authenticate them
+func (f EncryptedFileIo) ReadBufferedFile(path string, from, to int) ([]byte, error) {
+	if from < 0 || (to != 0 && from > to) {
+		return nil, NewFileIoError(BackendEncrypted, "ReadBufferedFile", path, ErrInvalidRange)
+	}
+
+	reader, err := f.decrypt("ReadBufferedFile", path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	if _, err := io.CopyN(io.Discard, reader, int64(from)); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = ErrInvalidRange
+		}
+		return nil, NewFileIoError(BackendEncrypted, "ReadBufferedFile", path, err)
+	}
+
+	var limited io.Reader = reader
+	if to != 0 {
+		limited = io.LimitReader(reader, int64(to-from))
+	}
+
+	content, err := io.ReadAll(limited)
+	if err != nil {
+		return nil, NewFileIoError(BackendEncrypted, "ReadBufferedFile", path, err)
+	}
+
+	return content, nil
+}
+
+func (f EncryptedFileIo) WriteFile(path string, data []byte, mode os.FileMode) error {
+	content, err := f.seal("WriteFile", path, data)
+	if err != nil {
+		return err
+	}
+
+	return f.fileIo.WriteFile(path, content, mode)
+}
+
+func (f EncryptedFileIo) WriteBufferedFile(path string, data []byte, bufferSize int, mode os.FileMode) error {
+	content, err := f.seal("WriteBufferedFile", path, data)
+	if err != nil {
+		return err
+	}
+
+	return f.fileIo.WriteBufferedFile(path, content, bufferSize, mode)
+}
+
+func (f EncryptedFileIo) ReadDir(path string) ([]fs.DirEntry, error) {
+	return f.fileIo.ReadDir(path)
+}
+
+func (f EncryptedFileIo) JoinPath(parts ...string) string {
+	return f.fileIo.JoinPath(parts...)
+}
+
+// CopyFile copies the encrypted content, the copy stays encrypted with the
+// key of source
+func (f EncryptedFileIo) CopyFile(source, destination string) error {
+	return f.fileIo.CopyFile(source, destination)
+}
+
+func (f EncryptedFileIo) DeleteFile(path string) error {
+	return f.fileIo.DeleteFile(path)
+}
+
+func (f EncryptedFileIo) Move(source, destination string, policy ...OverwritePolicy) error {
+	return f.fileIo.Move(source, destination, policy...)
+}
+
+func (f EncryptedFileIo) CopyDir(source, destination string) error {
+	return f.fileIo.CopyDir(source, destination)
+}
+
+func (f EncryptedFileIo) DeleteDir(path string) error {
+	return f.fileIo.DeleteDir(path)
+}
+
+// Checksum returns the checksum of the decrypted content
+func (f EncryptedFileIo) Checksum(path string, method ChecksumMethod) (string, error) {
+	if _, err := newChecksumHash(method); err != nil {
+		return "", NewFileIoError(BackendEncrypted, "Checksum", path, err)
+	}
+
+	reader, err := f.decrypt("Checksum", path)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	checksum, err := checksumReader(reader, method)
+	if err != nil {
+		return "", NewFileIoError(BackendEncrypted, "Checksum", path, err)
+	}
+
+	return checksum, nil
+}
+
+// FileInfo returns the backend FileInfo of path, with the plaintext size for
+// files
+func (f EncryptedFileIo) FileInfo(path string) (os.FileInfo, error) {
+	info, err := f.fileIo.FileInfo(path)
+	if err != nil || info.IsDir() {
+		return info, err
+	}
+
+	header, err := f.header("FileInfo", path)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := plaintextSize(info.Size(), header)
+	if err != nil {
+		return nil, NewFileIoError(BackendEncrypted, "FileInfo", path, err)
+	}
+
+	return encryptedFileInfo{FileInfo: info, size: size}, nil
+}
+
+func (f EncryptedFileIo) TempDir(dir, pattern string) (string, CleanupFunc, error) {
+	return f.fileIo.TempDir(dir, pattern)
+}
+
+// TempFile creates a temporary file holding encrypted empty content, so it
+// can be read before anything is written to it
+func (f EncryptedFileIo) TempFile(dir, pattern string) (string, CleanupFunc, error) {
+	path, cleanup, err := f.fileIo.TempFile(dir, pattern)
+	if err != nil {
+		return "", nil, err
+	}
+
+	content, err := f.seal("TempFile", path, nil)
+	if err == nil {
+		err = f.fileIo.WriteFile(path, content, 0o600)
+	}
+	if err != nil {
+		_ = cleanup()
+		return "", nil, err
+	}
+
+	return path, cleanup, nil
+}
+
+// OpenFile decrypts path chunk by chunk as it is read
+func (f EncryptedFileIo) OpenFile(path string) (io.ReadCloser, error) {
+	return f.decrypt("OpenFile", path)
+}
+
+// CreateFile encrypts path chunk by chunk as it is written, the last chunk
+// is written when the writer is closed
+func (f EncryptedFileIo) CreateFile(path string, mode fs.FileMode) (io.WriteCloser, error) {
+	writer, err := CreateWriter(f.fileIo, path, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.encrypt("CreateFile", path, writer)
+}
+
+// seal returns data encrypted with the current key
+func (f EncryptedFileIo) seal(op, path string, data []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer, err := f.encrypt(op, path, nopWriteCloser{Writer: &buffer})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// encrypt writes a header for the current key to writer and returns a writer
+// encrypting into it, writer is closed when that fails
+func (f EncryptedFileIo) encrypt(op, path string, writer io.WriteCloser) (io.WriteCloser, error) {
+	fail := func(err error) (io.WriteCloser, error) {
+		writer.Close()
+		return nil, NewFileIoError(BackendEncrypted, op, path, err)
+	}
+
+	id, key, err := f.keys.CurrentKey()
+	if err != nil {
+		return fail(err)
+	}
+
+	header := encryptionHeader{
+		keyID:     id,
+		chunkSize: f.chunkSize,
+		salt:      make([]byte, saltSize),
+		prefix:    make([]byte, noncePrefixSize),
+	}
+	if _, err := io.ReadFull(f.random, header.salt); err != nil {
+		return fail(err)
+	}
+	if _, err := io.ReadFull(f.random, header.prefix); err != nil {
+		return fail(err)
+	}
+	header.raw = header.encode()
+
+	aead, err := newAEAD(id, key, header.salt)
+	if err != nil {
+		return fail(err)
+	}
+
+	if _, err := writer.Write(header.raw); err != nil {
+		return fail(err)
+	}
+
+	return &encryptingWriter{
+		writer: writer,
+		aead:   aead,
+		header: header,
+		op:     op,
+		path:   path,
+	}, nil
+}
+
+// decrypt opens path and returns a reader decrypting it
+func (f EncryptedFileIo) decrypt(op, path string) (io.ReadCloser, error) {
+	source, err := OpenReader(f.fileIo, path)
+	if err != nil {
+		return nil, err
+	}
+
+	fail := func(err error) (io.ReadCloser, error) {
+		source.Close()
+		return nil, NewFileIoError(BackendEncrypted, op, path, err)
+	}
+
+	reader := bufio.NewReader(source)
+	header, err := readEncryptionHeader(reader)
+	if err != nil {
+		return fail(err)
+	}
+
+	key, err := f.keys.Key(header.keyID)
+	if err != nil {
+		return fail(err)
+	}
+
+	aead, err := newAEAD(header.keyID, key, header.salt)
+	if err != nil {
+		return fail(err)
+	}
+
+	return &decryptingReader{
+		reader: reader,
+		closer: source,
+		aead:   aead,
+		header: header,
+		chunk:  make([]byte, header.chunkSize+aead.Overhead()),
+		op:     op,
+		path:   path,
+	}, nil
+}
+
+// header reads the encryption header of path
+func (f EncryptedFileIo) header(op, path string) (encryptionHeader, error) {
+	source, err := OpenReader(f.fileIo, path)
+	if err != nil {
+		return encryptionHeader{}, err
+	}
+	defer source.Close()
+
+	header, err := readEncryptionHeader(bufio.NewReader(source))
+	if err != nil {
+		return encryptionHeader{}, NewFileIoError(BackendEncrypted, op, path, err)
+	}
+
+	return header, nil
+}
+
+// newAEAD returns the cipher sealing the file whose header holds salt
+func newAEAD(id string, key, salt []byte) (cipher.AEAD, error) {
+	if err := validateKey(id, key); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(deriveFileKey(key, salt))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// deriveFileKey derives the key of one file from key and its salt with
+// HKDF-SHA256 (RFC 5869), one block of output being a whole AES-256 key
+func deriveFileKey(key, salt []byte) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(key)
+
+	expand := hmac.New(sha256.New, extract.Sum(nil))
+	expand.Write([]byte(fileKeyInfo))
+	expand.Write([]byte{1})
+	return expand.Sum(nil)
+}
+
+type encryptionHeader struct {
+	raw       []byte
+	keyID     string
+	chunkSize int
+	salt      []byte
+	prefix    []byte
+}
+
+func (h encryptionHeader) encode() []byte {
+	raw := make([]byte, 0, fixedHeaderSize+len(h.keyID))
+	raw = append(raw, encryptionMagic...)
+	raw = append(raw, encryptionVersion)
+	raw = binary.BigEndian.AppendUint32(raw, uint32(h.chunkSize))
+	raw = append(raw, h.salt...)
+	raw = append(raw, h.prefix...)
+	raw = append(raw, byte(len(h.keyID)))
+	return append(raw, h.keyID...)
+}
+
+// nonce returns the nonce of the chunk counter
+func (h encryptionHeader) nonce(counter uint32, last bool) []byte {
+	nonce := make([]byte, 0, noncePrefixSize+5)
+	nonce = append(nonce, h.prefix...)
+	nonce = binary.BigEndian.AppendUint32(nonce, counter)
+	if last {
+		return append(nonce, 1)
+	}
+
+	return append(nonce, 0)
+}
+
+func readEncryptionHeader(reader io.Reader) (encryptionHeader, error) {
+	fixed := make([]byte, fixedHeaderSize)
+	if _, err := io.ReadFull(reader, fixed); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return encryptionHeader{}, ErrNotEncrypted
+		}
+		return encryptionHeader{}, err
+	}
+
+	if string(fixed[:len(encryptionMagic)]) != encryptionMagic {
+		return encryptionHeader{}, ErrNotEncrypted
+	}
+
+	offset := len(encryptionMagic)
+	if version := fixed[offset]; version != encryptionVersion {
+		return encryptionHeader{}, fmt.Errorf("%w: unsupported format version %d", ErrNotEncrypted, version)
+	}
+	offset++
+
+	chunkSize := binary.BigEndian.Uint32(fixed[offset:])
+	if chunkSize == 0 || chunkSize > maxChunkSize {
+		return encryptionHeader{}, fmt.Errorf("%w: invalid chunk size %d", ErrNotEncrypted, chunkSize)
+	}
+	offset += 4
+
+	header := encryptionHeader{
+		chunkSize: int(chunkSize),
+		salt:      fixed[offset : offset+saltSize],
+		prefix:    fixed[offset+saltSize : offset+saltSize+noncePrefixSize],
+	}
+
+	keyID := make([]byte, fixed[fixedHeaderSize-1])
+	if _, err := io.ReadFull(reader, keyID); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return encryptionHeader{}, ErrNotEncrypted
+		}
+		return encryptionHeader{}, err
+	}
+
+	header.keyID = string(keyID)
+	header.raw = append(fixed, keyID...)
+	return header, nil
+}
+
+// plaintextSize returns the size of the content of an encrypted file of size
+// bytes
+func plaintextSize(size int64, header encryptionHeader) (int64, error) {
+	body := size - int64(len(header.raw))
+	sealedChunk := int64(header.chunkSize + gcmTagSize)
+	chunks := (body + sealedChunk - 1) / sealedChunk
+	if body < gcmTagSize || body-(chunks-1)*sealedChunk < gcmTagSize {
+		return 0, ErrAuthenticationFailed
+	}
+
+	return body - chunks*gcmTagSize, nil
+}
+
+// encryptedFileInfo reports the plaintext size of an encrypted file
+type encryptedFileInfo struct {
+	os.FileInfo
+	size int64
+}
+
+func (i encryptedFileInfo) Size() int64 {
+	return i.size
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// encryptingWriter seals every full chunk written to it, the last chunk is
+// held back until Close so it can be flagged as last
+type encryptingWriter struct {
+	writer  io.WriteCloser
+	aead    cipher.AEAD
+	header  encryptionHeader
+	buffer  []byte
+	sealed  []byte
+	counter uint32
+	err     error
+	closed  bool
+	op      string
+	path    string
+}
+
+func (w *encryptingWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, fs.ErrClosed
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	w.buffer = append(w.buffer, p...)
+	consumed := 0
+	for len(w.buffer)-consumed > w.header.chunkSize {
+		if err := w.seal(w.buffer[consumed:consumed+w.header.chunkSize], false); err != nil {
+			w.err = err
+			return 0, err
+		}
+		consumed += w.header.chunkSize
+	}
+	w.buffer = append(w.buffer[:0], w.buffer[consumed:]...)
+
+	return len(p), nil
+}
+
+func (w *encryptingWriter) Close() error {
+	if w.closed {
+		return fs.ErrClosed
+	}
+
+	w.closed = true
+	if w.err == nil {
+		w.err = w.seal(w.buffer, true)
+	}
+
+	closeErr := w.writer.Close()
+	if w.err != nil {
+		return w.err
+	}
+
+	return closeErr
+}
+
+func (w *encryptingWriter) seal(chunk []byte, last bool) error {
+	if w.counter == math.MaxUint32 && !last {
+		return NewFileIoError(BackendEncrypted, w.op, w.path, errors.New("content too large to encrypt"))
+	}
+
+	w.sealed = w.aead.Seal(w.sealed[:0], w.header.nonce(w.counter, last), chunk, w.header.raw)
+	w.counter++
+
+	if _, err := w.writer.Write(w.sealed); err != nil {
+		return NewFileIoError(BackendEncrypted, w.op, w.path, err)
+	}
+
+	return nil
+}
+
+// decryptingReader opens one chunk at a time, the end of the content is only
+// accepted after a chunk flagged as last
+type decryptingReader struct {
+	reader  *bufio.Reader
+	closer  io.Closer
+	aead    cipher.AEAD
+	header  encryptionHeader
+	chunk   []byte
+	plain   []byte
+	counter uint32
+	done    bool
+	err     error
+	op      string
+	path    string
+}
+
+func (r *decryptingReader) Read(p []byte) (int, error) {
+	for len(r.plain) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.done {
+			return 0, io.EOF
+		}
+		r.err = r.next()
+	}
+
+	n := copy(p, r.plain)
+	r.plain = r.plain[n:]
+	return n, nil
+}
+
+func (r *decryptingReader) Close() error {
+	return r.closer.Close()
+}
+
+func (r *decryptingReader) next() error {
+	n, err := io.ReadFull(r.reader, r.chunk)
+	last := false
+	switch {
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		last = true
+	case err != nil:
+		return NewFileIoError(BackendEncrypted, r.op, r.path, err)
+	default:
+		if _, err := r.reader.Peek(1); errors.Is(err, io.EOF) {
+			last = true
+		} else if err != nil {
+			return NewFileIoError(BackendEncrypted, r.op, r.path, err)
+		}
+	}
+
+	if n < r.aead.Overhead() {
+		return NewFileIoError(BackendEncrypted, r.op, r.path, ErrAuthenticationFailed)
+	}
+
+	plain, err := r.aead.Open(r.chunk[:0], r.header.nonce(r.counter, last), r.chunk[:n], r.header.raw)
+	if err != nil {
+		return NewFileIoError(BackendEncrypted, r.op, r.path, ErrAuthenticationFailed)
+	}
+
+	r.counter++
+	r.plain = plain
+	r.done = last
+	return nil
+}
@@ -0,0 +1,253 @@
+package io
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testKey(seed byte) []byte {
+	return bytes.Repeat([]byte{seed}, EncryptionKeySize)
+}
+
+func newTestEncrypted(t *testing.T, options ...EncryptionOption) (EncryptedFileIo, *KeyRing, *MemoryFileIo) {
+	t.Helper()
+
+	keys, err := NewKeyRing("v1", testKey(1))
+	assert.NoError(t, err)
+	memory := NewMemoryFileIo()
+	return NewEncryptedFileIo(memory, keys, options...), keys, memory
+}
+
+func TestEncryptedFileIo_RoundTrip(t *testing.T) {
+	encrypted, _, memory := newTestEncrypted(t)
+	secret := []byte("password=hunter2")
+
+	assert.NoError(t, encrypted.WriteFile("/credentials", secret, 0o600))
+
+	stored, err := memory.ReadFile("/credentials")
+	assert.NoError(t, err)
+	assert.NotContains(t, string(stored), "hunter2")
+	assert.Equal(t, "CGHE", string(stored[:4]))
+
+	content, err := encrypted.ReadFile("/credentials")
+	assert.NoError(t, err)
+	assert.Equal(t, secret, content)
+
+	info, err := encrypted.FileInfo("/credentials")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(secret)), info.Size())
+	assert.Equal(t, fs.FileMode(0o600), info.Mode().Perm())
+
+	checksum, err := encrypted.Checksum("/credentials", ChecksumSHA256)
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%x", sha256.Sum256(secret)), checksum)
+
+	part, err := encrypted.ReadBufferedFile("/credentials", 9, 12)
+	assert.NoError(t, err)
+	assert.Equal(t, "hun", string(part))
+
+	assert.NoError(t, encrypted.WriteBufferedFile("/buffered", secret, 4, 0o600))
+	assert.NoError(t, encrypted.CopyFile("/buffered", "/copy"))
+	content, err = encrypted.ReadFile("/copy")
+	assert.NoError(t, err)
+	assert.Equal(t, secret, content)
+
+	path, cleanup, err := encrypted.TempFile("/", "tmp-*")
+	assert.NoError(t, err)
+	content, err = encrypted.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Empty(t, content)
+	assert.NoError(t, cleanup())
+}
+
+func TestEncryptedFileIo_Chunks(t *testing.T) {
+	encrypted, _, memory := newTestEncrypted(t, WithChunkSize(16))
+
+	for _, size := range []int{0, 1, 15, 16, 17, 32, 100} {
+		t.Run(fmt.Sprint(size), func(t *testing.T) {
+			content := make([]byte, size)
+			for i := range content {
+				content[i] = byte(i)
+			}
+
+			writer, err := encrypted.CreateFile("/file", 0o644)
+			assert.NoError(t, err)
+			for _, piece := range [][]byte{content[:size/3], content[size/3:]} {
+				_, err := writer.Write(piece)
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, writer.Close())
+			assert.ErrorIs(t, writer.Close(), fs.ErrClosed)
+
+			reader, err := encrypted.OpenFile("/file")
+			assert.NoError(t, err)
+			streamed, err := io.ReadAll(reader)
+			assert.NoError(t, err)
+			assert.NoError(t, reader.Close())
+			assert.Equal(t, content, streamed)
+
+			info, err := encrypted.FileInfo("/file")
+			assert.NoError(t, err)
+			assert.Equal(t, int64(size), info.Size())
+
+			stored, err := memory.ReadFile("/file")
+			assert.NoError(t, err)
+			sealed, err := encrypted.seal("WriteFile", "/file", content)
+			assert.NoError(t, err)
+			assert.Equal(t, len(sealed), len(stored))
+
+			for _, bounds := range [][2]int{{0, 0}, {size / 2, 0}, {1, size}, {size, size}, {3, 40}} {
+				expected, expectedErr := readRange(content, bounds[0], bounds[1])
+				actual, err := encrypted.ReadBufferedFile("/file", bounds[0], bounds[1])
+				if expectedErr != nil {
+					assert.ErrorIs(t, err, ErrInvalidRange, "range %v", bounds)
+					continue
+				}
+				assert.NoError(t, err, "range %v", bounds)
+				assert.Equal(t, expected, actual, "range %v", bounds)
+			}
+		})
+	}
+}
+
+func TestEncryptedFileIo_KeyRotation(t *testing.T) {
+	encrypted, keys, _ := newTestEncrypted(t)
+	assert.NoError(t, encrypted.WriteFile("/old", []byte("old secret"), 0o600))
+
+	assert.NoError(t, keys.Add("v2", testKey(2)))
+	assert.NoError(t, encrypted.WriteFile("/new", []byte("new secret"), 0o600))
+
+	id, err := encrypted.KeyID("/old")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", id)
+	id, err = encrypted.KeyID("/new")
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", id)
+
+	content, err := encrypted.ReadFile("/old")
+	assert.NoError(t, err)
+	assert.Equal(t, "old secret", string(content))
+
+	assert.NoError(t, encrypted.Rotate("/old"))
+	assert.NoError(t, encrypted.Rotate("/new"))
+	id, err = encrypted.KeyID("/old")
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", id)
+	entries, err := encrypted.ReadDir("/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"new", "old"}, entryNames(entries))
+
+	assert.Error(t, keys.Remove("v2"))
+	assert.NoError(t, keys.Remove("v1"))
+	content, err = encrypted.ReadFile("/old")
+	assert.NoError(t, err)
+	assert.Equal(t, "old secret", string(content))
+	info, err := encrypted.FileInfo("/old")
+	assert.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestEncryptedFileIo_FileKeys(t *testing.T) {
+	encrypted, _, memory := newTestEncrypted(t)
+	assert.NoError(t, encrypted.WriteFile("/first", []byte("same content"), 0o600))
+	assert.NoError(t, encrypted.WriteFile("/second", []byte("same content"), 0o600))
+
+	first, err := memory.ReadFile("/first")
+	assert.NoError(t, err)
+	second, err := memory.ReadFile("/second")
+	assert.NoError(t, err)
+
+	firstHeader, err := readEncryptionHeader(bytes.NewReader(first))
+	assert.NoError(t, err)
+	secondHeader, err := readEncryptionHeader(bytes.NewReader(second))
+	assert.NoError(t, err)
+	assert.Len(t, firstHeader.salt, saltSize)
+	assert.NotEqual(t, firstHeader.salt, secondHeader.salt)
+	assert.NotEqual(t, first[len(firstHeader.raw):], second[len(secondHeader.raw):])
+
+	firstKey := deriveFileKey(testKey(1), firstHeader.salt)
+	assert.Len(t, firstKey, EncryptionKeySize)
+	assert.NotEqual(t, testKey(1), firstKey)
+	assert.NotEqual(t, firstKey, deriveFileKey(testKey(1), secondHeader.salt))
+	assert.Equal(t, firstKey, deriveFileKey(testKey(1), firstHeader.salt))
+}
+
+func TestEncryptedFileIo_Errors(t *testing.T) {
+	encrypted, keys, memory := newTestEncrypted(t, WithChunkSize(8))
+	assert.NoError(t, encrypted.WriteFile("/file", []byte("some longer secret content"), 0o600))
+	stored, err := memory.ReadFile("/file")
+	assert.NoError(t, err)
+
+	t.Run("tampered", func(t *testing.T) {
+		tampered := append([]byte{}, stored...)
+		tampered[len(tampered)-20] ^= 1
+		assert.NoError(t, memory.WriteFile("/tampered", tampered, 0o600))
+
+		_, err := encrypted.ReadFile("/tampered")
+		assert.ErrorIs(t, err, ErrAuthenticationFailed)
+		var fileIoErr *FileIoError
+		assert.ErrorAs(t, err, &fileIoErr)
+		assert.Equal(t, BackendEncrypted, fileIoErr.Backend)
+	})
+
+	t.Run("header", func(t *testing.T) {
+		tampered := append([]byte{}, stored...)
+		tampered[10] ^= 1
+		assert.NoError(t, memory.WriteFile("/header", tampered, 0o600))
+
+		_, err := encrypted.ReadFile("/header")
+		assert.ErrorIs(t, err, ErrAuthenticationFailed)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		header, err := readEncryptionHeader(bytes.NewReader(stored))
+		assert.NoError(t, err)
+		truncated := stored[:len(header.raw)+2*(8+gcmTagSize)]
+		assert.NoError(t, memory.WriteFile("/truncated", truncated, 0o600))
+
+		_, err = encrypted.ReadFile("/truncated")
+		assert.ErrorIs(t, err, ErrAuthenticationFailed)
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		other, err := NewKeyRing("v1", testKey(9))
+		assert.NoError(t, err)
+
+		_, err = NewEncryptedFileIo(memory, other).ReadFile("/file")
+		assert.ErrorIs(t, err, ErrAuthenticationFailed)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		other, err := NewKeyRing("v2", testKey(1))
+		assert.NoError(t, err)
+
+		_, err = NewEncryptedFileIo(memory, other).ReadFile("/file")
+		assert.ErrorIs(t, err, ErrUnknownKey)
+	})
+
+	t.Run("not encrypted", func(t *testing.T) {
+		assert.NoError(t, memory.WriteFile("/plain", []byte("plain"), 0o600))
+
+		_, err := encrypted.ReadFile("/plain")
+		assert.ErrorIs(t, err, ErrNotEncrypted)
+		_, err = encrypted.FileInfo("/plain")
+		assert.ErrorIs(t, err, ErrNotEncrypted)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := encrypted.ReadFile("/missing")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("invalid keys", func(t *testing.T) {
+		_, err := NewKeyRing("v1", []byte("short"))
+		assert.Error(t, err)
+		assert.Error(t, keys.Add("", testKey(3)))
+	})
+}
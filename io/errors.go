@@ -0,0 +1,154 @@
+package io
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"syscall"
+)
+
+// Backend names reported in FileIoError
+const (
+	BackendDefault         = "default"
+	BackendMock            = "mock"
+	BackendFlock           = "flock"
+	BackendPidFile         = "pidfile"
+	BackendMemory          = "memory"
+	BackendRooted          = "rooted"
+	BackendReadOnly        = "readonly"
+	BackendOverlay         = "overlay"
+	BackendFS              = "fs"
+	BackendMount           = "mount"
+	BackendStream          = "stream"
+	BackendRetry           = "retry"
+	BackendFault           = "fault"
+	BackendQuota           = "quota"
+	BackendRateLimit       = "ratelimit"
+	BackendCache           = "cache"
+	BackendRecord          = "record"
+	BackendReplay          = "replay"
+	BackendEncrypted       = "encrypted"
+	BackendCaseInsensitive = "caseinsensitive"
+)
+
+var (
+	ErrInvalidChecksumMethod = errors.New("invalid checksum method")
+	ErrIsDirectory           = errors.New("is a directory")
+	ErrNotDirectory          = errors.New("not a directory")
+	ErrInvalidRange          = errors.New("invalid read range")
+	// ErrLocked is returned by TryLock when the lock is held by someone else
+	ErrLocked = errors.New("resource is locked")
+	// ErrIncompatibleMode is returned by EnsureDir when the directory exists
+	// but lacks some of the requested permission bits
+	ErrIncompatibleMode = errors.New("directory exists with incompatible mode")
+	// ErrVerificationFailed is returned when a copied file does not match its
+	// source
+	ErrVerificationFailed = errors.New("copied content does not match source")
+	// ErrPathEscapesRoot is returned by RootedFileIo for paths that resolve
+	// outside of its root directory
+	ErrPathEscapesRoot = errors.New("path escapes root directory")
+	// ErrReadOnly is returned by ReadOnlyFileIo for any operation that would
+	// modify the file system, it matches fs.ErrPermission with errors.Is
+	ErrReadOnly = fmt.Errorf("read-only file system: %w", fs.ErrPermission)
+	// ErrQuotaExceeded matches every QuotaError with errors.Is
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrUnexpectedCall is returned by ReplayFileIo for a call its cassette
+	// does not hold
+	ErrUnexpectedCall = errors.New("unexpected call")
+	// ErrNotEncrypted is returned by EncryptedFileIo for files that do not
+	// start with a known encryption header
+	ErrNotEncrypted = errors.New("file is not encrypted")
+	// ErrUnknownKey is returned when the key a file was encrypted with is not
+	// held by the KeyProvider
+	ErrUnknownKey = errors.New("unknown encryption key")
+	// ErrAuthenticationFailed is returned when encrypted content was tampered
+	// with, truncated or encrypted with a different key
+	ErrAuthenticationFailed = errors.New("message authentication failed")
+	// ErrTooManyPolicies is returned by Move when given more than one
+	// OverwritePolicy
+	ErrTooManyPolicies = errors.New("more than one overwrite policy")
+	// ErrCaseConflict is returned by case-insensitive backends when a name
+	// only differs in case from an existing entry, it matches fs.ErrExist
+	ErrCaseConflict = fmt.Errorf("name differs only in case from an existing entry: %w", fs.ErrExist)
+)
+
+// FileIoError records a failed FileIo operation, the path or paths involved,
+// the backend that served it and the underlying cause. Use errors.Is with the
+// sentinel values above or the fs errors (fs.ErrNotExist, fs.ErrExist...) to
+// inspect it.
+type FileIoError struct {
+	Op          string
+	Path        string
+	Destination string
+	Backend     string
+	Err         error
+}
+
+func (e *FileIoError) Error() string {
+	var sb strings.Builder
+	if e.Backend != "" {
+		sb.WriteString(e.Backend)
+		sb.WriteString(": ")
+	}
+
+	sb.WriteString(e.Op)
+	if e.Path != "" {
+		sb.WriteString(" ")
+		sb.WriteString(e.Path)
+	}
+	if e.Destination != "" {
+		sb.WriteString(" -> ")
+		sb.WriteString(e.Destination)
+	}
+	if e.Err != nil {
+		sb.WriteString(": ")
+		sb.WriteString(e.Err.Error())
+	}
+
+	return sb.String()
+}
+
+func (e *FileIoError) Unwrap() error {
+	return e.Err
+}
+
+// Is maps the platform errors returned by the os package onto the package
+// sentinels so callers do not need to check syscall values
+func (e *FileIoError) Is(target error) bool {
+	switch target {
+	case ErrNotDirectory:
+		return errors.Is(e.Err, syscall.ENOTDIR)
+	case ErrIsDirectory:
+		return errors.Is(e.Err, syscall.EISDIR)
+	default:
+		return false
+	}
+}
+
+// NewFileIoError wraps err with the operation details, it returns nil for a
+// nil err and returns err untouched when it already is a FileIoError
+func NewFileIoError(backend, op, path string, err error) error {
+	return NewFileIoLinkError(backend, op, path, "", err)
+}
+
+// NewFileIoLinkError is NewFileIoError for operations with a source and a
+// destination
+func NewFileIoLinkError(backend, op, source, destination string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var fileIoErr *FileIoError
+	if errors.As(err, &fileIoErr) {
+		return err
+	}
+
+	return &FileIoError{
+		Op:          op,
+		Path:        source,
+		Destination: destination,
+		Backend:     backend,
+		Err:         err,
+	}
+}
@@ -0,0 +1,137 @@
+package io
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileIoError(t *testing.T) {
+	t.Run("Error Message", func(t *testing.T) {
+		err := &FileIoError{Op: "ReadFile", Path: "/a", Backend: BackendDefault, Err: fs.ErrNotExist}
+		assert.Equal(t, "default: ReadFile /a: file does not exist", err.Error())
+
+		err = &FileIoError{Op: "CopyFile", Path: "/a", Destination: "/b", Err: fs.ErrExist}
+		assert.Equal(t, "CopyFile /a -> /b: file already exists", err.Error())
+	})
+
+	t.Run("Unwrap", func(t *testing.T) {
+		cause := errors.New("cause")
+		err := NewFileIoError(BackendDefault, "ReadFile", "/a", cause)
+		assert.Equal(t, cause, errors.Unwrap(err))
+		assert.ErrorIs(t, err, cause)
+	})
+
+	t.Run("Nil Error", func(t *testing.T) {
+		assert.NoError(t, NewFileIoError(BackendDefault, "ReadFile", "/a", nil))
+	})
+
+	t.Run("Already Wrapped", func(t *testing.T) {
+		inner := NewFileIoError(BackendDefault, "CopyFile", "/a/b", fs.ErrNotExist)
+		outer := NewFileIoLinkError(BackendDefault, "CopyDir", "/a", "/c", fmt.Errorf("copy: %w", inner))
+
+		var fileIoErr *FileIoError
+		assert.ErrorAs(t, outer, &fileIoErr)
+		assert.Equal(t, "CopyFile", fileIoErr.Op)
+	})
+
+	t.Run("Syscall Errors Map To Sentinels", func(t *testing.T) {
+		err := NewFileIoError(BackendDefault, "ReadDir", "/a", &os.PathError{Op: "open", Path: "/a", Err: syscall.ENOTDIR})
+		assert.ErrorIs(t, err, ErrNotDirectory)
+		assert.NotErrorIs(t, err, ErrIsDirectory)
+
+		err = NewFileIoError(BackendDefault, "ReadFile", "/a", syscall.EISDIR)
+		assert.ErrorIs(t, err, ErrIsDirectory)
+	})
+}
+
+func TestDefaultFileIoErrors(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "file.txt")
+	missing := filepath.Join(root, "missing.txt")
+	assert.NoError(t, os.WriteFile(file, []byte("data"), 0o644))
+
+	assertFileIoError := func(t *testing.T, err error, op, path string, target error) {
+		var fileIoErr *FileIoError
+		if assert.ErrorAs(t, err, &fileIoErr) {
+			assert.Equal(t, op, fileIoErr.Op)
+			assert.Equal(t, path, fileIoErr.Path)
+			assert.Equal(t, BackendDefault, fileIoErr.Backend)
+		}
+		assert.ErrorIs(t, err, target)
+	}
+
+	defaultClient := Default()
+
+	t.Run("Missing File", func(t *testing.T) {
+		_, err := defaultClient.ReadFile(missing)
+		assertFileIoError(t, err, "ReadFile", missing, fs.ErrNotExist)
+
+		_, err = defaultClient.ReadBufferedFile(missing, 0, 1)
+		assertFileIoError(t, err, "ReadBufferedFile", missing, fs.ErrNotExist)
+
+		_, err = defaultClient.FileInfo(missing)
+		assertFileIoError(t, err, "FileInfo", missing, fs.ErrNotExist)
+
+		err = defaultClient.DeleteFile(missing)
+		assertFileIoError(t, err, "DeleteFile", missing, fs.ErrNotExist)
+	})
+
+	t.Run("Directory Used As File", func(t *testing.T) {
+		_, err := defaultClient.ReadFile(root)
+		assertFileIoError(t, err, "ReadFile", root, ErrIsDirectory)
+
+		_, err = defaultClient.Checksum(root, ChecksumMD5)
+		assertFileIoError(t, err, "Checksum", root, ErrIsDirectory)
+
+		err = defaultClient.CopyFile(root, filepath.Join(root, "copy"))
+		assertFileIoError(t, err, "CopyFile", root, ErrIsDirectory)
+	})
+
+	t.Run("File Used As Directory", func(t *testing.T) {
+		_, err := defaultClient.ReadDir(file)
+		assertFileIoError(t, err, "ReadDir", file, ErrNotDirectory)
+
+		err = defaultClient.CopyDir(file, filepath.Join(root, "copy"))
+		assertFileIoError(t, err, "CopyDir", file, ErrNotDirectory)
+	})
+
+	t.Run("Deletes Keep Os Semantics", func(t *testing.T) {
+		dir := t.TempDir()
+		other := filepath.Join(dir, "other.txt")
+		empty := filepath.Join(dir, "empty")
+		assert.NoError(t, os.WriteFile(other, []byte("other"), 0o644))
+		assert.NoError(t, os.Mkdir(empty, 0o755))
+
+		assert.NoError(t, defaultClient.DeleteDir(other))
+		assert.NoFileExists(t, other)
+		assert.NoError(t, defaultClient.DeleteFile(empty))
+		assert.NoDirExists(t, empty)
+		full := filepath.Join(dir, "full")
+		assert.NoError(t, os.MkdirAll(filepath.Join(full, "child"), 0o755))
+		assertFileIoError(t, defaultClient.DeleteFile(full), "DeleteFile", full, syscall.ENOTEMPTY)
+	})
+
+	t.Run("Invalid Checksum Method", func(t *testing.T) {
+		_, err := defaultClient.Checksum(file, 10)
+		assertFileIoError(t, err, "Checksum", file, ErrInvalidChecksumMethod)
+	})
+
+	t.Run("Move Destination Exists", func(t *testing.T) {
+		other := filepath.Join(root, "other.txt")
+		assert.NoError(t, os.WriteFile(other, []byte("other"), 0o644))
+
+		err := defaultClient.Move(other, file)
+		assertFileIoError(t, err, "Move", other, fs.ErrExist)
+
+		var fileIoErr *FileIoError
+		assert.ErrorAs(t, err, &fileIoErr)
+		assert.Equal(t, file, fileIoErr.Destination)
+	})
+}
@@ -0,0 +1,165 @@
+package io
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cjlapao/common-go-helpers/io/paths"
+)
+
+// ErrExecutableNotFound is returned by LookPath when no executable matches
+var ErrExecutableNotFound = errors.New("executable file not found")
+
+// ErrRelativeExecutable is returned by LookPath for a relative name with a
+// separator, like exec.ErrDot it keeps the current directory out of the
+// lookup so the caller has to make the path absolute on purpose
+var ErrRelativeExecutable = errors.New("executable path is relative")
+
+// defaultPathExt is used when PATHEXT is not defined on Windows
+const defaultPathExt = ".com;.exe;.bat;.cmd"
+
+// osExecutable is swapped in tests to simulate os.Executable failures
+var osExecutable = os.Executable
+
+// ExecutablePath returns the absolute path of the running binary with
+// symlinks resolved, unlike GetExecutionPath which returns os.Args[0]
+func (f DefaultFileIo) ExecutablePath() (string, error) {
+	path, err := osExecutable()
+	if err != nil || path == "" {
+		// os.Executable can fail on some unix systems while procfs is mounted
+		procPath, procErr := os.Readlink("/proc/self/exe")
+		if procErr != nil {
+			if err == nil {
+				err = procErr
+			}
+			return "", NewFileIoError(BackendDefault, "ExecutablePath", "", err)
+		}
+		path = procPath
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", NewFileIoError(BackendDefault, "ExecutablePath", path, err)
+	}
+
+	absolute, err := filepath.Abs(resolved)
+	if err != nil {
+		return "", NewFileIoError(BackendDefault, "ExecutablePath", resolved, err)
+	}
+
+	return absolute, nil
+}
+
+// ExecutableDir returns the directory holding the running binary
+func (f DefaultFileIo) ExecutableDir() (string, error) {
+	path, err := f.ExecutablePath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Dir(path), nil
+}
+
+// LookPath searches the PATH of the process environment for name
+func (f DefaultFileIo) LookPath(name string) (string, error) {
+	return LookPath(f, name, paths.OsEnvironment{})
+}
+
+// LookPath searches the directories in the PATH variable of env for an
+// executable called name, following the rules of the operating system
+// reported by fileIo: on Windows the PATHEXT extensions are tried and any
+// existing file matches, elsewhere the file needs an execute bit. Names
+// containing a separator are checked directly without searching PATH and
+// must be absolute. Empty and relative PATH entries are skipped so the result
+// is always an absolute path.
+func LookPath(fileIo FileIo, name string, env paths.Environment) (string, error) {
+	operatingSystem := fileIo.GetOperatingSystem()
+	flavour := operatingSystem.PathFlavour()
+	extensions := []string{""}
+	listSeparator := ":"
+	if operatingSystem == WindowsOs {
+		listSeparator = ";"
+		extensions = windowsExtensions(name, env)
+	}
+
+	if strings.ContainsAny(name, flavour.Separator()+"/") {
+		if !flavour.IsAbs(name) {
+			return "", NewFileIoError(BackendDefault, "LookPath", name, ErrRelativeExecutable)
+		}
+		if path, ok := findExecutable(fileIo, operatingSystem, name, extensions); ok {
+			return path, nil
+		}
+		return "", NewFileIoError(BackendDefault, "LookPath", name, ErrExecutableNotFound)
+	}
+
+	pathList, _ := env.LookupEnv("PATH")
+	if operatingSystem == WindowsOs && pathList == "" {
+		pathList, _ = env.LookupEnv("Path")
+	}
+
+	for _, dir := range strings.Split(pathList, listSeparator) {
+		dir = strings.Trim(dir, `"`)
+		if dir == "" || !flavour.IsAbs(dir) {
+			// empty and relative entries resolve against the current
+			// directory, which would let it shadow the real executable
+			continue
+		}
+
+		if path, ok := findExecutable(fileIo, operatingSystem, flavour.Join(dir, name), extensions); ok {
+			return path, nil
+		}
+	}
+
+	return "", NewFileIoError(BackendDefault, "LookPath", name, ErrExecutableNotFound)
+}
+
+// windowsExtensions returns the extensions to try for name, no extension is
+// tried first when name already has one from PATHEXT
+func windowsExtensions(name string, env paths.Environment) []string {
+	pathExt, ok := env.LookupEnv("PATHEXT")
+	if !ok || pathExt == "" {
+		pathExt = defaultPathExt
+	}
+
+	extensions := []string{}
+	hasExtension := false
+	currentExtension := strings.ToLower(paths.Windows.Ext(name))
+	for _, extension := range strings.Split(strings.ToLower(pathExt), ";") {
+		if extension == "" {
+			continue
+		}
+		if !strings.HasPrefix(extension, ".") {
+			extension = "." + extension
+		}
+		if extension == currentExtension {
+			hasExtension = true
+		}
+		extensions = append(extensions, extension)
+	}
+
+	if hasExtension {
+		return append([]string{""}, extensions...)
+	}
+
+	return extensions
+}
+
+func findExecutable(fileIo FileIo, operatingSystem OperatingSystem, path string, extensions []string) (string, bool) {
+	for _, extension := range extensions {
+		candidate := path + extension
+		info, err := fileIo.FileInfo(candidate)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		if operatingSystem != WindowsOs && info.Mode().Perm()&0o111 == 0 {
+			continue
+		}
+
+		return candidate, true
+	}
+
+	return "", false
+}
@@ -0,0 +1,178 @@
+package io
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/cjlapao/common-go-helpers/io/paths"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecutablePath(t *testing.T) {
+	t.Run("Absolute Path", func(t *testing.T) {
+		actual, err := Default().ExecutablePath()
+		assert.NoError(t, err)
+		assert.True(t, filepath.IsAbs(actual))
+
+		expected, _ := os.Executable()
+		expected, _ = filepath.EvalSymlinks(expected)
+		assert.Equal(t, expected, actual)
+
+		dir, err := Default().ExecutableDir()
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Dir(actual), dir)
+	})
+
+	t.Run("Resolves Symlinks", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("symlinks need elevated rights on windows")
+		}
+
+		dir := t.TempDir()
+		target := filepath.Join(dir, "real-binary")
+		link := filepath.Join(dir, "link-binary")
+		assert.NoError(t, os.WriteFile(target, []byte("bin"), 0o755))
+		assert.NoError(t, os.Symlink(target, link))
+
+		original := osExecutable
+		osExecutable = func() (string, error) { return link, nil }
+		defer func() { osExecutable = original }()
+
+		expected, _ := filepath.EvalSymlinks(target)
+		actual, err := Default().ExecutablePath()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("Falls Back To Proc", func(t *testing.T) {
+		if _, err := os.Readlink("/proc/self/exe"); err != nil {
+			t.Skip("procfs is not available")
+		}
+
+		original := osExecutable
+		osExecutable = func() (string, error) { return "", errors.New("not supported") }
+		defer func() { osExecutable = original }()
+
+		actual, err := Default().ExecutablePath()
+		assert.NoError(t, err)
+		assert.True(t, filepath.IsAbs(actual))
+	})
+}
+
+func TestLookPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("execute bits are not available on windows")
+	}
+
+	first := t.TempDir()
+	second := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(first, "tool"), []byte("data"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(second, "tool"), []byte("bin"), 0o755))
+	assert.NoError(t, os.Mkdir(filepath.Join(first, "folder"), 0o755))
+
+	env := paths.StaticEnvironment{
+		Variables: map[string]string{
+			"PATH": first + ":" + second,
+		},
+	}
+	linux := Default(WithOperatingSystem(LinuxOs))
+
+	t.Run("Skips Non Executable", func(t *testing.T) {
+		actual, err := LookPath(linux, "tool", env)
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(second, "tool"), actual)
+	})
+
+	t.Run("Path With Separator", func(t *testing.T) {
+		actual, err := LookPath(linux, filepath.Join(second, "tool"), env)
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(second, "tool"), actual)
+
+		_, err = LookPath(linux, filepath.Join(first, "tool"), env)
+		assert.ErrorIs(t, err, ErrExecutableNotFound)
+	})
+
+	t.Run("Relative Name", func(t *testing.T) {
+		_, err := LookPath(linux, filepath.Join(".", "bin", "tool"), env)
+		assert.ErrorIs(t, err, ErrRelativeExecutable)
+	})
+
+	t.Run("Skips Current Directory", func(t *testing.T) {
+		wd, err := os.Getwd()
+		assert.NoError(t, err)
+		assert.NoError(t, os.Chdir(second))
+		defer os.Chdir(wd)
+
+		for _, pathList := range []string{"", ":", first + "::" + first, ".", "bin:."} {
+			emptyEnv := paths.StaticEnvironment{Variables: map[string]string{"PATH": pathList}}
+			_, err := LookPath(linux, "tool", emptyEnv)
+			assert.ErrorIs(t, err, ErrExecutableNotFound, pathList)
+		}
+
+		_, err = LookPath(linux, "tool", paths.StaticEnvironment{})
+		assert.ErrorIs(t, err, ErrExecutableNotFound)
+
+		actual, err := LookPath(linux, "tool", paths.StaticEnvironment{
+			Variables: map[string]string{"PATH": ":" + second},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(second, "tool"), actual)
+		assert.True(t, filepath.IsAbs(actual))
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		_, err := LookPath(linux, "folder", env)
+		assert.ErrorIs(t, err, ErrExecutableNotFound)
+
+		_, err = LookPath(linux, "missing", env)
+		var fileIoErr *FileIoError
+		assert.ErrorAs(t, err, &fileIoErr)
+		assert.Equal(t, "LookPath", fileIoErr.Op)
+	})
+
+	t.Run("Windows Extensions", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "tool.cmd"), []byte("bin"), 0o644))
+		windows := windowsPathFileIo{DefaultFileIo: Default(WithOperatingSystem(WindowsOs))}
+		windowsEnv := paths.StaticEnvironment{
+			Variables: map[string]string{
+				"Path":    `C:\missing;` + windowsPath(dir),
+				"PATHEXT": ".EXE;.CMD",
+			},
+		}
+
+		actual, err := LookPath(windows, "tool", windowsEnv)
+		assert.NoError(t, err)
+		assert.Equal(t, windowsPath(dir)+`\tool.cmd`, actual)
+
+		actual, err = LookPath(windows, "tool.cmd", windowsEnv)
+		assert.NoError(t, err)
+		assert.Equal(t, windowsPath(dir)+`\tool.cmd`, actual)
+	})
+}
+
+func TestWindowsExtensions(t *testing.T) {
+	env := paths.StaticEnvironment{}
+	assert.Equal(t, []string{".com", ".exe", ".bat", ".cmd"}, windowsExtensions("tool", env))
+	assert.Equal(t, []string{"", ".com", ".exe", ".bat", ".cmd"}, windowsExtensions("tool.EXE", env))
+
+	env.Variables = map[string]string{"PATHEXT": "EXE;;.Ps1"}
+	assert.Equal(t, []string{".exe", ".ps1"}, windowsExtensions("tool", env))
+}
+
+// windowsPathFileIo lets Windows flavoured lookups run against the local
+// file system by mapping "C:\..." paths back to posix ones
+type windowsPathFileIo struct {
+	DefaultFileIo
+}
+
+func (f windowsPathFileIo) FileInfo(path string) (os.FileInfo, error) {
+	return os.Stat(paths.Convert(path, paths.Windows, paths.Posix))
+}
+
+func windowsPath(path string) string {
+	return `C:` + paths.Convert(path, paths.Posix, paths.Windows)
+}
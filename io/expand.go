@@ -0,0 +1,22 @@
+package io
+
+import (
+	"github.com/cjlapao/common-go-helpers/io/paths"
+)
+
+// ExpandPath expands ~, ~user and $VAR, ${VAR:-default} or %VAR% variables
+// in path and converts the result with the ToOsPath of fileIo. The process
+// environment is used unless env is given.
+func ExpandPath(fileIo FileIo, path string, env ...paths.Environment) (string, error) {
+	var environment paths.Environment = paths.OsEnvironment{}
+	if len(env) == 1 && env[0] != nil {
+		environment = env[0]
+	}
+
+	expanded, err := paths.Expand(path, environment)
+	if err != nil {
+		return "", NewFileIoError(BackendDefault, "ExpandPath", path, err)
+	}
+
+	return fileIo.ToOsPath(expanded), nil
+}
@@ -0,0 +1,51 @@
+package io
+
+import (
+	"testing"
+
+	"github.com/cjlapao/common-go-helpers/io/paths"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandPath(t *testing.T) {
+	env := paths.StaticEnvironment{
+		Variables: map[string]string{
+			"XDG_CACHE_HOME": "/home/me/.cache",
+			"APPDATA":        `C:\Users\me\AppData\Roaming`,
+		},
+		Home: "/home/me",
+	}
+
+	t.Run("Linux OS", func(t *testing.T) {
+		linux := Default(WithOperatingSystem(LinuxOs))
+
+		actual, err := ExpandPath(linux, "~/.cache/app", env)
+		assert.NoError(t, err)
+		assert.Equal(t, "/home/me/.cache/app", actual)
+
+		actual, err = ExpandPath(linux, `${XDG_CACHE_HOME}\app`, env)
+		assert.NoError(t, err)
+		assert.Equal(t, "/home/me/.cache/app", actual)
+	})
+
+	t.Run("Windows OS", func(t *testing.T) {
+		actual, err := ExpandPath(Default(WithOperatingSystem(WindowsOs)), "%APPDATA%/app", env)
+		assert.NoError(t, err)
+		assert.Equal(t, `C:\Users\me\AppData\Roaming\app`, actual)
+	})
+
+	t.Run("Unknown User", func(t *testing.T) {
+		_, err := ExpandPath(Default(), "~nobody-here/app", env)
+		var fileIoErr *FileIoError
+		assert.ErrorAs(t, err, &fileIoErr)
+		assert.Equal(t, "ExpandPath", fileIoErr.Op)
+	})
+
+	t.Run("Process Environment", func(t *testing.T) {
+		t.Setenv("COMMON_GO_HELPERS_TEST", "/data")
+
+		actual, err := ExpandPath(Default(), "$COMMON_GO_HELPERS_TEST/app")
+		assert.NoError(t, err)
+		assert.Equal(t, Default().ToOsPath("/data/app"), actual)
+	})
+}
@@ -0,0 +1,514 @@
+package io
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+)
+
+// FaultRule describes when a FaultFileIo injects a fault and what it does.
+// A call matches the rule when its method is listed in Methods and one of its
+// paths matches the Path glob, every call matching the empty values. The
+// matching calls then go through After, Times and Probability, in that
+// order, to decide whether the rule fires.
+type FaultRule struct {
+	// Methods are the FileIo method names the rule applies to, all when empty
+	Methods []string
+	// Path is a glob, as used by WithRedactedPaths, matched against the paths
+	// of the call, all paths when empty
+	Path string
+	// After lets the first After matching calls through untouched
+	After int
+	// Times is how many times the rule fires at most, unlimited when zero
+	Times int
+	// Probability is the chance a matching call fires the rule, drawn from the
+	// FaultFileIo seed, always when zero
+	Probability float64
+
+	// Err fails the call, wrapped in a FileIoError. FileExists and DirExists
+	// report false instead.
+	Err error
+	// Latency delays the call
+	Latency time.Duration
+	// PartialWrite stores only the first Limit bytes of a write then fails it
+	// with Err, io.ErrShortWrite when Err is nil. The rule only matches
+	// WriteFile, WriteBufferedFile and CreateFile.
+	PartialWrite bool
+	// ShortRead returns only the first Limit bytes of a read, without error.
+	// The rule only matches ReadFile, ReadBufferedFile and OpenFile.
+	ShortRead bool
+	// Limit is the number of bytes kept by PartialWrite and ShortRead
+	Limit int
+	// CorruptRead flips every bit of one byte, chosen from the seed, in the
+	// content of a read. The rule only matches the methods ShortRead does.
+	CorruptRead bool
+}
+
+// partialWriteMethods are the methods a PartialWrite rule applies to
+var partialWriteMethods = map[string]bool{
+	"WriteFile":         true,
+	"WriteBufferedFile": true,
+	"CreateFile":        true,
+}
+
+// readMethods are the methods ShortRead and CorruptRead rules apply to
+var readMethods = map[string]bool{
+	"ReadFile":         true,
+	"ReadBufferedFile": true,
+	"OpenFile":         true,
+}
+
+// InjectedFault records a fault fired by a FaultFileIo
+type InjectedFault struct {
+	Rule   int
+	Method string
+	Path   string
+}
+
+// FaultFileIo wraps a FileIo and injects errors, latency, partial writes and
+// corrupted reads in the calls matching its rules. Probabilities and
+// corruption offsets come from a seeded source so failing runs can be
+// replayed.
+type FaultFileIo struct {
+	fileIo FileIo
+	sleep  func(time.Duration)
+
+	mu       sync.Mutex
+	rules    []FaultRule
+	matched  []int
+	fired    []int
+	random   *rand.Rand
+	injected []InjectedFault
+}
+
+// NewFaultFileIo returns a FileIo injecting the faults described by rules in
+// the calls made to fileIo, seed drives every random decision
+func NewFaultFileIo(fileIo FileIo, seed int64, rules ...FaultRule) *FaultFileIo {
+	result := &FaultFileIo{
+		fileIo: fileIo,
+		sleep:  time.Sleep,
+		random: rand.New(rand.NewSource(seed)),
+	}
+
+	for _, rule := range rules {
+		result.AddRule(rule)
+	}
+
+	return result
+}
+
+// AddRule adds rule after the existing ones and returns its index
+func (f *FaultFileIo) AddRule(rule FaultRule) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.rules = append(f.rules, rule)
+	f.matched = append(f.matched, 0)
+	f.fired = append(f.fired, 0)
+	return len(f.rules) - 1
+}
+
+// ClearRules removes every rule, faults stop being injected
+func (f *FaultFileIo) ClearRules() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.rules = nil
+	f.matched = nil
+	f.fired = nil
+}
+
+// Injected returns the faults fired so far, in call order
+func (f *FaultFileIo) Injected() []InjectedFault {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]InjectedFault{}, f.injected...)
+}
+
+func (f *FaultFileIo) GetOperatingSystem() OperatingSystem {
+	return f.fileIo.GetOperatingSystem()
+}
+
+func (f *FaultFileIo) FileExists(path string) bool {
+	if fault := f.inject("FileExists", path, ""); fault.err != nil {
+		return false
+	}
+
+	return f.fileIo.FileExists(path)
+}
+
+func (f *FaultFileIo) DirExists(folderPath string) bool {
+	if fault := f.inject("DirExists", folderPath, ""); fault.err != nil {
+		return false
+	}
+
+	return f.fileIo.DirExists(folderPath)
+}
+
+func (f *FaultFileIo) CreateDir(folderPath string, mode fs.FileMode) error {
+	if fault := f.inject("CreateDir", folderPath, ""); fault.err != nil {
+		return fault.err
+	}
+
+	return f.fileIo.CreateDir(folderPath, mode)
+}
+
+func (f *FaultFileIo) CreateDirAll(folderPath string, mode fs.FileMode, parentMode ...fs.FileMode) error {
+	if fault := f.inject("CreateDirAll", folderPath, ""); fault.err != nil {
+		return fault.err
+	}
+
+	return f.fileIo.CreateDirAll(folderPath, mode, parentMode...)
+}
+
+func (f *FaultFileIo) EnsureDir(folderPath string, mode fs.FileMode) error {
+	if fault := f.inject("EnsureDir", folderPath, ""); fault.err != nil {
+		return fault.err
+	}
+
+	return f.fileIo.EnsureDir(folderPath, mode)
+}
+
+func (f *FaultFileIo) GetExecutionPath() string {
+	return f.fileIo.GetExecutionPath()
+}
+
+func (f *FaultFileIo) ToOsPath(path string) string {
+	return f.fileIo.ToOsPath(path)
+}
+
+func (f *FaultFileIo) GetOsPathSeparator() string {
+	return f.fileIo.GetOsPathSeparator()
+}
+
+func (f *FaultFileIo) ReadFile(path string) ([]byte, error) {
+	fault := f.inject("ReadFile", path, "")
+	if fault.err != nil {
+		return nil, fault.err
+	}
+
+	content, err := f.fileIo.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return fault.read(content), nil
+}
+
+func (f *FaultFileIo) ReadBufferedFile(path string, from, to int) ([]byte, error) {
+	fault := f.inject("ReadBufferedFile", path, "")
+	if fault.err != nil {
+		return nil, fault.err
+	}
+
+	content, err := f.fileIo.ReadBufferedFile(path, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return fault.read(content), nil
+}
+
+func (f *FaultFileIo) WriteFile(path string, data []byte, mode os.FileMode) error {
+	fault := f.inject("WriteFile", path, "")
+	if fault.partialWrite {
+		if err := f.fileIo.WriteFile(path, fault.truncate(data), mode); err != nil {
+			return err
+		}
+		return fault.partialErr
+	}
+	if fault.err != nil {
+		return fault.err
+	}
+
+	return f.fileIo.WriteFile(path, data, mode)
+}
+
+func (f *FaultFileIo) WriteBufferedFile(path string, data []byte, bufferSize int, mode os.FileMode) error {
+	fault := f.inject("WriteBufferedFile", path, "")
+	if fault.partialWrite {
+		if err := f.fileIo.WriteBufferedFile(path, fault.truncate(data), bufferSize, mode); err != nil {
+			return err
+		}
+		return fault.partialErr
+	}
+	if fault.err != nil {
+		return fault.err
+	}
+
+	return f.fileIo.WriteBufferedFile(path, data, bufferSize, mode)
+}
+
+func (f *FaultFileIo) ReadDir(path string) ([]fs.DirEntry, error) {
+	if fault := f.inject("ReadDir", path, ""); fault.err != nil {
+		return nil, fault.err
+	}
+
+	return f.fileIo.ReadDir(path)
+}
+
+func (f *FaultFileIo) JoinPath(parts ...string) string {
+	return f.fileIo.JoinPath(parts...)
+}
+
+func (f *FaultFileIo) CopyFile(source, destination string) error {
+	if fault := f.inject("CopyFile", source, destination); fault.err != nil {
+		return fault.err
+	}
+
+	return f.fileIo.CopyFile(source, destination)
+}
+
+func (f *FaultFileIo) DeleteFile(path string) error {
+	if fault := f.inject("DeleteFile", path, ""); fault.err != nil {
+		return fault.err
+	}
+
+	return f.fileIo.DeleteFile(path)
+}
+
+func (f *FaultFileIo) Move(source, destination string, policy ...OverwritePolicy) error {
+	if fault := f.inject("Move", source, destination); fault.err != nil {
+		return fault.err
+	}
+
+	return f.fileIo.Move(source, destination, policy...)
+}
+
+func (f *FaultFileIo) CopyDir(source, destination string) error {
+	if fault := f.inject("CopyDir", source, destination); fault.err != nil {
+		return fault.err
+	}
+
+	return f.fileIo.CopyDir(source, destination)
+}
+
+func (f *FaultFileIo) DeleteDir(path string) error {
+	if fault := f.inject("DeleteDir", path, ""); fault.err != nil {
+		return fault.err
+	}
+
+	return f.fileIo.DeleteDir(path)
+}
+
+func (f *FaultFileIo) Checksum(path string, method ChecksumMethod) (string, error) {
+	if fault := f.inject("Checksum", path, ""); fault.err != nil {
+		return "", fault.err
+	}
+
+	return f.fileIo.Checksum(path, method)
+}
+
+func (f *FaultFileIo) FileInfo(path string) (os.FileInfo, error) {
+	if fault := f.inject("FileInfo", path, ""); fault.err != nil {
+		return nil, fault.err
+	}
+
+	return f.fileIo.FileInfo(path)
+}
+
+func (f *FaultFileIo) TempDir(dir, pattern string) (string, CleanupFunc, error) {
+	if fault := f.inject("TempDir", dir, ""); fault.err != nil {
+		return "", nil, fault.err
+	}
+
+	return f.fileIo.TempDir(dir, pattern)
+}
+
+func (f *FaultFileIo) TempFile(dir, pattern string) (string, CleanupFunc, error) {
+	if fault := f.inject("TempFile", dir, ""); fault.err != nil {
+		return "", nil, fault.err
+	}
+
+	return f.fileIo.TempFile(dir, pattern)
+}
+
+// OpenFile opens path for reading, a short or corrupted read fault makes the
+// whole file be read upfront
+func (f *FaultFileIo) OpenFile(path string) (io.ReadCloser, error) {
+	fault := f.inject("OpenFile", path, "")
+	if fault.err != nil {
+		return nil, fault.err
+	}
+
+	reader, err := OpenReader(f.fileIo, path)
+	if err != nil || (!fault.shortRead && !fault.corruptRead) {
+		return reader, err
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, NewFileIoError(BackendFault, "OpenFile", path, err)
+	}
+
+	return io.NopCloser(bytes.NewReader(fault.read(content))), nil
+}
+
+// CreateFile creates path for writing, a partial write fault makes the
+// writer store the first bytes it is given and fail afterwards
+func (f *FaultFileIo) CreateFile(path string, mode fs.FileMode) (io.WriteCloser, error) {
+	fault := f.inject("CreateFile", path, "")
+	if fault.err != nil && !fault.partialWrite {
+		return nil, fault.err
+	}
+
+	writer, err := CreateWriter(f.fileIo, path, mode)
+	if err != nil || !fault.partialWrite {
+		return writer, err
+	}
+
+	return &partialWriter{writer: writer, remaining: fault.limit, err: fault.partialErr}, nil
+}
+
+// injectedFault is what the rules fired by a call do to it
+type injectedFault struct {
+	err          error
+	partialWrite bool
+	partialErr   error
+	shortRead    bool
+	corruptRead  bool
+	limit        int
+	corruptAt    float64
+}
+
+// inject evaluates the rules for a call, sleeps for the injected latency and
+// returns the faults to apply
+func (f *FaultFileIo) inject(method, path, destination string) injectedFault {
+	f.mu.Lock()
+	var fault injectedFault
+	var latency time.Duration
+	for i, rule := range f.rules {
+		if !rule.matches(method, path, destination) {
+			continue
+		}
+
+		f.matched[i]++
+		if f.matched[i] <= rule.After {
+			continue
+		}
+		if rule.Times > 0 && f.fired[i] >= rule.Times {
+			continue
+		}
+		if rule.Probability > 0 && f.random.Float64() >= rule.Probability {
+			continue
+		}
+
+		f.fired[i]++
+		f.injected = append(f.injected, InjectedFault{Rule: i, Method: method, Path: path})
+		latency += rule.Latency
+
+		if rule.PartialWrite && !fault.partialWrite {
+			fault.partialWrite = true
+			fault.limit = rule.Limit
+			cause := rule.Err
+			if cause == nil {
+				cause = io.ErrShortWrite
+			}
+			fault.partialErr = NewFileIoError(BackendFault, method, path, cause)
+		}
+		if rule.ShortRead && !fault.shortRead {
+			fault.shortRead = true
+			fault.limit = rule.Limit
+		}
+		if rule.CorruptRead && !fault.corruptRead {
+			fault.corruptRead = true
+			fault.corruptAt = f.random.Float64()
+		}
+		if rule.Err != nil && fault.err == nil {
+			fault.err = NewFileIoError(BackendFault, method, path, rule.Err)
+		}
+	}
+	f.mu.Unlock()
+
+	if latency > 0 {
+		f.sleep(latency)
+	}
+
+	return fault
+}
+
+func (r FaultRule) matches(method, path, destination string) bool {
+	if r.PartialWrite && !partialWriteMethods[method] {
+		return false
+	}
+	if (r.ShortRead || r.CorruptRead) && !readMethods[method] {
+		return false
+	}
+
+	if len(r.Methods) > 0 {
+		found := false
+		for _, candidate := range r.Methods {
+			if candidate == method {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if r.Path == "" {
+		return true
+	}
+
+	return matchGlob(r.Path, path) || (destination != "" && matchGlob(r.Path, destination))
+}
+
+func (fault injectedFault) truncate(data []byte) []byte {
+	if fault.limit < 0 {
+		return data[:0]
+	}
+	if fault.limit < len(data) {
+		return data[:fault.limit]
+	}
+
+	return data
+}
+
+// read applies the short and corrupted read faults to content
+func (fault injectedFault) read(content []byte) []byte {
+	if fault.shortRead {
+		content = fault.truncate(content)
+	}
+
+	if fault.corruptRead && len(content) > 0 {
+		content = append([]byte{}, content...)
+		content[int(fault.corruptAt*float64(len(content)))] ^= 0xff
+	}
+
+	return content
+}
+
+// partialWriter lets remaining bytes through then fails every write with err
+type partialWriter struct {
+	writer    io.WriteCloser
+	remaining int
+	err       error
+}
+
+func (w *partialWriter) Write(p []byte) (int, error) {
+	if len(p) <= w.remaining {
+		n, err := w.writer.Write(p)
+		w.remaining -= n
+		return n, err
+	}
+
+	n, err := w.writer.Write(p[:max(w.remaining, 0)])
+	w.remaining -= n
+	if err != nil {
+		return n, err
+	}
+
+	return n, w.err
+}
+
+func (w *partialWriter) Close() error {
+	return w.writer.Close()
+}
@@ -0,0 +1,201 @@
+package io
+
+import (
+	"io"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFaultFileIo_CallCount(t *testing.T) {
+	memory := NewMemoryFileIo()
+	faulty := NewFaultFileIo(memory, 1, FaultRule{
+		Methods: []string{"WriteFile"},
+		After:   2,
+		Times:   1,
+		Err:     syscall.ENOSPC,
+	})
+
+	assert.NoError(t, faulty.WriteFile("/1.txt", []byte("1"), 0o644))
+	assert.NoError(t, faulty.WriteFile("/2.txt", []byte("2"), 0o644))
+	err := faulty.WriteFile("/3.txt", []byte("3"), 0o644)
+	assert.ErrorIs(t, err, syscall.ENOSPC)
+	assert.Equal(t, BackendFault, err.(*FileIoError).Backend)
+	assert.False(t, memory.FileExists("/3.txt"))
+	assert.NoError(t, faulty.WriteFile("/4.txt", []byte("4"), 0o644))
+
+	_, err = faulty.ReadFile("/1.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, []InjectedFault{{Rule: 0, Method: "WriteFile", Path: "/3.txt"}}, faulty.Injected())
+}
+
+func TestFaultFileIo_PathGlob(t *testing.T) {
+	memory := NewMemoryFileIo()
+	assert.NoError(t, memory.CreateDirAll("/nfs/data", 0o755))
+	assert.NoError(t, memory.WriteFile("/nfs/data/file.txt", []byte("x"), 0o644))
+	assert.NoError(t, memory.WriteFile("/local.txt", []byte("x"), 0o644))
+	faulty := NewFaultFileIo(memory, 1, FaultRule{Path: "/nfs/**", Err: syscall.ESTALE})
+
+	_, err := faulty.ReadFile("/nfs/data/file.txt")
+	assert.ErrorIs(t, err, syscall.ESTALE)
+	assert.False(t, faulty.FileExists("/nfs/data/file.txt"))
+	assert.ErrorIs(t, faulty.CopyFile("/local.txt", "/nfs/data/copy.txt"), syscall.ESTALE)
+
+	_, err = faulty.ReadFile("/local.txt")
+	assert.NoError(t, err)
+	assert.True(t, faulty.FileExists("/local.txt"))
+
+	faulty.ClearRules()
+	_, err = faulty.ReadFile("/nfs/data/file.txt")
+	assert.NoError(t, err)
+}
+
+func TestFaultFileIo_Probability(t *testing.T) {
+	run := func() []InjectedFault {
+		memory := NewMemoryFileIo()
+		assert.NoError(t, memory.WriteFile("/file.txt", []byte("x"), 0o644))
+		faulty := NewFaultFileIo(memory, 42, FaultRule{Probability: 0.5, Err: syscall.EIO})
+		for i := 0; i < 50; i++ {
+			_, _ = faulty.ReadFile("/file.txt")
+		}
+		return faulty.Injected()
+	}
+
+	first := run()
+	assert.Greater(t, len(first), 5)
+	assert.Less(t, len(first), 45)
+	assert.Equal(t, first, run())
+}
+
+func TestFaultFileIo_Latency(t *testing.T) {
+	faulty := NewFaultFileIo(NewMemoryFileIo(), 1,
+		FaultRule{Methods: []string{"DirExists"}, Latency: time.Second},
+		FaultRule{Latency: 500 * time.Millisecond},
+	)
+	var slept []time.Duration
+	faulty.sleep = func(delay time.Duration) {
+		slept = append(slept, delay)
+	}
+
+	assert.True(t, faulty.DirExists("/"))
+	assert.False(t, faulty.FileExists("/missing"))
+	assert.Equal(t, []time.Duration{1500 * time.Millisecond, 500 * time.Millisecond}, slept)
+}
+
+func TestFaultFileIo_PartialWrites(t *testing.T) {
+	memory := NewMemoryFileIo()
+	faulty := NewFaultFileIo(memory, 1, FaultRule{
+		Methods:      []string{"WriteFile", "CreateFile"},
+		PartialWrite: true,
+		Limit:        4,
+	})
+
+	err := faulty.WriteFile("/file.txt", []byte("content"), 0o644)
+	assert.ErrorIs(t, err, io.ErrShortWrite)
+	content, _ := memory.ReadFile("/file.txt")
+	assert.Equal(t, "cont", string(content))
+
+	writer, err := faulty.CreateFile("/stream.txt", 0o644)
+	assert.NoError(t, err)
+	n, err := writer.Write([]byte("ab"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	n, err = writer.Write([]byte("cdef"))
+	assert.ErrorIs(t, err, io.ErrShortWrite)
+	assert.Equal(t, 2, n)
+	assert.NoError(t, writer.Close())
+	content, _ = memory.ReadFile("/stream.txt")
+	assert.Equal(t, "abcd", string(content))
+}
+
+func TestFaultFileIo_PartialWritesLeaveReads(t *testing.T) {
+	memory := NewMemoryFileIo()
+	assert.NoError(t, memory.WriteFile("/file.txt", []byte("content"), 0o644))
+	faulty := NewFaultFileIo(memory, 1, FaultRule{
+		Path:         "/file.txt",
+		PartialWrite: true,
+		Limit:        2,
+		Times:        1,
+	})
+
+	content, err := faulty.ReadFile("/file.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "content", string(content))
+	assert.True(t, faulty.FileExists("/file.txt"))
+	_, err = faulty.Checksum("/file.txt", ChecksumSHA256)
+	assert.NoError(t, err)
+	assert.NoError(t, faulty.CopyFile("/file.txt", "/copy.txt"))
+	assert.Empty(t, faulty.Injected())
+
+	err = faulty.WriteFile("/file.txt", []byte("replaced"), 0o644)
+	assert.ErrorIs(t, err, io.ErrShortWrite)
+	content, err = faulty.ReadFile("/file.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "re", string(content))
+}
+
+func TestFaultFileIo_ReadFaultsLeaveMetadata(t *testing.T) {
+	memory := NewMemoryFileIo()
+	assert.NoError(t, memory.WriteFile("/a.txt", []byte("hello world"), 0o644))
+	faulty := NewFaultFileIo(memory, 1,
+		FaultRule{Path: "/a.txt", ShortRead: true, Limit: 3, Times: 1},
+		FaultRule{Path: "/a.txt", CorruptRead: true, After: 1, Times: 1},
+	)
+
+	assert.True(t, faulty.FileExists("/a.txt"))
+	assert.True(t, faulty.DirExists("/"))
+	_, err := faulty.FileInfo("/a.txt")
+	assert.NoError(t, err)
+	_, err = faulty.ReadDir("/")
+	assert.NoError(t, err)
+	_, err = faulty.Checksum("/a.txt", ChecksumSHA256)
+	assert.NoError(t, err)
+	assert.Empty(t, faulty.Injected())
+
+	content, err := faulty.ReadFile("/a.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "hel", string(content))
+	content, err = faulty.ReadFile("/a.txt")
+	assert.NoError(t, err)
+	assert.NotEqual(t, "hello world", string(content))
+	assert.Equal(t, []InjectedFault{
+		{Rule: 0, Method: "ReadFile", Path: "/a.txt"},
+		{Rule: 1, Method: "ReadFile", Path: "/a.txt"},
+	}, faulty.Injected())
+}
+
+func TestFaultFileIo_Reads(t *testing.T) {
+	memory := NewMemoryFileIo()
+	assert.NoError(t, memory.WriteFile("/file.txt", []byte("content"), 0o644))
+
+	short := NewFaultFileIo(memory, 1, FaultRule{ShortRead: true, Limit: 3})
+	content, err := short.ReadFile("/file.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "con", string(content))
+
+	reader, err := short.OpenFile("/file.txt")
+	assert.NoError(t, err)
+	content, _ = io.ReadAll(reader)
+	assert.Equal(t, "con", string(content))
+	assert.NoError(t, reader.Close())
+
+	corrupt := NewFaultFileIo(memory, 1, FaultRule{CorruptRead: true})
+	content, err = corrupt.ReadFile("/file.txt")
+	assert.NoError(t, err)
+	assert.Len(t, content, 7)
+	assert.NotEqual(t, "content", string(content))
+
+	differences := 0
+	for i := range content {
+		if content[i] != "content"[i] {
+			differences++
+			assert.Equal(t, "content"[i]^0xff, content[i])
+		}
+	}
+	assert.Equal(t, 1, differences)
+
+	original, _ := memory.ReadFile("/file.txt")
+	assert.Equal(t, "content", string(original))
+}
@@ -1,27 +1,58 @@
 package io
 
 import (
-	"crypto/md5"
-	"crypto/sha1"
-	"crypto/sha256"
-	"errors"
-	"fmt"
-	"hash"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
-	"strings"
+	"runtime"
+
+	"github.com/cjlapao/common-go-helpers/io/paths"
 )
 
-type DefaultFileIo struct{}
+type DefaultFileIo struct {
+	platform PlatformProvider
+}
+
+// Option configures a DefaultFileIo created with Default
+type Option func(*DefaultFileIo)
+
+// WithPlatform sets the platform used for OS detection and path handling
+func WithPlatform(platform PlatformProvider) Option {
+	return func(f *DefaultFileIo) {
+		f.platform = platform
+	}
+}
+
+// WithOperatingSystem makes the FileIo behave as if it ran on operatingSystem
+func WithOperatingSystem(operatingSystem OperatingSystem) Option {
+	return WithPlatform(StaticPlatform{
+		OperatingSystemValue: operatingSystem,
+		ArchitectureValue:    runtime.GOARCH,
+	})
+}
+
+func Default(options ...Option) DefaultFileIo {
+	f := DefaultFileIo{}
+	for _, option := range options {
+		option(&f)
+	}
+
+	return f
+}
 
-func Default() DefaultFileIo {
-	return DefaultFileIo{}
+// Platform returns the platform provider of the FileIo, RuntimePlatform
+// unless another one was configured
+func (f DefaultFileIo) Platform() PlatformProvider {
+	if f.platform == nil {
+		return RuntimePlatform{}
+	}
+
+	return f.platform
 }
 
 func (f DefaultFileIo) GetOperatingSystem() OperatingSystem {
-	return getOperatingSystem()
+	return f.Platform().OperatingSystem()
 }
 
 func (f DefaultFileIo) FileExists(path string) bool {
@@ -36,7 +67,7 @@ func (f DefaultFileIo) DirExists(folderPath string) bool {
 
 func (f DefaultFileIo) CreateDir(folderPath string, mode os.FileMode) error {
 	err := os.Mkdir(folderPath, mode)
-	return err
+	return NewFileIoError(BackendDefault, "CreateDir", folderPath, err)
 }
 
 func (f DefaultFileIo) GetExecutionPath() string {
@@ -44,7 +75,7 @@ func (f DefaultFileIo) GetExecutionPath() string {
 }
 
 func (f DefaultFileIo) GetOsPathSeparator() string {
-	switch getOperatingSystem() {
+	switch f.GetOperatingSystem() {
 	case WindowsOs:
 		return "\\"
 	case LinuxOs:
@@ -57,59 +88,54 @@ func (f DefaultFileIo) GetOsPathSeparator() string {
 }
 
 func (f DefaultFileIo) ToOsPath(path string) string {
-	switch getOperatingSystem() {
+	switch f.GetOperatingSystem() {
 	case WindowsOs:
-		return strings.ReplaceAll(path, "/", "\\")
-	case LinuxOs:
-		if strings.ContainsAny(path, ":") {
-			pathParts := strings.Split(path, ":")
-			path = pathParts[1]
-		}
-		return strings.ReplaceAll(path, "\\", "/")
-	case MacOs:
-		if strings.ContainsAny(path, ":") {
-			pathParts := strings.Split(path, ":")
-			path = pathParts[1]
-		}
-		return strings.ReplaceAll(path, "\\", "/")
-	default:
+		return paths.Convert(path, paths.Posix, paths.Windows)
+	case UnknownOs:
 		return path
+	default:
+		return paths.Convert(path, paths.Windows, paths.Posix)
 	}
 }
 
 func (f DefaultFileIo) ReadFile(path string) ([]byte, error) {
-	if !f.FileExists(path) {
-		return nil, os.ErrNotExist
+	if err := requireFile("ReadFile", path); err != nil {
+		return nil, err
 	}
 
 	data, err := os.ReadFile(path)
-	return data, err
+	if err != nil {
+		return nil, NewFileIoError(BackendDefault, "ReadFile", path, err)
+	}
+
+	return data, nil
 }
 
 func (f DefaultFileIo) ReadBufferedFile(path string, from, to int) ([]byte, error) {
-	if !f.FileExists(path) {
-		return nil, os.ErrNotExist
+	if err := requireFile("ReadBufferedFile", path); err != nil {
+		return nil, err
 	}
 
 	file, err := os.Open(path)
 	if err != nil {
-		return nil, err
+		return nil, NewFileIoError(BackendDefault, "ReadBufferedFile", path, err)
 	}
 	defer file.Close()
 
 	stat, err := file.Stat()
 	if err != nil {
-		return nil, err
+		return nil, NewFileIoError(BackendDefault, "ReadBufferedFile", path, err)
 	}
 
-	if int(stat.Size()) < to || to == 0 {
-		to = int(stat.Size())
+	from, to, err = rangeBounds(int(stat.Size()), from, to)
+	if err != nil {
+		return nil, NewFileIoError(BackendDefault, "ReadBufferedFile", path, err)
 	}
 
 	buffer := make([]byte, to-from)
 	_, err = file.ReadAt(buffer, int64(from))
 	if err != nil {
-		return nil, err
+		return nil, NewFileIoError(BackendDefault, "ReadBufferedFile", path, err)
 	}
 
 	return buffer, nil
@@ -118,7 +144,7 @@ func (f DefaultFileIo) ReadBufferedFile(path string, from, to int) ([]byte, erro
 func (f DefaultFileIo) WriteFile(path string, data []byte, mode os.FileMode) error {
 	err := os.WriteFile(path, data, mode)
 	if err != nil {
-		return err
+		return NewFileIoError(BackendDefault, "WriteFile", path, err)
 	}
 
 	return nil
@@ -127,12 +153,12 @@ func (f DefaultFileIo) WriteFile(path string, data []byte, mode os.FileMode) err
 func (f DefaultFileIo) WriteBufferedFile(path string, data []byte, bufferSize int, mode os.FileMode) error {
 	file, err := os.Create(path)
 	if err != nil {
-		return err
+		return NewFileIoError(BackendDefault, "WriteBufferedFile", path, err)
 	}
 	defer file.Close()
 
 	if err := file.Chmod(mode); err != nil {
-		return err
+		return NewFileIoError(BackendDefault, "WriteBufferedFile", path, err)
 	}
 
 	for i := 0; i < len(data); i += bufferSize {
@@ -143,7 +169,7 @@ func (f DefaultFileIo) WriteBufferedFile(path string, data []byte, bufferSize in
 
 		_, err = file.Write(data[i:end])
 		if err != nil {
-			return err
+			return NewFileIoError(BackendDefault, "WriteBufferedFile", path, err)
 		}
 	}
 
@@ -151,24 +177,32 @@ func (f DefaultFileIo) WriteBufferedFile(path string, data []byte, bufferSize in
 }
 
 func (f DefaultFileIo) ReadDir(path string) ([]fs.DirEntry, error) {
+	if err := requireDir("ReadDir", path); err != nil {
+		return nil, err
+	}
+
 	dir, err := os.ReadDir(path)
 	if err != nil {
-		return nil, err
+		return nil, NewFileIoError(BackendDefault, "ReadDir", path, err)
 	}
 
 	return dir, nil
 }
 
 func (f DefaultFileIo) JoinPath(parts ...string) string {
-	for i := range parts {
-		parts[i] = strings.ReplaceAll(parts[i], "\\", "")
-		parts[i] = strings.ReplaceAll(parts[i], "/", "")
+	return f.GetOperatingSystem().PathFlavour().Join(parts...)
+}
+
+func (f DefaultFileIo) CopyFile(source, destination string) error {
+	if err := requireFile("CopyFile", source); err != nil {
+		return err
 	}
 
-	return strings.Join(parts, f.GetOsPathSeparator())
+	err := copyFile(source, destination)
+	return NewFileIoLinkError(BackendDefault, "CopyFile", source, destination, err)
 }
 
-func (f DefaultFileIo) CopyFile(source, destination string) error {
+func copyFile(source, destination string) error {
 	sourceFile, err := os.Open(source)
 	if err != nil {
 		return err
@@ -207,26 +241,30 @@ func (f DefaultFileIo) CopyFile(source, destination string) error {
 func (f DefaultFileIo) DeleteFile(path string) error {
 	err := os.Remove(path)
 	if err != nil {
-		return err
+		return NewFileIoError(BackendDefault, "DeleteFile", path, err)
 	}
 
 	return nil
 }
 
 func (f DefaultFileIo) CopyDir(source, destination string) error {
+	if err := requireDir("CopyDir", source); err != nil {
+		return err
+	}
+
 	sourceInfo, err := os.Stat(source)
 	if err != nil {
-		return err
+		return NewFileIoLinkError(BackendDefault, "CopyDir", source, destination, err)
 	}
 
 	err = os.MkdirAll(destination, sourceInfo.Mode())
 	if err != nil {
-		return err
+		return NewFileIoLinkError(BackendDefault, "CopyDir", source, destination, err)
 	}
 
 	directory, err := os.ReadDir(source)
 	if err != nil {
-		return err
+		return NewFileIoLinkError(BackendDefault, "CopyDir", source, destination, err)
 	}
 
 	for _, file := range directory {
@@ -252,48 +290,65 @@ func (f DefaultFileIo) CopyDir(source, destination string) error {
 func (f DefaultFileIo) DeleteDir(path string) error {
 	err := os.RemoveAll(path)
 	if err != nil {
-		return err
+		return NewFileIoError(BackendDefault, "DeleteDir", path, err)
 	}
 
 	return nil
 }
 
 func (f DefaultFileIo) Checksum(path string, method ChecksumMethod) (string, error) {
+	if err := requireFile("Checksum", path); err != nil {
+		return "", err
+	}
+
 	file, err := os.Open(path)
 	if err != nil {
-		return "", err
+		return "", NewFileIoError(BackendDefault, "Checksum", path, err)
 	}
-	var hash hash.Hash
-	switch method {
-	case ChecksumMD5:
-		hash = md5.New()
-		_, err := io.Copy(hash, file)
-		if err != nil {
-			return "", err
-		}
-	case ChecksumSHA1:
-		hash = sha1.New()
-		_, err := io.Copy(hash, file)
-		if err != nil {
-			return "", err
-		}
-	case ChecksumSHA256:
-		hash = sha256.New()
-		_, err := io.Copy(hash, file)
-		if err != nil {
-			return "", err
-		}
-	default:
-		return "", errors.New("invalid checksum method")
+	defer file.Close()
+
+	checksum, err := checksumReader(file, method)
+	if err != nil {
+		return "", NewFileIoError(BackendDefault, "Checksum", path, err)
 	}
 
-	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+	return checksum, nil
 }
 
 func (f DefaultFileIo) FileInfo(path string) (os.FileInfo, error) {
 	fileInfo, err := os.Stat(path)
 	if err != nil {
-		return nil, err
+		return nil, NewFileIoError(BackendDefault, "FileInfo", path, err)
 	}
 	return fileInfo, nil
 }
+
+// requireFile fails with ErrIsDirectory when path is a directory, or with the
+// stat error when it cannot be found
+func requireFile(op, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return NewFileIoError(BackendDefault, op, path, err)
+	}
+
+	if info.IsDir() {
+		return NewFileIoError(BackendDefault, op, path, ErrIsDirectory)
+	}
+
+	return nil
+}
+
+// requireDir fails with ErrNotDirectory when path is not a directory, or with
+// the stat error when it cannot be found
+func requireDir(op, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return NewFileIoError(BackendDefault, op, path, err)
+	}
+
+	if !info.IsDir() {
+		return NewFileIoError(BackendDefault, op, path, ErrNotDirectory)
+	}
+
+	return nil
+}
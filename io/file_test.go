@@ -92,8 +92,7 @@ func TestGetExecutionPath(t *testing.T) {
 
 func TestGetOsPathSeparator(t *testing.T) {
 	t.Run("Windows OS", func(t *testing.T) {
-		os.Setenv("TEST_OS_OVERRIDE", "windows")
-		defaultClient := Default()
+		defaultClient := Default(WithOperatingSystem(WindowsOs))
 		expectedSeparator := "\\"
 		actualSeparator := defaultClient.GetOsPathSeparator()
 
@@ -103,8 +102,7 @@ func TestGetOsPathSeparator(t *testing.T) {
 	})
 
 	t.Run("Linux OS", func(t *testing.T) {
-		os.Setenv("TEST_OS_OVERRIDE", "linux")
-		defaultClient := Default()
+		defaultClient := Default(WithOperatingSystem(LinuxOs))
 		expectedSeparator := "/"
 		actualSeparator := defaultClient.GetOsPathSeparator()
 
@@ -114,8 +112,7 @@ func TestGetOsPathSeparator(t *testing.T) {
 	})
 
 	t.Run("Mac OS", func(t *testing.T) {
-		os.Setenv("TEST_OS_OVERRIDE", "darwin")
-		defaultClient := Default()
+		defaultClient := Default(WithOperatingSystem(MacOs))
 		expectedSeparator := "/"
 		actualSeparator := defaultClient.GetOsPathSeparator()
 
@@ -125,8 +122,7 @@ func TestGetOsPathSeparator(t *testing.T) {
 	})
 
 	t.Run("Unknown OS", func(t *testing.T) {
-		os.Setenv("TEST_OS_OVERRIDE", "unknown")
-		defaultClient := Default()
+		defaultClient := Default(WithOperatingSystem(UnknownOs))
 		expectedSeparator := "/"
 		actualSeparator := defaultClient.GetOsPathSeparator()
 
@@ -138,8 +134,7 @@ func TestGetOsPathSeparator(t *testing.T) {
 
 func TestToOsPath(t *testing.T) {
 	t.Run("Windows OS", func(t *testing.T) {
-		defaultClient := Default()
-		os.Setenv("TEST_OS_OVERRIDE", "windows")
+		defaultClient := Default(WithOperatingSystem(WindowsOs))
 		expectedPath := "C:\\path\\to\\file"
 		actualPath := defaultClient.ToOsPath("C:/path/to/file")
 
@@ -149,8 +144,7 @@ func TestToOsPath(t *testing.T) {
 	})
 
 	t.Run("Linux OS", func(t *testing.T) {
-		defaultClient := Default()
-		os.Setenv("TEST_OS_OVERRIDE", "linux")
+		defaultClient := Default(WithOperatingSystem(LinuxOs))
 		expectedPath := "/path/to/file"
 		actualPath := defaultClient.ToOsPath("C:\\path\\to\\file")
 
@@ -160,8 +154,7 @@ func TestToOsPath(t *testing.T) {
 	})
 
 	t.Run("Mac OS", func(t *testing.T) {
-		defaultClient := Default()
-		os.Setenv("TEST_OS_OVERRIDE", "darwin")
+		defaultClient := Default(WithOperatingSystem(MacOs))
 		expectedPath := "/path/to/file"
 		actualPath := defaultClient.ToOsPath("C:\\path\\to\\file")
 
@@ -170,9 +163,28 @@ func TestToOsPath(t *testing.T) {
 		}
 	})
 
+	t.Run("Linux OS Keeps Colons", func(t *testing.T) {
+		defaultClient := Default(WithOperatingSystem(LinuxOs))
+		expectedPath := "/data/a:b/file"
+		actualPath := defaultClient.ToOsPath("/data/a:b/file")
+
+		if actualPath != expectedPath {
+			t.Errorf("Expected OS path to be %s, but got %s", expectedPath, actualPath)
+		}
+	})
+
+	t.Run("Linux OS UNC Path", func(t *testing.T) {
+		defaultClient := Default(WithOperatingSystem(LinuxOs))
+		expectedPath := "//server/share/file"
+		actualPath := defaultClient.ToOsPath("\\\\server\\share\\file")
+
+		if actualPath != expectedPath {
+			t.Errorf("Expected OS path to be %s, but got %s", expectedPath, actualPath)
+		}
+	})
+
 	t.Run("Unknown OS", func(t *testing.T) {
-		defaultClient := Default()
-		os.Setenv("TEST_OS_OVERRIDE", "unknown")
+		defaultClient := Default(WithOperatingSystem(UnknownOs))
 		expectedPath := "C:\\path\\to\\file"
 		actualPath := defaultClient.ToOsPath("C:\\path\\to\\file")
 
@@ -276,6 +288,20 @@ func TestReadBufferedFile(t *testing.T) {
 
 		assert.Equal(t, buffer, expectedBuffer)
 	})
+
+	t.Run("Invalid Ranges", func(t *testing.T) {
+		defaultClient := Default()
+		existingFilePath := filepath.Join(getTestPath(), "test_file_1.txt")
+
+		for _, bounds := range [][2]int{{10, 5}, {100, 0}, {100, 200}, {-1, 5}} {
+			_, err := defaultClient.ReadBufferedFile(existingFilePath, bounds[0], bounds[1])
+			assert.ErrorIs(t, err, ErrInvalidRange, "range %v", bounds)
+		}
+
+		buffer, err := defaultClient.ReadBufferedFile(existingFilePath, 43, 0)
+		assert.NoError(t, err)
+		assert.Empty(t, buffer)
+	})
 }
 
 func TestWriteFile(t *testing.T) {
@@ -434,10 +460,8 @@ func TestReadDir(t *testing.T) {
 }
 
 func TestJoinPath(t *testing.T) {
-	defaultClient := Default()
-
 	t.Run("Join Path with Windows Separator", func(t *testing.T) {
-		os.Setenv("TEST_OS_OVERRIDE", "windows")
+		defaultClient := Default(WithOperatingSystem(WindowsOs))
 		expectedPath := "path\\to\\file"
 		actualPath := defaultClient.JoinPath("path\\", "to\\", "file")
 
@@ -447,7 +471,7 @@ func TestJoinPath(t *testing.T) {
 	})
 
 	t.Run("Join Path with Linux Separator", func(t *testing.T) {
-		os.Setenv("TEST_OS_OVERRIDE", "linux")
+		defaultClient := Default(WithOperatingSystem(LinuxOs))
 		expectedPath := "path/to/file"
 		actualPath := defaultClient.JoinPath("path/", "to/", "file")
 
@@ -457,7 +481,7 @@ func TestJoinPath(t *testing.T) {
 	})
 
 	t.Run("Join Path with Mixed Separators", func(t *testing.T) {
-		os.Setenv("TEST_OS_OVERRIDE", "windows")
+		defaultClient := Default(WithOperatingSystem(WindowsOs))
 		expectedPath := "path\\to\\file"
 		actualPath := defaultClient.JoinPath("path\\", "to/", "file")
 
@@ -465,6 +489,26 @@ func TestJoinPath(t *testing.T) {
 			t.Errorf("Expected joined path to be %s, but got %s", expectedPath, actualPath)
 		}
 	})
+
+	t.Run("Join Absolute Path with Nested Part", func(t *testing.T) {
+		defaultClient := Default(WithOperatingSystem(LinuxOs))
+		expectedPath := "/etc/nginx/conf.d"
+		actualPath := defaultClient.JoinPath("/etc", "nginx/conf.d")
+
+		if actualPath != expectedPath {
+			t.Errorf("Expected joined path to be %s, but got %s", expectedPath, actualPath)
+		}
+	})
+
+	t.Run("Join Windows Drive Path", func(t *testing.T) {
+		defaultClient := Default(WithOperatingSystem(WindowsOs))
+		expectedPath := "C:\\etc\\nginx\\conf.d"
+		actualPath := defaultClient.JoinPath("C:\\etc", "nginx/conf.d")
+
+		if actualPath != expectedPath {
+			t.Errorf("Expected joined path to be %s, but got %s", expectedPath, actualPath)
+		}
+	})
 }
 
 func TestCopyFile(t *testing.T) {
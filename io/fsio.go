@@ -0,0 +1,205 @@
+package io
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/cjlapao/common-go-helpers/io/paths"
+)
+
+// FSFileIo serves a read-only fs.FS, like an embed.FS, as a FileIo. Paths are
+// slash separated, a leading slash is ignored, and every operation that would
+// modify the file system fails with ErrReadOnly.
+type FSFileIo struct {
+	fsys fs.FS
+}
+
+// NewFSFileIo returns a read-only FileIo over fsys
+func NewFSFileIo(fsys fs.FS) FSFileIo {
+	return FSFileIo{
+		fsys: fsys,
+	}
+}
+
+func (f FSFileIo) GetOperatingSystem() OperatingSystem {
+	return UnknownOs
+}
+
+func (f FSFileIo) FileExists(path string) bool {
+	_, err := fs.Stat(f.fsys, fsPath(path))
+	return err == nil
+}
+
+func (f FSFileIo) DirExists(folderPath string) bool {
+	_, err := fs.Stat(f.fsys, fsPath(folderPath))
+	return err == nil
+}
+
+func (f FSFileIo) CreateDir(folderPath string, mode fs.FileMode) error {
+	return NewFileIoError(BackendFS, "CreateDir", folderPath, ErrReadOnly)
+}
+
+func (f FSFileIo) CreateDirAll(folderPath string, mode fs.FileMode, parentMode ...fs.FileMode) error {
+	return NewFileIoError(BackendFS, "CreateDirAll", folderPath, ErrReadOnly)
+}
+
+func (f FSFileIo) EnsureDir(folderPath string, mode fs.FileMode) error {
+	return NewFileIoError(BackendFS, "EnsureDir", folderPath, ErrReadOnly)
+}
+
+func (f FSFileIo) GetExecutionPath() string {
+	return os.Args[0]
+}
+
+func (f FSFileIo) ToOsPath(path string) string {
+	return paths.Convert(path, paths.Windows, paths.Posix)
+}
+
+func (f FSFileIo) GetOsPathSeparator() string {
+	return paths.Posix.Separator()
+}
+
+func (f FSFileIo) ReadFile(path string) ([]byte, error) {
+	if err := f.requireFile("ReadFile", path); err != nil {
+		return nil, err
+	}
+
+	content, err := fs.ReadFile(f.fsys, fsPath(path))
+	if err != nil {
+		return nil, NewFileIoError(BackendFS, "ReadFile", path, err)
+	}
+
+	return content, nil
+}
+
+func (f FSFileIo) ReadBufferedFile(path string, from, to int) ([]byte, error) {
+	content, err := f.ReadFile(path)
+	if err != nil {
+		return nil, NewFileIoError(BackendFS, "ReadBufferedFile", path, err)
+	}
+
+	buffer, err := readRange(content, from, to)
+	if err != nil {
+		return nil, NewFileIoError(BackendFS, "ReadBufferedFile", path, err)
+	}
+
+	return buffer, nil
+}
+
+func (f FSFileIo) WriteFile(path string, data []byte, mode os.FileMode) error {
+	return NewFileIoError(BackendFS, "WriteFile", path, ErrReadOnly)
+}
+
+func (f FSFileIo) WriteBufferedFile(path string, data []byte, bufferSize int, mode os.FileMode) error {
+	return NewFileIoError(BackendFS, "WriteBufferedFile", path, ErrReadOnly)
+}
+
+func (f FSFileIo) ReadDir(path string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(f.fsys, fsPath(path))
+	if err != nil {
+		if info, statErr := fs.Stat(f.fsys, fsPath(path)); statErr == nil && !info.IsDir() {
+			err = ErrNotDirectory
+		}
+		return nil, NewFileIoError(BackendFS, "ReadDir", path, err)
+	}
+
+	return entries, nil
+}
+
+func (f FSFileIo) JoinPath(parts ...string) string {
+	return paths.Posix.Join(parts...)
+}
+
+func (f FSFileIo) CopyFile(source, destination string) error {
+	return NewFileIoLinkError(BackendFS, "CopyFile", source, destination, ErrReadOnly)
+}
+
+func (f FSFileIo) DeleteFile(path string) error {
+	return NewFileIoError(BackendFS, "DeleteFile", path, ErrReadOnly)
+}
+
+func (f FSFileIo) Move(source, destination string, policy ...OverwritePolicy) error {
+	return NewFileIoLinkError(BackendFS, "Move", source, destination, ErrReadOnly)
+}
+
+func (f FSFileIo) CopyDir(source, destination string) error {
+	return NewFileIoLinkError(BackendFS, "CopyDir", source, destination, ErrReadOnly)
+}
+
+func (f FSFileIo) DeleteDir(path string) error {
+	return NewFileIoError(BackendFS, "DeleteDir", path, ErrReadOnly)
+}
+
+func (f FSFileIo) Checksum(path string, method ChecksumMethod) (string, error) {
+	reader, err := f.OpenFile(path)
+	if err != nil {
+		return "", NewFileIoError(BackendFS, "Checksum", path, err)
+	}
+	defer reader.Close()
+
+	checksum, err := checksumReader(reader, method)
+	if err != nil {
+		return "", NewFileIoError(BackendFS, "Checksum", path, err)
+	}
+
+	return checksum, nil
+}
+
+func (f FSFileIo) FileInfo(path string) (os.FileInfo, error) {
+	info, err := fs.Stat(f.fsys, fsPath(path))
+	if err != nil {
+		return nil, NewFileIoError(BackendFS, "FileInfo", path, err)
+	}
+
+	return info, nil
+}
+
+func (f FSFileIo) TempDir(dir, pattern string) (string, CleanupFunc, error) {
+	return "", nil, NewFileIoError(BackendFS, "TempDir", dir, ErrReadOnly)
+}
+
+func (f FSFileIo) TempFile(dir, pattern string) (string, CleanupFunc, error) {
+	return "", nil, NewFileIoError(BackendFS, "TempFile", dir, ErrReadOnly)
+}
+
+func (f FSFileIo) OpenFile(path string) (io.ReadCloser, error) {
+	if err := f.requireFile("OpenFile", path); err != nil {
+		return nil, err
+	}
+
+	file, err := f.fsys.Open(fsPath(path))
+	if err != nil {
+		return nil, NewFileIoError(BackendFS, "OpenFile", path, err)
+	}
+
+	return file, nil
+}
+
+func (f FSFileIo) CreateFile(path string, mode fs.FileMode) (io.WriteCloser, error) {
+	return nil, NewFileIoError(BackendFS, "CreateFile", path, ErrReadOnly)
+}
+
+func (f FSFileIo) requireFile(op, path string) error {
+	info, err := fs.Stat(f.fsys, fsPath(path))
+	if err != nil {
+		return NewFileIoError(BackendFS, op, path, err)
+	}
+
+	if info.IsDir() {
+		return NewFileIoError(BackendFS, op, path, ErrIsDirectory)
+	}
+
+	return nil
+}
+
+// fsPath turns a FileIo path into the unrooted form fs.FS expects
+func fsPath(path string) string {
+	cleaned := strings.TrimLeft(paths.Posix.Clean("/"+path), "/")
+	if cleaned == "" {
+		return "."
+	}
+
+	return cleaned
+}
@@ -0,0 +1,65 @@
+package io
+
+import (
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestFS() fstest.MapFS {
+	return fstest.MapFS{
+		"config/app.yaml":      {Data: []byte("name: app"), Mode: 0o644},
+		"config/env/prod.yaml": {Data: []byte("env: prod"), Mode: 0o600},
+	}
+}
+
+func TestFSFileIo(t *testing.T) {
+	fsFileIo := NewFSFileIo(newTestFS())
+
+	content, err := fsFileIo.ReadFile("/config/app.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "name: app", string(content))
+
+	partial, err := fsFileIo.ReadBufferedFile("config/app.yaml", 6, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "app", string(partial))
+
+	entries, err := fsFileIo.ReadDir("/config")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app.yaml", "env"}, entryNames(entries))
+
+	entries, err = fsFileIo.ReadDir("/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"config"}, entryNames(entries))
+
+	_, err = fsFileIo.ReadDir("/config/app.yaml")
+	assert.ErrorIs(t, err, ErrNotDirectory)
+
+	_, err = fsFileIo.ReadFile("/config")
+	assert.ErrorIs(t, err, ErrIsDirectory)
+
+	_, err = fsFileIo.ReadFile("/missing")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	info, err := fsFileIo.FileInfo("/config/env/prod.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0o600), info.Mode())
+
+	reader, err := fsFileIo.OpenFile("/config/env/prod.yaml")
+	assert.NoError(t, err)
+	streamed, _ := io.ReadAll(reader)
+	assert.NoError(t, reader.Close())
+	assert.Equal(t, "env: prod", string(streamed))
+
+	checksum, err := fsFileIo.Checksum("/config/app.yaml", ChecksumMD5)
+	assert.NoError(t, err)
+	assert.Len(t, checksum, 32)
+
+	assert.ErrorIs(t, fsFileIo.WriteFile("/config/app.yaml", nil, 0o644), ErrReadOnly)
+	assert.ErrorIs(t, fsFileIo.DeleteDir("/config"), fs.ErrPermission)
+	_, err = fsFileIo.CreateFile("/config/new.yaml", 0o644)
+	assert.ErrorIs(t, err, ErrReadOnly)
+}
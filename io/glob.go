@@ -0,0 +1,53 @@
+package io
+
+import (
+	"path"
+	"strings"
+)
+
+// matchGlob reports whether name matches pattern. Both are treated as slash
+// separated, "*" and "?" match within a segment as in path.Match and a "**"
+// segment matches any number of segments. A pattern without a slash is
+// matched against the last segment of name only.
+func matchGlob(pattern, name string) bool {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(name))
+		return ok
+	}
+
+	return matchSegments(splitSegments(pattern), splitSegments(name))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
+
+func splitSegments(value string) []string {
+	value = strings.Trim(value, "/")
+	if value == "" {
+		return nil
+	}
+
+	return strings.Split(value, "/")
+}
@@ -0,0 +1,31 @@
+package io
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"*.key", "/etc/ssl/server.key", true},
+		{"*.key", "/etc/ssl/server.pem", false},
+		{"/etc/*/server.key", "/etc/ssl/server.key", true},
+		{"/etc/*", "/etc/ssl/server.key", false},
+		{"/etc/**", "/etc/ssl/server.key", true},
+		{"/etc/**", "/etc", true},
+		{"**/secrets/*", "/home/user/secrets/token", true},
+		{"**/secrets/*", "/secrets/token", true},
+		{"/data/file-?.txt", "/data/file-1.txt", true},
+		{"/data/file-?.txt", "/data/file-10.txt", false},
+		{"C:/Users/**/*.key", `C:\Users\me\id.key`, true},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.match, matchGlob(test.pattern, test.name), "%s ~ %s", test.pattern, test.name)
+	}
+}
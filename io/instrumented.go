@@ -0,0 +1,374 @@
+package io
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// InstrumentedOption configures an InstrumentedFileIo
+type InstrumentedOption func(*InstrumentedFileIo)
+
+// WithMetricsSink records the metrics of every call in sink
+func WithMetricsSink(sink MetricsSink) InstrumentedOption {
+	return func(f *InstrumentedFileIo) {
+		f.sink = sink
+	}
+}
+
+// WithTracer starts a span on tracer around every call
+func WithTracer(tracer Tracer) InstrumentedOption {
+	return func(f *InstrumentedFileIo) {
+		f.tracer = tracer
+	}
+}
+
+// InstrumentedFileIo wraps a FileIo and reports the count, latency, bytes and
+// errors of every call to a MetricsSink and a Tracer. Path helpers that never
+// touch the file system, like JoinPath or ToOsPath, are passed through without
+// being recorded. Streams opened through OpenFile and CreateFile are recorded
+// once they are closed, with the bytes that went through them. Copies, moves
+// and checksums report the size of the source files they went through,
+// measured with FileInfo before the call so the walk is not part of its
+// duration, and only kept when the call succeeded.
+type InstrumentedFileIo struct {
+	fileIo FileIo
+	sink   MetricsSink
+	tracer Tracer
+	now    func() time.Time
+}
+
+// NewInstrumentedFileIo returns a FileIo recording the calls made to fileIo
+func NewInstrumentedFileIo(fileIo FileIo, options ...InstrumentedOption) InstrumentedFileIo {
+	result := InstrumentedFileIo{
+		fileIo: fileIo,
+		now:    time.Now,
+	}
+
+	for _, option := range options {
+		option(&result)
+	}
+
+	return result
+}
+
+func (f InstrumentedFileIo) GetOperatingSystem() OperatingSystem {
+	return f.fileIo.GetOperatingSystem()
+}
+
+func (f InstrumentedFileIo) FileExists(path string) bool {
+	done := f.start("FileExists", path, "")
+	exists := f.fileIo.FileExists(path)
+	done(0, 0, nil)
+	return exists
+}
+
+func (f InstrumentedFileIo) DirExists(folderPath string) bool {
+	done := f.start("DirExists", folderPath, "")
+	exists := f.fileIo.DirExists(folderPath)
+	done(0, 0, nil)
+	return exists
+}
+
+func (f InstrumentedFileIo) CreateDir(folderPath string, mode fs.FileMode) error {
+	done := f.start("CreateDir", folderPath, "")
+	err := f.fileIo.CreateDir(folderPath, mode)
+	done(0, 0, err)
+	return err
+}
+
+func (f InstrumentedFileIo) CreateDirAll(folderPath string, mode fs.FileMode, parentMode ...fs.FileMode) error {
+	done := f.start("CreateDirAll", folderPath, "")
+	err := f.fileIo.CreateDirAll(folderPath, mode, parentMode...)
+	done(0, 0, err)
+	return err
+}
+
+func (f InstrumentedFileIo) EnsureDir(folderPath string, mode fs.FileMode) error {
+	done := f.start("EnsureDir", folderPath, "")
+	err := f.fileIo.EnsureDir(folderPath, mode)
+	done(0, 0, err)
+	return err
+}
+
+func (f InstrumentedFileIo) GetExecutionPath() string {
+	return f.fileIo.GetExecutionPath()
+}
+
+func (f InstrumentedFileIo) ToOsPath(path string) string {
+	return f.fileIo.ToOsPath(path)
+}
+
+func (f InstrumentedFileIo) GetOsPathSeparator() string {
+	return f.fileIo.GetOsPathSeparator()
+}
+
+func (f InstrumentedFileIo) ReadFile(path string) ([]byte, error) {
+	done := f.start("ReadFile", path, "")
+	content, err := f.fileIo.ReadFile(path)
+	done(int64(len(content)), 0, err)
+	return content, err
+}
+
+func (f InstrumentedFileIo) ReadBufferedFile(path string, from, to int) ([]byte, error) {
+	done := f.start("ReadBufferedFile", path, "")
+	content, err := f.fileIo.ReadBufferedFile(path, from, to)
+	done(int64(len(content)), 0, err)
+	return content, err
+}
+
+func (f InstrumentedFileIo) WriteFile(path string, data []byte, mode os.FileMode) error {
+	done := f.start("WriteFile", path, "")
+	err := f.fileIo.WriteFile(path, data, mode)
+	done(0, writtenBytes(data, err), err)
+	return err
+}
+
+func (f InstrumentedFileIo) WriteBufferedFile(path string, data []byte, bufferSize int, mode os.FileMode) error {
+	done := f.start("WriteBufferedFile", path, "")
+	err := f.fileIo.WriteBufferedFile(path, data, bufferSize, mode)
+	done(0, writtenBytes(data, err), err)
+	return err
+}
+
+func (f InstrumentedFileIo) ReadDir(path string) ([]fs.DirEntry, error) {
+	done := f.start("ReadDir", path, "")
+	entries, err := f.fileIo.ReadDir(path)
+	done(0, 0, err)
+	return entries, err
+}
+
+func (f InstrumentedFileIo) JoinPath(parts ...string) string {
+	return f.fileIo.JoinPath(parts...)
+}
+
+func (f InstrumentedFileIo) CopyFile(source, destination string) error {
+	size := f.treeSize(source)
+	done := f.start("CopyFile", source, destination)
+	err := f.fileIo.CopyFile(source, destination)
+	done(transferred(size, err), transferred(size, err), err)
+	return err
+}
+
+func (f InstrumentedFileIo) DeleteFile(path string) error {
+	done := f.start("DeleteFile", path, "")
+	err := f.fileIo.DeleteFile(path)
+	done(0, 0, err)
+	return err
+}
+
+func (f InstrumentedFileIo) Move(source, destination string, policy ...OverwritePolicy) error {
+	size := f.treeSize(source)
+	done := f.start("Move", source, destination)
+	err := f.fileIo.Move(source, destination, policy...)
+	done(transferred(size, err), transferred(size, err), err)
+	return err
+}
+
+func (f InstrumentedFileIo) CopyDir(source, destination string) error {
+	size := f.treeSize(source)
+	done := f.start("CopyDir", source, destination)
+	err := f.fileIo.CopyDir(source, destination)
+	done(transferred(size, err), transferred(size, err), err)
+	return err
+}
+
+func (f InstrumentedFileIo) DeleteDir(path string) error {
+	done := f.start("DeleteDir", path, "")
+	err := f.fileIo.DeleteDir(path)
+	done(0, 0, err)
+	return err
+}
+
+func (f InstrumentedFileIo) Checksum(path string, method ChecksumMethod) (string, error) {
+	size := f.treeSize(path)
+	done := f.start("Checksum", path, "")
+	checksum, err := f.fileIo.Checksum(path, method)
+	done(transferred(size, err), 0, err)
+	return checksum, err
+}
+
+func (f InstrumentedFileIo) FileInfo(path string) (os.FileInfo, error) {
+	done := f.start("FileInfo", path, "")
+	info, err := f.fileIo.FileInfo(path)
+	done(0, 0, err)
+	return info, err
+}
+
+func (f InstrumentedFileIo) TempDir(dir, pattern string) (string, CleanupFunc, error) {
+	done := f.start("TempDir", dir, "")
+	path, cleanup, err := f.fileIo.TempDir(dir, pattern)
+	done(0, 0, err)
+	return path, cleanup, err
+}
+
+func (f InstrumentedFileIo) TempFile(dir, pattern string) (string, CleanupFunc, error) {
+	done := f.start("TempFile", dir, "")
+	path, cleanup, err := f.fileIo.TempFile(dir, pattern)
+	done(0, 0, err)
+	return path, cleanup, err
+}
+
+// OpenFile opens path for reading, the call is recorded when the returned
+// reader is closed
+func (f InstrumentedFileIo) OpenFile(path string) (io.ReadCloser, error) {
+	done := f.start("OpenFile", path, "")
+	reader, err := OpenReader(f.fileIo, path)
+	if err != nil {
+		done(0, 0, err)
+		return nil, err
+	}
+
+	return &instrumentedReader{reader: reader, done: done}, nil
+}
+
+// CreateFile creates path for writing, the call is recorded when the returned
+// writer is closed
+func (f InstrumentedFileIo) CreateFile(path string, mode fs.FileMode) (io.WriteCloser, error) {
+	done := f.start("CreateFile", path, "")
+	writer, err := CreateWriter(f.fileIo, path, mode)
+	if err != nil {
+		done(0, 0, err)
+		return nil, err
+	}
+
+	return &instrumentedWriter{writer: writer, done: done}, nil
+}
+
+// start begins recording method and returns the function finishing the
+// record with the bytes moved and the error returned by the call
+func (f InstrumentedFileIo) start(method, path, destination string) func(read, written int64, err error) {
+	var endSpan func(call CallMetrics)
+	if f.tracer != nil {
+		attributes := map[string]string{"path": path}
+		if destination != "" {
+			attributes["destination"] = destination
+		}
+		endSpan = f.tracer.StartSpan(method, attributes)
+	}
+
+	started := f.now()
+	return func(read, written int64, err error) {
+		call := CallMetrics{
+			Method:       method,
+			Path:         path,
+			Destination:  destination,
+			Duration:     f.now().Sub(started),
+			BytesRead:    read,
+			BytesWritten: written,
+			Err:          err,
+		}
+
+		if f.sink != nil {
+			f.sink.RecordCall(call)
+		}
+		if endSpan != nil {
+			endSpan(call)
+		}
+	}
+}
+
+// treeSize returns the size of the file at path, or of every file below it
+// for a directory, it is zero when path cannot be read or nothing records
+// the calls
+func (f InstrumentedFileIo) treeSize(path string) int64 {
+	if f.sink == nil && f.tracer == nil {
+		return 0
+	}
+
+	info, err := f.fileIo.FileInfo(path)
+	if err != nil {
+		return 0
+	}
+	if !info.IsDir() {
+		return info.Size()
+	}
+
+	entries, err := f.fileIo.ReadDir(path)
+	if err != nil {
+		return 0
+	}
+
+	var size int64
+	for _, entry := range entries {
+		size += f.treeSize(f.fileIo.JoinPath(path, entry.Name()))
+	}
+
+	return size
+}
+
+func writtenBytes(data []byte, err error) int64 {
+	return transferred(int64(len(data)), err)
+}
+
+// transferred returns size for a successful call and zero for a failed one
+func transferred(size int64, err error) int64 {
+	if err != nil {
+		return 0
+	}
+
+	return size
+}
+
+type instrumentedReader struct {
+	reader io.ReadCloser
+	done   func(read, written int64, err error)
+	read   int64
+	err    error
+	once   sync.Once
+}
+
+func (r *instrumentedReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	if err != nil && !errors.Is(err, io.EOF) && r.err == nil {
+		r.err = err
+	}
+
+	return n, err
+}
+
+func (r *instrumentedReader) Close() error {
+	err := r.reader.Close()
+	r.once.Do(func() {
+		if r.err == nil {
+			r.err = err
+		}
+		r.done(r.read, 0, r.err)
+	})
+
+	return err
+}
+
+type instrumentedWriter struct {
+	writer  io.WriteCloser
+	done    func(read, written int64, err error)
+	written int64
+	err     error
+	once    sync.Once
+}
+
+func (w *instrumentedWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.written += int64(n)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+
+	return n, err
+}
+
+func (w *instrumentedWriter) Close() error {
+	err := w.writer.Close()
+	w.once.Do(func() {
+		if w.err == nil {
+			w.err = err
+		}
+		w.done(0, w.written, w.err)
+	})
+
+	return err
+}
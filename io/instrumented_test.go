@@ -0,0 +1,210 @@
+package io
+
+import (
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// steppingClock advances by step every time it is read
+func steppingClock(step time.Duration) func() time.Time {
+	current := time.Unix(0, 0)
+	return func() time.Time {
+		current = current.Add(step)
+		return current
+	}
+}
+
+func TestInstrumentedFileIo_Metrics(t *testing.T) {
+	memory := NewMemoryFileIo()
+	sink := NewMemoryMetricsSink()
+	instrumented := NewInstrumentedFileIo(memory, WithMetricsSink(sink))
+	instrumented.now = steppingClock(2 * time.Millisecond)
+
+	assert.NoError(t, instrumented.WriteFile("/file.txt", []byte("content"), 0o644))
+	content, err := instrumented.ReadFile("/file.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "content", string(content))
+	_, err = instrumented.ReadFile("/missing.txt")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	partial, err := instrumented.ReadBufferedFile("/file.txt", 0, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, "con", string(partial))
+	assert.True(t, instrumented.FileExists("/file.txt"))
+	assert.Equal(t, "/a/b", instrumented.JoinPath("/a", "b"))
+
+	snapshot := sink.Snapshot()
+	assert.NotContains(t, snapshot, "JoinPath")
+
+	read := snapshot["ReadFile"]
+	assert.Equal(t, int64(2), read.Calls)
+	assert.Equal(t, int64(1), read.Errors)
+	assert.Equal(t, int64(7), read.BytesRead)
+	assert.Equal(t, 4*time.Millisecond, read.TotalDuration)
+	assert.Equal(t, 2*time.Millisecond, read.MaxDuration)
+	assert.Equal(t, int64(2), read.BucketCounts[3])
+
+	assert.Equal(t, int64(7), snapshot["WriteFile"].BytesWritten)
+	assert.Equal(t, int64(3), snapshot["ReadBufferedFile"].BytesRead)
+	assert.Equal(t, int64(1), snapshot["FileExists"].Calls)
+
+	sink.Reset()
+	assert.Empty(t, sink.Snapshot())
+}
+
+func TestInstrumentedFileIo_CopyBytes(t *testing.T) {
+	memory := NewMemoryFileIo()
+	sink := NewMemoryMetricsSink()
+	instrumented := NewInstrumentedFileIo(memory, WithMetricsSink(sink))
+	assert.NoError(t, memory.CreateDirAll("/tree/nested", 0o755))
+	assert.NoError(t, memory.WriteFile("/tree/a.txt", []byte("12345"), 0o644))
+	assert.NoError(t, memory.WriteFile("/tree/nested/b.txt", []byte("123"), 0o644))
+
+	assert.NoError(t, instrumented.CopyFile("/tree/a.txt", "/copy.txt"))
+	assert.NoError(t, instrumented.CopyDir("/tree", "/copy"))
+	assert.NoError(t, instrumented.Move("/copy", "/moved"))
+	_, err := instrumented.Checksum("/tree/nested/b.txt", ChecksumSHA256)
+	assert.NoError(t, err)
+	assert.Error(t, instrumented.CopyFile("/missing.txt", "/other.txt"))
+
+	snapshot := sink.Snapshot()
+	assert.Equal(t, int64(5), snapshot["CopyFile"].BytesRead)
+	assert.Equal(t, int64(5), snapshot["CopyFile"].BytesWritten)
+	assert.Equal(t, int64(8), snapshot["CopyDir"].BytesRead)
+	assert.Equal(t, int64(8), snapshot["CopyDir"].BytesWritten)
+	assert.Equal(t, int64(8), snapshot["Move"].BytesWritten)
+	assert.Equal(t, int64(3), snapshot["Checksum"].BytesRead)
+	assert.Equal(t, int64(0), snapshot["Checksum"].BytesWritten)
+}
+
+func TestInstrumentedFileIo_CopyDurations(t *testing.T) {
+	memory := NewMemoryFileIo()
+	assert.NoError(t, memory.CreateDirAll("/tree/nested", 0o755))
+	assert.NoError(t, memory.WriteFile("/tree/nested/a.txt", []byte("12345"), 0o644))
+	assert.NoError(t, memory.CreateDirAll("/merged/tree", 0o755))
+	assert.NoError(t, memory.WriteFile("/merged/tree/old.txt", []byte("already there"), 0o644))
+
+	// every call costs latency on a fake clock, the sizing walk included
+	current := time.Unix(0, 0)
+	faulty := NewFaultFileIo(memory, 1,
+		FaultRule{Methods: []string{"CopyDir", "Move"}, Latency: time.Millisecond},
+		FaultRule{Methods: []string{"FileInfo", "ReadDir"}, Latency: time.Second},
+	)
+	faulty.sleep = func(delay time.Duration) { current = current.Add(delay) }
+	sink := NewMemoryMetricsSink()
+	instrumented := NewInstrumentedFileIo(faulty, WithMetricsSink(sink))
+	instrumented.now = func() time.Time { return current }
+
+	assert.NoError(t, instrumented.CopyDir("/tree", "/copy"))
+	assert.NoError(t, instrumented.Move("/copy", "/merged/tree", OverwriteMerge))
+
+	snapshot := sink.Snapshot()
+	assert.Equal(t, time.Millisecond, snapshot["CopyDir"].TotalDuration)
+	assert.Equal(t, time.Millisecond, snapshot["Move"].TotalDuration)
+	assert.Equal(t, int64(5), snapshot["CopyDir"].BytesWritten)
+	assert.Equal(t, int64(5), snapshot["Move"].BytesWritten)
+}
+
+func TestInstrumentedFileIo_Streams(t *testing.T) {
+	sink := NewMemoryMetricsSink()
+	instrumented := NewInstrumentedFileIo(NewMemoryFileIo(), WithMetricsSink(sink))
+
+	writer, err := instrumented.CreateFile("/stream.txt", 0o644)
+	assert.NoError(t, err)
+	_, err = io.WriteString(writer, "streamed content")
+	assert.NoError(t, err)
+	assert.NotContains(t, sink.Snapshot(), "CreateFile")
+	assert.NoError(t, writer.Close())
+	assert.ErrorIs(t, writer.Close(), fs.ErrClosed)
+
+	reader, err := instrumented.OpenFile("/stream.txt")
+	assert.NoError(t, err)
+	content, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "streamed content", string(content))
+	assert.NoError(t, reader.Close())
+
+	_, err = instrumented.OpenFile("/missing.txt")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	snapshot := sink.Snapshot()
+	assert.Equal(t, int64(1), snapshot["CreateFile"].Calls)
+	assert.Equal(t, int64(16), snapshot["CreateFile"].BytesWritten)
+	assert.Equal(t, int64(2), snapshot["OpenFile"].Calls)
+	assert.Equal(t, int64(1), snapshot["OpenFile"].Errors)
+	assert.Equal(t, int64(16), snapshot["OpenFile"].BytesRead)
+}
+
+func TestInstrumentedFileIo_Tracer(t *testing.T) {
+	type span struct {
+		method     string
+		attributes map[string]string
+		ended      bool
+		err        error
+	}
+	var spans []*span
+	tracer := TracerFunc(func(method string, attributes map[string]string) func(call CallMetrics) {
+		current := &span{method: method, attributes: attributes}
+		spans = append(spans, current)
+		return func(call CallMetrics) {
+			current.ended = true
+			current.err = call.Err
+		}
+	})
+
+	memory := NewMemoryFileIo()
+	assert.NoError(t, memory.WriteFile("/source.txt", []byte("x"), 0o644))
+	instrumented := NewInstrumentedFileIo(memory, WithTracer(tracer))
+
+	assert.NoError(t, instrumented.CopyFile("/source.txt", "/target.txt"))
+	_, err := instrumented.ReadDir("/missing")
+	assert.Error(t, err)
+
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "CopyFile", spans[0].method)
+	assert.Equal(t, map[string]string{"path": "/source.txt", "destination": "/target.txt"}, spans[0].attributes)
+	assert.True(t, spans[0].ended)
+	assert.NoError(t, spans[0].err)
+	assert.Equal(t, "ReadDir", spans[1].method)
+	assert.Equal(t, map[string]string{"path": "/missing"}, spans[1].attributes)
+	assert.True(t, spans[1].ended)
+	assert.Equal(t, err, spans[1].err)
+}
+
+func TestPrometheusExporter(t *testing.T) {
+	sink := NewMemoryMetricsSink(time.Millisecond, 10*time.Millisecond)
+	sink.RecordCall(CallMetrics{Method: "ReadFile", Duration: 500 * time.Microsecond, BytesRead: 10})
+	sink.RecordCall(CallMetrics{Method: "ReadFile", Duration: 5 * time.Millisecond, BytesRead: 5})
+	sink.RecordCall(CallMetrics{Method: "WriteFile", Duration: 20 * time.Millisecond, Err: fs.ErrPermission})
+
+	var output strings.Builder
+	written, err := NewPrometheusExporter(sink).WriteTo(&output)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(output.Len()), written)
+
+	text := output.String()
+	expected := []string{
+		"# TYPE fileio_calls_total counter",
+		`fileio_calls_total{method="ReadFile"} 2`,
+		`fileio_calls_total{method="WriteFile"} 1`,
+		`fileio_errors_total{method="ReadFile"} 0`,
+		`fileio_errors_total{method="WriteFile"} 1`,
+		`fileio_read_bytes_total{method="ReadFile"} 15`,
+		"# TYPE fileio_call_duration_seconds histogram",
+		`fileio_call_duration_seconds_bucket{method="ReadFile",le="0.001"} 1`,
+		`fileio_call_duration_seconds_bucket{method="ReadFile",le="0.01"} 2`,
+		`fileio_call_duration_seconds_bucket{method="ReadFile",le="+Inf"} 2`,
+		`fileio_call_duration_seconds_sum{method="ReadFile"} 0.0055`,
+		`fileio_call_duration_seconds_count{method="ReadFile"} 2`,
+		`fileio_call_duration_seconds_bucket{method="WriteFile",le="0.01"} 0`,
+		`fileio_call_duration_seconds_bucket{method="WriteFile",le="+Inf"} 1`,
+	}
+	for _, line := range expected {
+		assert.Contains(t, text, line+"\n")
+	}
+	assert.Less(t, strings.Index(text, `calls_total{method="ReadFile"}`), strings.Index(text, `calls_total{method="WriteFile"}`))
+}
@@ -10,6 +10,8 @@ type FileIo interface {
 	FileExists(path string) bool
 	DirExists(folderPath string) bool
 	CreateDir(folderPath string, mode fs.FileMode) error
+	CreateDirAll(folderPath string, mode fs.FileMode, parentMode ...fs.FileMode) error
+	EnsureDir(folderPath string, mode fs.FileMode) error
 	GetExecutionPath() string
 	ToOsPath(path string) string
 	GetOsPathSeparator() string
@@ -21,8 +23,11 @@ type FileIo interface {
 	JoinPath(parts ...string) string
 	CopyFile(source, destination string) error
 	DeleteFile(path string) error
+	Move(source, destination string, policy ...OverwritePolicy) error
 	CopyDir(source, destination string) error
 	DeleteDir(path string) error
 	Checksum(path string, method ChecksumMethod) (string, error)
 	FileInfo(path string) (os.FileInfo, error)
+	TempDir(dir, pattern string) (string, CleanupFunc, error)
+	TempFile(dir, pattern string) (string, CleanupFunc, error)
 }
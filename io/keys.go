@@ -0,0 +1,96 @@
+package io
+
+import (
+	"fmt"
+	"sync"
+)
+
+// EncryptionKeySize is the key length required by EncryptedFileIo, AES-256
+const EncryptionKeySize = 32
+
+// KeyProvider supplies the keys used by EncryptedFileIo. Every file records
+// the id of the key it was encrypted with, so rotating keys only requires the
+// provider to keep the previous keys available for reading.
+type KeyProvider interface {
+	// CurrentKey returns the id and key new content is encrypted with
+	CurrentKey() (id string, key []byte, err error)
+	// Key returns the key with the given id, ErrUnknownKey when there is none
+	Key(id string) ([]byte, error)
+}
+
+// KeyRing is an in-memory KeyProvider, the last key added is the current one
+type KeyRing struct {
+	mu      sync.RWMutex
+	current string
+	keys    map[string][]byte
+}
+
+// NewKeyRing returns a KeyRing holding key under id
+func NewKeyRing(id string, key []byte) (*KeyRing, error) {
+	result := &KeyRing{keys: map[string][]byte{}}
+	if err := result.Add(id, key); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Add stores key under id and makes it the current key, the previous keys
+// stay available to decrypt the files they encrypted
+func (r *KeyRing) Add(id string, key []byte) error {
+	if err := validateKey(id, key); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.keys[id] = append([]byte{}, key...)
+	r.current = id
+	return nil
+}
+
+// Remove drops the key with the given id, files encrypted with it can no
+// longer be read. The current key cannot be removed.
+func (r *KeyRing) Remove(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if id == r.current {
+		return fmt.Errorf("cannot remove current key %q", id)
+	}
+
+	delete(r.keys, id)
+	return nil
+}
+
+func (r *KeyRing) CurrentKey() (string, []byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.current, r.keys[r.current], nil
+}
+
+func (r *KeyRing) Key(id string) ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, ok := r.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, id)
+	}
+
+	return key, nil
+}
+
+// validateKey checks key is an AES-256 key and id fits in a file header
+func validateKey(id string, key []byte) error {
+	if len(id) == 0 || len(id) > maxKeyIDLength {
+		return fmt.Errorf("key id must be 1 to %d bytes long, got %d", maxKeyIDLength, len(id))
+	}
+	if len(key) != EncryptionKeySize {
+		return fmt.Errorf("key %q is %d bytes long, AES-256 needs %d", id, len(key), EncryptionKeySize)
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package io
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// LockMode defines how an advisory lock is held
+type LockMode int
+
+const (
+	LockExclusive LockMode = iota
+	LockShared
+)
+
+// lockRetryInterval is how often a waiting lock retries when the backend
+// cannot block on a context
+const lockRetryInterval = 10 * time.Millisecond
+
+// FileLock is a held advisory lock, it must be released with Unlock
+type FileLock interface {
+	Path() string
+	Mode() LockMode
+	Unlock() error
+}
+
+// FileLocker acquires advisory locks on paths, the mode defaults to
+// LockExclusive when omitted
+type FileLocker interface {
+	Lock(path string, mode ...LockMode) (FileLock, error)
+	TryLock(path string, mode ...LockMode) (FileLock, error)
+	LockContext(ctx context.Context, path string, mode ...LockMode) (FileLock, error)
+	LockTimeout(path string, timeout time.Duration, mode ...LockMode) (FileLock, error)
+}
+
+func (f DefaultFileIo) Lock(path string, mode ...LockMode) (FileLock, error) {
+	return defaultLocker().Lock(path, mode...)
+}
+
+func (f DefaultFileIo) TryLock(path string, mode ...LockMode) (FileLock, error) {
+	return defaultLocker().TryLock(path, mode...)
+}
+
+func (f DefaultFileIo) LockContext(ctx context.Context, path string, mode ...LockMode) (FileLock, error) {
+	return defaultLocker().LockContext(ctx, path, mode...)
+}
+
+func (f DefaultFileIo) LockTimeout(path string, timeout time.Duration, mode ...LockMode) (FileLock, error) {
+	return defaultLocker().LockTimeout(path, timeout, mode...)
+}
+
+// lockFilePath returns the file guarding path, path with suffix or ".lock"
+// appended
+func lockFilePath(path, suffix string) string {
+	if suffix == "" {
+		suffix = ".lock"
+	}
+
+	return path + suffix
+}
+
+func getLockMode(mode []LockMode) LockMode {
+	if len(mode) == 1 {
+		return mode[0]
+	}
+
+	return LockExclusive
+}
+
+// waitForLock keeps calling try until it stops returning ErrLocked or the
+// context is done
+func waitForLock(ctx context.Context, try func() (FileLock, error)) (FileLock, error) {
+	ticker := time.NewTicker(lockRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		lock, err := try()
+		if !errors.Is(err, ErrLocked) {
+			return lock, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func lockWithTimeout(locker FileLocker, path string, timeout time.Duration, mode []LockMode) (FileLock, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return locker.LockContext(ctx, path, mode...)
+}
@@ -0,0 +1,134 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package io
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// FlockLocker holds cross-process advisory locks using flock(2) on a
+// "<path>.lock" file next to path, so directories can be locked too. The lock
+// file is created when missing and kept after Unlock, removing it would let
+// a waiting process lock a file that is no longer the lock file.
+type FlockLocker struct {
+	Suffix string
+}
+
+type flockLock struct {
+	mu   sync.Mutex
+	file *os.File
+	path string
+	mode LockMode
+}
+
+func NewFlockLocker() FlockLocker {
+	return FlockLocker{Suffix: ".lock"}
+}
+
+func defaultLocker() FileLocker {
+	return NewFlockLocker()
+}
+
+func (l FlockLocker) Lock(path string, mode ...LockMode) (FileLock, error) {
+	return l.acquire(path, getLockMode(mode), true)
+}
+
+func (l FlockLocker) TryLock(path string, mode ...LockMode) (FileLock, error) {
+	return l.acquire(path, getLockMode(mode), false)
+}
+
+func (l FlockLocker) LockContext(ctx context.Context, path string, mode ...LockMode) (FileLock, error) {
+	lock, err := waitForLock(ctx, func() (FileLock, error) {
+		return l.TryLock(path, mode...)
+	})
+	if err != nil {
+		return nil, NewFileIoError(BackendFlock, "LockContext", path, err)
+	}
+
+	return lock, nil
+}
+
+func (l FlockLocker) LockTimeout(path string, timeout time.Duration, mode ...LockMode) (FileLock, error) {
+	return lockWithTimeout(l, path, timeout, mode)
+}
+
+// LockFilePath returns the lock file used to guard path
+func (l FlockLocker) LockFilePath(path string) string {
+	return lockFilePath(path, l.Suffix)
+}
+
+func (l FlockLocker) acquire(path string, mode LockMode, block bool) (FileLock, error) {
+	file, err := os.OpenFile(l.LockFilePath(path), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, NewFileIoError(BackendFlock, "Lock", path, err)
+	}
+
+	how := syscall.LOCK_EX
+	if mode == LockShared {
+		how = syscall.LOCK_SH
+	}
+	if !block {
+		how |= syscall.LOCK_NB
+	}
+
+	for {
+		err = syscall.Flock(int(file.Fd()), how)
+		if !errors.Is(err, syscall.EINTR) {
+			break
+		}
+	}
+
+	if err != nil {
+		file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, NewFileIoError(BackendFlock, "TryLock", path, ErrLocked)
+		}
+		return nil, NewFileIoError(BackendFlock, "Lock", path, err)
+	}
+
+	return &flockLock{
+		file: file,
+		path: path,
+		mode: mode,
+	}, nil
+}
+
+func (l *flockLock) Path() string {
+	return l.path
+}
+
+func (l *flockLock) Mode() LockMode {
+	return l.mode
+}
+
+func (l *flockLock) Unlock() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+
+	err := syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+	closeErr := l.file.Close()
+	l.file = nil
+	if err == nil {
+		err = closeErr
+	}
+
+	return NewFileIoError(BackendFlock, "Unlock", l.path, err)
+}
+
+func processExists(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
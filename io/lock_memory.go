@@ -0,0 +1,144 @@
+package io
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// MemoryLocker holds advisory locks inside the current process, it is meant
+// for mock and in-memory backends where there is no file to flock
+type MemoryLocker struct {
+	mu    sync.Mutex
+	locks map[string]*memoryLockState
+}
+
+type memoryLockState struct {
+	readers  int
+	writer   bool
+	released chan struct{}
+}
+
+type memoryLock struct {
+	once   sync.Once
+	locker *MemoryLocker
+	path   string
+	mode   LockMode
+}
+
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{
+		locks: map[string]*memoryLockState{},
+	}
+}
+
+func (l *MemoryLocker) Lock(path string, mode ...LockMode) (FileLock, error) {
+	return l.LockContext(context.Background(), path, mode...)
+}
+
+func (l *MemoryLocker) TryLock(path string, mode ...LockMode) (FileLock, error) {
+	lock, _ := l.tryAcquire(path, getLockMode(mode))
+	if lock == nil {
+		return nil, NewFileIoError(BackendMemory, "TryLock", path, ErrLocked)
+	}
+
+	return lock, nil
+}
+
+func (l *MemoryLocker) LockContext(ctx context.Context, path string, mode ...LockMode) (FileLock, error) {
+	for {
+		lock, released := l.tryAcquire(path, getLockMode(mode))
+		if lock != nil {
+			return lock, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, NewFileIoError(BackendMemory, "LockContext", path, ctx.Err())
+		case <-released:
+		}
+	}
+}
+
+func (l *MemoryLocker) LockTimeout(path string, timeout time.Duration, mode ...LockMode) (FileLock, error) {
+	return lockWithTimeout(l, path, timeout, mode)
+}
+
+// tryAcquire returns the lock when it was granted, otherwise a channel that
+// is closed the next time the current holder releases it
+func (l *MemoryLocker) tryAcquire(path string, mode LockMode) (FileLock, <-chan struct{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.locks == nil {
+		l.locks = map[string]*memoryLockState{}
+	}
+
+	key := filepath.Clean(path)
+	state, ok := l.locks[key]
+	if !ok {
+		state = &memoryLockState{
+			released: make(chan struct{}),
+		}
+		l.locks[key] = state
+	}
+
+	switch {
+	case state.writer:
+		return nil, state.released
+	case mode == LockExclusive && state.readers > 0:
+		return nil, state.released
+	case mode == LockExclusive:
+		state.writer = true
+	default:
+		state.readers++
+	}
+
+	return &memoryLock{
+		locker: l,
+		path:   path,
+		mode:   mode,
+	}, nil
+}
+
+func (l *MemoryLocker) release(path string, mode LockMode) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := filepath.Clean(path)
+	state, ok := l.locks[key]
+	if !ok {
+		return
+	}
+
+	if mode == LockExclusive {
+		state.writer = false
+	} else {
+		state.readers--
+	}
+
+	close(state.released)
+	if !state.writer && state.readers == 0 {
+		delete(l.locks, key)
+		return
+	}
+
+	state.released = make(chan struct{})
+}
+
+func (l *memoryLock) Path() string {
+	return l.path
+}
+
+func (l *memoryLock) Mode() LockMode {
+	return l.mode
+}
+
+func (l *memoryLock) Unlock() error {
+	l.once.Do(func() {
+		l.locker.release(l.path, l.mode)
+	})
+
+	return nil
+}
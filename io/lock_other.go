@@ -0,0 +1,27 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package io
+
+import (
+	"os"
+)
+
+func defaultLocker() FileLocker {
+	return NewPidFileLocker()
+}
+
+// processExists is best effort on platforms without signal 0, FindProcess
+// only fails on Windows when the process is gone
+func processExists(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	_ = process.Release()
+	return true
+}
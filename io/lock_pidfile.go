@@ -0,0 +1,225 @@
+package io
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultPidFileGrace is how long a lock file without a readable PID is
+// considered to be still being written by its owner
+const DefaultPidFileGrace = 10 * time.Second
+
+// PidFileLocker holds locks by creating a "<path>.lock" file containing the
+// owner PID. A lock file whose owner is no longer running is considered stale
+// and taken over. Shared locks are not supported and behave as exclusive.
+type PidFileLocker struct {
+	Suffix string
+	// Grace is how old a lock file without a readable PID must be to be
+	// stale, DefaultPidFileGrace when zero
+	Grace time.Duration
+}
+
+type pidFileLock struct {
+	mu       sync.Mutex
+	path     string
+	lockPath string
+	mode     LockMode
+	released bool
+}
+
+func NewPidFileLocker() *PidFileLocker {
+	return &PidFileLocker{
+		Suffix: ".lock",
+		Grace:  DefaultPidFileGrace,
+	}
+}
+
+func (l *PidFileLocker) Lock(path string, mode ...LockMode) (FileLock, error) {
+	return l.LockContext(context.Background(), path, mode...)
+}
+
+func (l *PidFileLocker) TryLock(path string, mode ...LockMode) (FileLock, error) {
+	lock, err := l.tryLock(path, getLockMode(mode))
+	if err != nil {
+		return nil, NewFileIoError(BackendPidFile, "TryLock", path, err)
+	}
+
+	return lock, nil
+}
+
+func (l *PidFileLocker) LockContext(ctx context.Context, path string, mode ...LockMode) (FileLock, error) {
+	lock, err := waitForLock(ctx, func() (FileLock, error) {
+		return l.TryLock(path, mode...)
+	})
+	if err != nil {
+		return nil, NewFileIoError(BackendPidFile, "LockContext", path, err)
+	}
+
+	return lock, nil
+}
+
+func (l *PidFileLocker) LockTimeout(path string, timeout time.Duration, mode ...LockMode) (FileLock, error) {
+	return lockWithTimeout(l, path, timeout, mode)
+}
+
+// LockFilePath returns the lock file used to guard path
+func (l *PidFileLocker) LockFilePath(path string) string {
+	return lockFilePath(path, l.Suffix)
+}
+
+// IsStale reports whether the lock file for path exists but its owner is no
+// longer running. A lock file that is empty or does not hold a PID is only
+// stale once it is older than the grace period.
+func (l *PidFileLocker) IsStale(path string) (bool, error) {
+	_, stale, err := l.inspect(l.LockFilePath(path))
+	return stale, err
+}
+
+func (l *PidFileLocker) tryLock(path string, mode LockMode) (FileLock, error) {
+	lockPath := l.LockFilePath(path)
+	lock, err := l.create(path, lockPath, mode)
+	if !errors.Is(err, ErrLocked) {
+		return lock, err
+	}
+
+	info, stale, err := l.inspect(lockPath)
+	if err != nil || (info != nil && !stale) {
+		return nil, ErrLocked
+	}
+
+	if info != nil {
+		if err := takeOverLockFile(lockPath, info); err != nil {
+			return nil, err
+		}
+	}
+
+	return l.create(path, lockPath, mode)
+}
+
+// create writes the PID to a temporary file and links it as the lock file,
+// so the lock file never exists without its PID
+func (l *PidFileLocker) create(path, lockPath string, mode LockMode) (FileLock, error) {
+	temp, err := os.CreateTemp(filepath.Dir(lockPath), filepath.Base(lockPath)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(temp.Name())
+
+	_, err = temp.WriteString(strconv.Itoa(os.Getpid()))
+	if err == nil {
+		err = temp.Chmod(0o644)
+	}
+	closeErr := temp.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Link(temp.Name(), lockPath); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return nil, ErrLocked
+		}
+		return nil, err
+	}
+
+	return &pidFileLock{
+		path:     path,
+		lockPath: lockPath,
+		mode:     mode,
+	}, nil
+}
+
+// inspect returns the FileInfo of the lock file, nil when there is none, and
+// whether it is stale
+func (l *PidFileLocker) inspect(lockPath string) (os.FileInfo, bool, error) {
+	file, err := os.Open(lockPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, false, err
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		grace := l.Grace
+		if grace == 0 {
+			grace = DefaultPidFileGrace
+		}
+		return info, time.Since(info.ModTime()) > grace, nil
+	}
+
+	return info, !processExists(pid), nil
+}
+
+// takeOverLockFile moves the stale lock file out of the way. Renaming it is
+// atomic, so only one process moves it, and the file moved is put back when
+// it turns out to be a newer lock than the one found stale.
+func takeOverLockFile(lockPath string, stale os.FileInfo) error {
+	aside := fmt.Sprintf("%s.stale.%d.%d", lockPath, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(lockPath, aside); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer os.Remove(aside)
+
+	moved, err := os.Stat(aside)
+	if err != nil {
+		return err
+	}
+	// inode numbers get reused, the content must not have changed either
+	if !os.SameFile(stale, moved) || !stale.ModTime().Equal(moved.ModTime()) || stale.Size() != moved.Size() {
+		_ = os.Link(aside, lockPath)
+		return ErrLocked
+	}
+
+	return nil
+}
+
+func (l *pidFileLock) Path() string {
+	return l.path
+}
+
+func (l *pidFileLock) Mode() LockMode {
+	return l.mode
+}
+
+func (l *pidFileLock) Unlock() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.released {
+		return nil
+	}
+
+	l.released = true
+	err := os.Remove(l.lockPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return NewFileIoError(BackendPidFile, "Unlock", l.path, err)
+	}
+
+	return nil
+}
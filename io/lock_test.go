@@ -0,0 +1,202 @@
+package io
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testLockerContract(t *testing.T, locker FileLocker, path string) {
+	t.Run("Exclusive Lock Blocks TryLock", func(t *testing.T) {
+		lock, err := locker.Lock(path)
+		assert.NoError(t, err)
+		assert.Equal(t, LockExclusive, lock.Mode())
+		assert.Equal(t, path, lock.Path())
+
+		_, err = locker.TryLock(path)
+		assert.ErrorIs(t, err, ErrLocked)
+
+		assert.NoError(t, lock.Unlock())
+		assert.NoError(t, lock.Unlock())
+
+		lock, err = locker.TryLock(path)
+		assert.NoError(t, err)
+		assert.NoError(t, lock.Unlock())
+	})
+
+	t.Run("Lock Timeout", func(t *testing.T) {
+		lock, err := locker.Lock(path)
+		assert.NoError(t, err)
+		defer lock.Unlock()
+
+		_, err = locker.LockTimeout(path, 30*time.Millisecond)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("Lock Context Waits For Release", func(t *testing.T) {
+		lock, err := locker.Lock(path)
+		assert.NoError(t, err)
+
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			_ = lock.Unlock()
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		second, err := locker.LockContext(ctx, path)
+		assert.NoError(t, err)
+		assert.NoError(t, second.Unlock())
+	})
+}
+
+func TestFlockLocker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flock")
+	testLockerContract(t, Default(), path)
+
+	t.Run("Shared Locks", func(t *testing.T) {
+		locker := Default()
+		first, err := locker.Lock(path, LockShared)
+		assert.NoError(t, err)
+		second, err := locker.TryLock(path, LockShared)
+		assert.NoError(t, err)
+		assert.Equal(t, LockShared, second.Mode())
+
+		_, err = locker.TryLock(path, LockExclusive)
+		assert.ErrorIs(t, err, ErrLocked)
+
+		assert.NoError(t, first.Unlock())
+		assert.NoError(t, second.Unlock())
+	})
+
+	t.Run("Directory Is Locked Through Sidecar", func(t *testing.T) {
+		dir := t.TempDir()
+		locker := NewFlockLocker()
+		lock, err := locker.Lock(dir)
+		assert.NoError(t, err)
+		assert.FileExists(t, dir+".lock")
+
+		_, err = locker.TryLock(dir)
+		assert.ErrorIs(t, err, ErrLocked)
+		assert.NoError(t, lock.Unlock())
+	})
+}
+
+func TestPidFileLocker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pid")
+	locker := NewPidFileLocker()
+	testLockerContract(t, locker, path)
+
+	t.Run("Lock File Contains Pid", func(t *testing.T) {
+		lock, err := locker.Lock(path)
+		assert.NoError(t, err)
+
+		content, err := os.ReadFile(locker.LockFilePath(path))
+		assert.NoError(t, err)
+		assert.Equal(t, strconv.Itoa(os.Getpid()), string(content))
+
+		stale, err := locker.IsStale(path)
+		assert.NoError(t, err)
+		assert.False(t, stale)
+
+		assert.NoError(t, lock.Unlock())
+		assert.NoFileExists(t, locker.LockFilePath(path))
+	})
+
+	t.Run("Stale Lock Is Taken Over", func(t *testing.T) {
+		err := os.WriteFile(locker.LockFilePath(path), []byte("2147483646"), 0o644)
+		assert.NoError(t, err)
+
+		stale, err := locker.IsStale(path)
+		assert.NoError(t, err)
+		assert.True(t, stale)
+
+		lock, err := locker.TryLock(path)
+		assert.NoError(t, err)
+		assert.NoError(t, lock.Unlock())
+	})
+
+	t.Run("Corrupted Lock Is Stale After Grace", func(t *testing.T) {
+		lockPath := locker.LockFilePath(path)
+		for _, content := range []string{"", "not a pid"} {
+			err := os.WriteFile(lockPath, []byte(content), 0o644)
+			assert.NoError(t, err)
+
+			stale, err := locker.IsStale(path)
+			assert.NoError(t, err)
+			assert.False(t, stale)
+			_, err = locker.TryLock(path)
+			assert.ErrorIs(t, err, ErrLocked)
+
+			old := time.Now().Add(-2 * DefaultPidFileGrace)
+			assert.NoError(t, os.Chtimes(lockPath, old, old))
+			lock, err := locker.TryLock(path)
+			assert.NoError(t, err)
+			assert.NoError(t, lock.Unlock())
+		}
+	})
+
+	t.Run("Newer Lock Is Not Taken Over", func(t *testing.T) {
+		lockPath := locker.LockFilePath(path)
+		assert.NoError(t, os.WriteFile(lockPath, []byte("2147483646"), 0o644))
+		stale, err := os.Stat(lockPath)
+		assert.NoError(t, err)
+
+		assert.NoError(t, os.Remove(lockPath))
+		lock, err := locker.Lock(path)
+		assert.NoError(t, err)
+
+		assert.ErrorIs(t, takeOverLockFile(lockPath, stale), ErrLocked)
+		content, err := os.ReadFile(lockPath)
+		assert.NoError(t, err)
+		assert.Equal(t, strconv.Itoa(os.Getpid()), string(content))
+		assert.NoError(t, lock.Unlock())
+	})
+
+	t.Run("Directory", func(t *testing.T) {
+		dir := t.TempDir()
+		lock, err := locker.Lock(dir)
+		assert.NoError(t, err)
+		assert.NoError(t, lock.Unlock())
+		assert.DirExists(t, dir)
+	})
+}
+
+func TestMemoryLocker(t *testing.T) {
+	locker := NewMemoryLocker()
+	testLockerContract(t, locker, "/memory/path")
+
+	t.Run("Shared Locks", func(t *testing.T) {
+		first, err := locker.Lock("/memory/shared", LockShared)
+		assert.NoError(t, err)
+		second, err := locker.TryLock("/memory/shared", LockShared)
+		assert.NoError(t, err)
+
+		_, err = locker.TryLock("/memory/shared")
+		assert.True(t, errors.Is(err, ErrLocked))
+
+		assert.NoError(t, first.Unlock())
+		_, err = locker.TryLock("/memory/shared")
+		assert.ErrorIs(t, err, ErrLocked)
+
+		assert.NoError(t, second.Unlock())
+		lock, err := locker.TryLock("/memory/shared")
+		assert.NoError(t, err)
+		assert.NoError(t, lock.Unlock())
+	})
+
+	t.Run("Paths Are Cleaned", func(t *testing.T) {
+		lock, err := locker.Lock("/memory/a/../b")
+		assert.NoError(t, err)
+
+		_, err = locker.TryLock("/memory/b")
+		assert.ErrorIs(t, err, ErrLocked)
+		assert.NoError(t, lock.Unlock())
+	})
+}
@@ -0,0 +1,181 @@
+package io
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+)
+
+// RedactedPath replaces redacted paths in log records
+const RedactedPath = "[REDACTED]"
+
+// LoggingOption configures a SlogSink
+type LoggingOption func(*SlogSink)
+
+// WithClassLevel logs the calls of every method in class at level
+func WithClassLevel(class MethodClass, level slog.Level) LoggingOption {
+	return func(s *SlogSink) {
+		s.levels[class] = level
+	}
+}
+
+// WithErrorLevel logs failed calls at level, whatever their class
+func WithErrorLevel(level slog.Level) LoggingOption {
+	return func(s *SlogSink) {
+		s.errorLevel = level
+	}
+}
+
+// WithRedactedPaths replaces paths matching any of patterns with RedactedPath.
+// Patterns are slash separated globs where "**" matches any number of
+// segments, a pattern without a slash is matched against the file name. The
+// paths held by the errors of a call are redacted from its message as well.
+func WithRedactedPaths(patterns ...string) LoggingOption {
+	return func(s *SlogSink) {
+		s.redacted = append(s.redacted, patterns...)
+	}
+}
+
+// SlogSink is a MetricsSink writing a log record for every call. Reads are
+// logged at debug level, writes and deletes at info level and failed calls at
+// error level unless configured otherwise.
+type SlogSink struct {
+	logger     *slog.Logger
+	levels     map[MethodClass]slog.Level
+	errorLevel slog.Level
+	redacted   []string
+}
+
+// NewSlogSink returns a sink logging calls to logger, slog.Default when nil
+func NewSlogSink(logger *slog.Logger, options ...LoggingOption) *SlogSink {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	result := &SlogSink{
+		logger: logger,
+		levels: map[MethodClass]slog.Level{
+			MethodClassRead:   slog.LevelDebug,
+			MethodClassWrite:  slog.LevelInfo,
+			MethodClassDelete: slog.LevelInfo,
+		},
+		errorLevel: slog.LevelError,
+	}
+
+	for _, option := range options {
+		option(result)
+	}
+
+	return result
+}
+
+// NewLoggingFileIo returns a FileIo logging every call made to fileIo through
+// logger
+func NewLoggingFileIo(fileIo FileIo, logger *slog.Logger, options ...LoggingOption) InstrumentedFileIo {
+	return NewInstrumentedFileIo(fileIo, WithMetricsSink(NewSlogSink(logger, options...)))
+}
+
+func (s *SlogSink) RecordCall(call CallMetrics) {
+	ctx := context.Background()
+	level := s.levels[MethodClassOf(call.Method)]
+	if call.Err != nil {
+		level = s.errorLevel
+	}
+
+	if !s.logger.Enabled(ctx, level) {
+		return
+	}
+
+	path := s.redact(call.Path)
+	attributes := []slog.Attr{
+		slog.String("method", call.Method),
+		slog.String("class", MethodClassOf(call.Method).String()),
+		slog.String("path", path),
+	}
+
+	destination := s.redact(call.Destination)
+	if call.Destination != "" {
+		attributes = append(attributes, slog.String("destination", destination))
+	}
+	if call.BytesRead > 0 {
+		attributes = append(attributes, slog.Int64("bytes_read", call.BytesRead))
+	}
+	if call.BytesWritten > 0 {
+		attributes = append(attributes, slog.Int64("bytes_written", call.BytesWritten))
+	}
+	attributes = append(attributes, slog.Duration("duration", call.Duration))
+
+	if call.Err != nil {
+		attributes = append(attributes, slog.String("error", s.redactError(call, call.Err)))
+	}
+
+	s.logger.LogAttrs(ctx, level, "FileIo call", attributes...)
+}
+
+// redactError returns the message of err with every redacted path replaced,
+// the paths are collected from the call and from the errors in the chain
+func (s *SlogSink) redactError(call CallMetrics, err error) string {
+	var redacted []string
+	for _, path := range append(errorPaths(err), call.Path, call.Destination) {
+		if path != "" && s.redact(path) != path {
+			redacted = append(redacted, path)
+		}
+	}
+
+	// longer paths first so a redacted parent does not leave a child behind
+	sort.Slice(redacted, func(i, j int) bool {
+		return len(redacted[i]) > len(redacted[j])
+	})
+
+	message := err.Error()
+	for _, path := range redacted {
+		message = strings.ReplaceAll(message, path, RedactedPath)
+	}
+
+	return message
+}
+
+// errorPaths returns the paths held by the FileIoError, PathError and
+// LinkError values in the chain of err
+func errorPaths(err error) []string {
+	var result []string
+	for err != nil {
+		switch typed := err.(type) {
+		case *FileIoError:
+			result = append(result, typed.Path, typed.Destination)
+		case *fs.PathError:
+			result = append(result, typed.Path)
+		case *os.LinkError:
+			result = append(result, typed.Old, typed.New)
+		}
+
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, inner := range joined.Unwrap() {
+				result = append(result, errorPaths(inner)...)
+			}
+			return result
+		}
+
+		err = errors.Unwrap(err)
+	}
+
+	return result
+}
+
+func (s *SlogSink) redact(path string) string {
+	if path == "" {
+		return path
+	}
+
+	for _, pattern := range s.redacted {
+		if matchGlob(pattern, path) {
+			return RedactedPath
+		}
+	}
+
+	return path
+}
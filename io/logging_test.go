@@ -0,0 +1,144 @@
+package io
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func logRecords(t *testing.T, buffer *bytes.Buffer) []map[string]any {
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		record := map[string]any{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+
+	return records
+}
+
+func TestLoggingFileIo(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buffer, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	logging := NewLoggingFileIo(NewMemoryFileIo(), logger)
+
+	assert.NoError(t, logging.WriteFile("/file.txt", []byte("content"), 0o644))
+	_, err := logging.ReadFile("/file.txt")
+	assert.NoError(t, err)
+	assert.NoError(t, logging.CopyFile("/file.txt", "/copy.txt"))
+	assert.NoError(t, logging.DeleteFile("/copy.txt"))
+	_, err = logging.ReadFile("/missing.txt")
+	assert.Error(t, err)
+
+	records := logRecords(t, &buffer)
+	assert.Len(t, records, 4)
+
+	assert.Equal(t, "INFO", records[0]["level"])
+	assert.Equal(t, "WriteFile", records[0]["method"])
+	assert.Equal(t, "write", records[0]["class"])
+	assert.Equal(t, "/file.txt", records[0]["path"])
+	assert.Equal(t, float64(7), records[0]["bytes_written"])
+	assert.Contains(t, records[0], "duration")
+
+	assert.Equal(t, "CopyFile", records[1]["method"])
+	assert.Equal(t, "/copy.txt", records[1]["destination"])
+
+	assert.Equal(t, "DeleteFile", records[2]["method"])
+	assert.Equal(t, "delete", records[2]["class"])
+
+	assert.Equal(t, "ERROR", records[3]["level"])
+	assert.Equal(t, "ReadFile", records[3]["method"])
+	assert.Contains(t, records[3]["error"], "/missing.txt")
+}
+
+func TestLoggingFileIo_Levels(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buffer, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	logging := NewLoggingFileIo(NewMemoryFileIo(), logger,
+		WithClassLevel(MethodClassRead, slog.LevelInfo),
+		WithClassLevel(MethodClassDelete, slog.LevelWarn),
+		WithErrorLevel(slog.LevelWarn),
+	)
+
+	assert.True(t, logging.DirExists("/"))
+	assert.NoError(t, logging.DeleteDir("/missing"))
+	_, err := logging.ReadDir("/missing")
+	assert.Error(t, err)
+
+	records := logRecords(t, &buffer)
+	assert.Len(t, records, 3)
+	assert.Equal(t, "INFO", records[0]["level"])
+	assert.Equal(t, "WARN", records[1]["level"])
+	assert.Equal(t, "WARN", records[2]["level"])
+}
+
+func TestLoggingFileIo_Redaction(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buffer, nil))
+	memory := NewMemoryFileIo()
+	assert.NoError(t, memory.CreateDirAll("/home/user/secrets", 0o700))
+	logging := NewLoggingFileIo(memory, logger, WithRedactedPaths("*.key", "/home/*/secrets/**"))
+
+	assert.NoError(t, logging.WriteFile("/home/user/secrets/token", []byte("x"), 0o600))
+	assert.NoError(t, logging.WriteFile("/home/user/id.key", []byte("x"), 0o600))
+	assert.NoError(t, logging.WriteFile("/home/user/notes.txt", []byte("x"), 0o600))
+	_, err := logging.ReadFile("/home/user/secrets/missing")
+	assert.Error(t, err)
+
+	records := logRecords(t, &buffer)
+	assert.Len(t, records, 4)
+	assert.Equal(t, RedactedPath, records[0]["path"])
+	assert.Equal(t, RedactedPath, records[1]["path"])
+	assert.Equal(t, "/home/user/notes.txt", records[2]["path"])
+	assert.Equal(t, RedactedPath, records[3]["path"])
+	assert.NotContains(t, records[3]["error"], "secrets")
+	assert.NotContains(t, buffer.String(), "token")
+}
+
+func TestLoggingFileIo_RedactionNestedPaths(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buffer, nil))
+	root := t.TempDir()
+	writeTestTree(t, root, map[string]string{"home/user/secrets/token": "x"})
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "backup", "secrets", "token"), 0o755))
+	logging := NewLoggingFileIo(Default(), logger, WithRedactedPaths("**/secrets/**"))
+
+	err := logging.CopyDir(filepath.Join(root, "home", "user"), filepath.Join(root, "backup"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "secrets")
+
+	records := logRecords(t, &buffer)
+	assert.Len(t, records, 1)
+	assert.Equal(t, filepath.Join(root, "home", "user"), records[0]["path"])
+	assert.Contains(t, records[0]["error"], RedactedPath)
+	assert.NotContains(t, records[0]["error"], "secrets")
+
+	joined := errors.Join(
+		&fs.PathError{Op: "open", Path: "/vault/secrets/a", Err: fs.ErrNotExist},
+		&os.LinkError{Op: "rename", Old: "/tmp/b", New: "/vault/secrets/b", Err: fs.ErrExist},
+	)
+	sink := NewSlogSink(logger, WithRedactedPaths("**/secrets/**"))
+	message := sink.redactError(CallMetrics{Path: "/vault"}, NewFileIoError(BackendDefault, "Move", "/vault", joined))
+	assert.NotContains(t, message, "secrets")
+	assert.Contains(t, message, "/tmp/b")
+}
+
+func TestMethodClassOf(t *testing.T) {
+	assert.Equal(t, MethodClassRead, MethodClassOf("ReadFile"))
+	assert.Equal(t, MethodClassRead, MethodClassOf("Checksum"))
+	assert.Equal(t, MethodClassWrite, MethodClassOf("Move"))
+	assert.Equal(t, MethodClassWrite, MethodClassOf("CreateFile"))
+	assert.Equal(t, MethodClassDelete, MethodClassOf("DeleteDir"))
+	assert.Equal(t, "delete", MethodClassDelete.String())
+}
@@ -0,0 +1,695 @@
+package io
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cjlapao/common-go-helpers/io/paths"
+)
+
+// MemoryFileIo is a FileIo keeping files and directories in memory, paths
+// are slash separated and relative paths are taken from the root. It behaves
+// like DefaultFileIo, errors included, which makes it a fast backend for
+// tests and scratch space. Use NewMemoryFileIo to create one.
+type MemoryFileIo struct {
+	mu              sync.RWMutex
+	nodes           map[string]*memoryNode
+	locker          *MemoryLocker
+	counter         uint64
+	caseInsensitive bool
+}
+
+// MemoryOption configures a MemoryFileIo
+type MemoryOption func(*MemoryFileIo)
+
+// WithCaseInsensitivePaths makes paths match whatever their case, like on
+// macOS and Windows. Entries keep the case they were created with, and
+// writing a file or moving an entry onto a name that only differs in case
+// from an existing entry fails with ErrCaseConflict.
+func WithCaseInsensitivePaths() MemoryOption {
+	return func(f *MemoryFileIo) {
+		f.caseInsensitive = true
+	}
+}
+
+type memoryNode struct {
+	path    string
+	dir     bool
+	data    []byte
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+type memoryFileInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func NewMemoryFileIo(options ...MemoryOption) *MemoryFileIo {
+	result := &MemoryFileIo{
+		nodes: map[string]*memoryNode{
+			"/": {
+				path:    "/",
+				dir:     true,
+				mode:    0o755,
+				modTime: time.Now(),
+			},
+		},
+		locker: NewMemoryLocker(),
+	}
+
+	for _, option := range options {
+		option(result)
+	}
+
+	return result
+}
+
+func (f *MemoryFileIo) GetOperatingSystem() OperatingSystem {
+	return UnknownOs
+}
+
+func (f *MemoryFileIo) FileExists(path string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	_, ok := f.lookup(path)
+	return ok
+}
+
+func (f *MemoryFileIo) DirExists(folderPath string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	_, ok := f.lookup(folderPath)
+	return ok
+}
+
+func (f *MemoryFileIo) CreateDir(folderPath string, mode fs.FileMode) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.requireSameCase("CreateDir", folderPath); err != nil {
+		return err
+	}
+	if _, ok := f.lookup(folderPath); ok {
+		return NewFileIoError(BackendMemory, "CreateDir", folderPath, fs.ErrExist)
+	}
+
+	if err := f.requireParent("CreateDir", folderPath); err != nil {
+		return err
+	}
+
+	f.createDir(folderPath, mode)
+	return nil
+}
+
+func (f *MemoryFileIo) CreateDirAll(folderPath string, mode fs.FileMode, parentMode ...fs.FileMode) error {
+	parent := mode
+	if len(parentMode) == 1 {
+		parent = parentMode[0]
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.requireSameCase("CreateDirAll", folderPath); err != nil {
+		return err
+	}
+
+	missing := []string{}
+	current := slashPath(folderPath)
+	for {
+		if node, ok := f.nodes[f.key(current)]; ok {
+			if !node.dir {
+				return NewFileIoError(BackendMemory, "CreateDirAll", current, ErrNotDirectory)
+			}
+			break
+		}
+
+		missing = append(missing, current)
+		current = paths.Posix.Dir(current)
+	}
+
+	for i := len(missing) - 1; i >= 0; i-- {
+		dirMode := parent
+		if i == 0 {
+			dirMode = mode
+		}
+		f.createDir(missing[i], dirMode)
+	}
+
+	return nil
+}
+
+func (f *MemoryFileIo) EnsureDir(folderPath string, mode fs.FileMode) error {
+	f.mu.Lock()
+	err := f.requireSameCase("EnsureDir", folderPath)
+	f.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	info, err := f.FileInfo(folderPath)
+	if err != nil {
+		return f.CreateDirAll(folderPath, mode)
+	}
+
+	if !info.IsDir() {
+		return NewFileIoError(BackendMemory, "EnsureDir", folderPath, ErrNotDirectory)
+	}
+
+	if info.Mode().Perm()&mode.Perm() != mode.Perm() {
+		return NewFileIoError(BackendMemory, "EnsureDir", folderPath, ErrIncompatibleMode)
+	}
+
+	return nil
+}
+
+func (f *MemoryFileIo) GetExecutionPath() string {
+	return os.Args[0]
+}
+
+func (f *MemoryFileIo) ToOsPath(path string) string {
+	return paths.Convert(path, paths.Windows, paths.Posix)
+}
+
+func (f *MemoryFileIo) GetOsPathSeparator() string {
+	return paths.Posix.Separator()
+}
+
+func (f *MemoryFileIo) ReadFile(path string) ([]byte, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	node, err := f.requireFile("ReadFile", path)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.Clone(node.data), nil
+}
+
+func (f *MemoryFileIo) ReadBufferedFile(path string, from, to int) ([]byte, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	node, err := f.requireFile("ReadBufferedFile", path)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer, err := readRange(node.data, from, to)
+	if err != nil {
+		return nil, NewFileIoError(BackendMemory, "ReadBufferedFile", path, err)
+	}
+
+	return buffer, nil
+}
+
+func (f *MemoryFileIo) WriteFile(path string, data []byte, mode os.FileMode) error {
+	return f.writeFile("WriteFile", path, data, mode, false)
+}
+
+func (f *MemoryFileIo) WriteBufferedFile(path string, data []byte, bufferSize int, mode os.FileMode) error {
+	return f.writeFile("WriteBufferedFile", path, data, mode, true)
+}
+
+func (f *MemoryFileIo) ReadDir(path string) ([]fs.DirEntry, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	node, ok := f.lookup(path)
+	if !ok {
+		return nil, NewFileIoError(BackendMemory, "ReadDir", path, fs.ErrNotExist)
+	}
+	if !node.dir {
+		return nil, NewFileIoError(BackendMemory, "ReadDir", path, ErrNotDirectory)
+	}
+
+	entries := []fs.DirEntry{}
+	for _, child := range f.children(f.key(node.path)) {
+		entries = append(entries, fs.FileInfoToDirEntry(child.info()))
+	}
+
+	return entries, nil
+}
+
+func (f *MemoryFileIo) JoinPath(parts ...string) string {
+	return paths.Posix.Join(parts...)
+}
+
+func (f *MemoryFileIo) CopyFile(source, destination string) error {
+	f.mu.RLock()
+	node, err := f.requireFile("CopyFile", source)
+	var data []byte
+	var mode fs.FileMode
+	if err == nil {
+		data, mode = node.data, node.mode
+	}
+	f.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	return f.writeFile("CopyFile", destination, data, mode, true)
+}
+
+func (f *MemoryFileIo) DeleteFile(path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := f.requireFile("DeleteFile", path); err != nil {
+		return err
+	}
+
+	delete(f.nodes, f.key(path))
+	return nil
+}
+
+func (f *MemoryFileIo) Move(source, destination string, policy ...OverwritePolicy) error {
+	overwrite, err := overwritePolicy(policy)
+	if err != nil {
+		return NewFileIoLinkError(BackendMemory, "Move", source, destination, err)
+	}
+
+	if f.caseInsensitive {
+		if f.key(source) == f.key(destination) && slashPath(source) != slashPath(destination) {
+			return f.renameCase(source, destination)
+		}
+
+		f.mu.RLock()
+		node, ok := f.lookup(destination)
+		f.mu.RUnlock()
+		if ok && overwrite != OverwriteMerge && paths.Posix.Base(node.path) != paths.Posix.Base(slashPath(destination)) {
+			return NewFileIoLinkError(BackendMemory, "Move", source, destination, ErrCaseConflict)
+		}
+	}
+
+	return moveByCopy(f, BackendMemory, source, destination, overwrite)
+}
+
+func (f *MemoryFileIo) CopyDir(source, destination string) error {
+	info, err := f.FileInfo(source)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return NewFileIoLinkError(BackendMemory, "CopyDir", source, destination, ErrNotDirectory)
+	}
+
+	if err := f.CreateDirAll(destination, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	entries, err := f.ReadDir(source)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		sourcePath := paths.Posix.Join(source, entry.Name())
+		destinationPath := paths.Posix.Join(destination, entry.Name())
+		if entry.IsDir() {
+			err = f.CopyDir(sourcePath, destinationPath)
+		} else {
+			err = f.CopyFile(sourcePath, destinationPath)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *MemoryFileIo) DeleteDir(path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	node, ok := f.lookup(path)
+	if !ok {
+		return nil
+	}
+	if !node.dir {
+		return NewFileIoError(BackendMemory, "DeleteDir", path, ErrNotDirectory)
+	}
+
+	root := f.key(node.path)
+	for key := range f.nodes {
+		if key != "/" && isWithin(paths.Posix, root, key) {
+			delete(f.nodes, key)
+		}
+	}
+
+	return nil
+}
+
+func (f *MemoryFileIo) Checksum(path string, method ChecksumMethod) (string, error) {
+	content, err := f.ReadFile(path)
+	if err != nil {
+		return "", NewFileIoError(BackendMemory, "Checksum", path, err)
+	}
+
+	checksum, err := checksumReader(bytes.NewReader(content), method)
+	if err != nil {
+		return "", NewFileIoError(BackendMemory, "Checksum", path, err)
+	}
+
+	return checksum, nil
+}
+
+func (f *MemoryFileIo) FileInfo(path string) (os.FileInfo, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	node, ok := f.lookup(path)
+	if !ok {
+		return nil, NewFileIoError(BackendMemory, "FileInfo", path, fs.ErrNotExist)
+	}
+
+	return node.info(), nil
+}
+
+// TempDir creates the directory in dir, or in /tmp when dir is empty
+func (f *MemoryFileIo) TempDir(dir, pattern string) (string, CleanupFunc, error) {
+	path, err := f.createTemp("TempDir", dir, pattern, true)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return path, func() error {
+		return f.DeleteDir(path)
+	}, nil
+}
+
+// TempFile creates the file in dir, or in /tmp when dir is empty
+func (f *MemoryFileIo) TempFile(dir, pattern string) (string, CleanupFunc, error) {
+	path, err := f.createTemp("TempFile", dir, pattern, false)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return path, func() error {
+		err := f.DeleteFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}, nil
+}
+
+func (f *MemoryFileIo) OpenFile(path string) (io.ReadCloser, error) {
+	content, err := f.ReadFile(path)
+	if err != nil {
+		return nil, NewFileIoError(BackendMemory, "OpenFile", path, err)
+	}
+
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+// CreateFile checks path can be written straight away but only stores the
+// content when the writer is closed
+func (f *MemoryFileIo) CreateFile(path string, mode fs.FileMode) (io.WriteCloser, error) {
+	f.mu.RLock()
+	err := f.requireWritable("CreateFile", path)
+	f.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	return &bufferedWriter{
+		close: func(content []byte) error {
+			return f.writeFile("CreateFile", path, content, mode, true)
+		},
+	}, nil
+}
+
+func (f *MemoryFileIo) Lock(path string, mode ...LockMode) (FileLock, error) {
+	return f.locker.Lock(path, mode...)
+}
+
+func (f *MemoryFileIo) TryLock(path string, mode ...LockMode) (FileLock, error) {
+	return f.locker.TryLock(path, mode...)
+}
+
+func (f *MemoryFileIo) LockContext(ctx context.Context, path string, mode ...LockMode) (FileLock, error) {
+	return f.locker.LockContext(ctx, path, mode...)
+}
+
+func (f *MemoryFileIo) LockTimeout(path string, timeout time.Duration, mode ...LockMode) (FileLock, error) {
+	return f.locker.LockTimeout(path, timeout, mode...)
+}
+
+// slashPath turns any path into the absolute, clean and slash separated form
+// used as key by the in-memory and mount table backends
+func slashPath(path string) string {
+	return paths.Posix.Clean("/" + path)
+}
+
+// key returns the map key of path, folded when paths are case insensitive
+func (f *MemoryFileIo) key(path string) string {
+	if f.caseInsensitive {
+		return foldCase(slashPath(path))
+	}
+
+	return slashPath(path)
+}
+
+// realPath returns path under the exact path of its parent, so every node
+// path starts with the path of its parent whatever the case path was given in
+func (f *MemoryFileIo) realPath(path string) string {
+	clean := slashPath(path)
+	if parent, ok := f.lookup(paths.Posix.Dir(clean)); ok && clean != "/" {
+		return paths.Posix.Join(parent.path, paths.Posix.Base(clean))
+	}
+
+	return clean
+}
+
+func (f *MemoryFileIo) lookup(path string) (*memoryNode, bool) {
+	node, ok := f.nodes[f.key(path)]
+	return node, ok
+}
+
+func (f *MemoryFileIo) requireFile(op, path string) (*memoryNode, error) {
+	node, ok := f.lookup(path)
+	if !ok {
+		return nil, NewFileIoError(BackendMemory, op, path, fs.ErrNotExist)
+	}
+	if node.dir {
+		return nil, NewFileIoError(BackendMemory, op, path, ErrIsDirectory)
+	}
+
+	return node, nil
+}
+
+func (f *MemoryFileIo) requireParent(op, path string) error {
+	parent, ok := f.lookup(paths.Posix.Dir(slashPath(path)))
+	if !ok {
+		return NewFileIoError(BackendMemory, op, path, fs.ErrNotExist)
+	}
+	if !parent.dir {
+		return NewFileIoError(BackendMemory, op, path, ErrNotDirectory)
+	}
+
+	return nil
+}
+
+func (f *MemoryFileIo) requireWritable(op, path string) error {
+	if node, ok := f.lookup(path); ok {
+		if node.dir {
+			return NewFileIoError(BackendMemory, op, path, ErrIsDirectory)
+		}
+	}
+	if err := f.requireSameCase(op, path); err != nil {
+		return err
+	}
+
+	return f.requireParent(op, path)
+}
+
+// requireSameCase fails with ErrCaseConflict when path names an existing entry
+// in a different case, which only happens with case-insensitive paths
+func (f *MemoryFileIo) requireSameCase(op, path string) error {
+	if node, ok := f.lookup(path); ok && paths.Posix.Base(node.path) != paths.Posix.Base(slashPath(path)) {
+		return NewFileIoError(BackendMemory, op, path, ErrCaseConflict)
+	}
+
+	return nil
+}
+
+// writeFile stores data at path, like the os package the mode of an existing
+// file is kept unless setMode is true
+func (f *MemoryFileIo) writeFile(op, path string, data []byte, mode fs.FileMode, setMode bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.requireWritable(op, path); err != nil {
+		return err
+	}
+
+	key := f.key(path)
+	node, ok := f.nodes[key]
+	if !ok {
+		node = &memoryNode{
+			path: f.realPath(path),
+			mode: mode.Perm(),
+		}
+		f.nodes[key] = node
+	}
+	if setMode {
+		node.mode = mode.Perm()
+	}
+
+	node.data = bytes.Clone(data)
+	node.modTime = time.Now()
+	return nil
+}
+
+func (f *MemoryFileIo) createDir(path string, mode fs.FileMode) {
+	f.nodes[f.key(path)] = &memoryNode{
+		path:    f.realPath(path),
+		dir:     true,
+		mode:    mode.Perm(),
+		modTime: time.Now(),
+	}
+}
+
+// renameCase changes the case of the name of source to the one of
+// destination, both naming the same entry, the entries beneath it follow
+func (f *MemoryFileIo) renameCase(source, destination string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	node, ok := f.lookup(source)
+	if !ok {
+		return NewFileIoLinkError(BackendMemory, "Move", source, destination, fs.ErrNotExist)
+	}
+	if node.path == "/" {
+		return nil
+	}
+
+	previous := node.path
+	renamed := paths.Posix.Join(paths.Posix.Dir(previous), paths.Posix.Base(slashPath(destination)))
+	for _, other := range f.nodes {
+		if isWithin(paths.Posix, previous, other.path) {
+			other.path = renamed + strings.TrimPrefix(other.path, previous)
+		}
+	}
+
+	return nil
+}
+
+// children returns the nodes directly under the directory with key dir
+func (f *MemoryFileIo) children(dir string) []*memoryNode {
+	children := []*memoryNode{}
+	for key, node := range f.nodes {
+		if key != "/" && paths.Posix.Dir(key) == dir {
+			children = append(children, node)
+		}
+	}
+
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].path < children[j].path
+	})
+
+	return children
+}
+
+// createTemp picks a name from pattern like os.MkdirTemp, the last "*" is
+// replaced by a counter or the counter is appended
+func (f *MemoryFileIo) createTemp(op, dir, pattern string, isDir bool) (string, error) {
+	if dir == "" {
+		dir = "/tmp"
+		if err := f.CreateDirAll(dir, 0o777); err != nil {
+			return "", NewFileIoError(BackendMemory, op, dir, err)
+		}
+	}
+
+	prefix, suffix := pattern, ""
+	if index := strings.LastIndex(pattern, "*"); index >= 0 {
+		prefix, suffix = pattern[:index], pattern[index+1:]
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	parent, ok := f.lookup(dir)
+	if !ok {
+		return "", NewFileIoError(BackendMemory, op, dir, fs.ErrNotExist)
+	}
+	if !parent.dir {
+		return "", NewFileIoError(BackendMemory, op, dir, ErrNotDirectory)
+	}
+
+	for {
+		f.counter++
+		path := paths.Posix.Join(parent.path, prefix+strconv.FormatUint(f.counter, 10)+suffix)
+		if _, exists := f.lookup(path); exists {
+			continue
+		}
+
+		if isDir {
+			f.createDir(path, 0o700)
+		} else {
+			f.nodes[f.key(path)] = &memoryNode{
+				path:    path,
+				mode:    0o600,
+				modTime: time.Now(),
+			}
+		}
+
+		return path, nil
+	}
+}
+
+func (n *memoryNode) info() os.FileInfo {
+	mode := n.mode
+	if n.dir {
+		mode |= fs.ModeDir
+	}
+
+	return memoryFileInfo{
+		name:    paths.Posix.Base(n.path),
+		size:    int64(len(n.data)),
+		mode:    mode,
+		modTime: n.modTime,
+	}
+}
+
+func (i memoryFileInfo) Name() string {
+	return i.name
+}
+
+func (i memoryFileInfo) Size() int64 {
+	return i.size
+}
+
+func (i memoryFileInfo) Mode() fs.FileMode {
+	return i.mode
+}
+
+func (i memoryFileInfo) ModTime() time.Time {
+	return i.modTime
+}
+
+func (i memoryFileInfo) IsDir() bool {
+	return i.mode.IsDir()
+}
+
+func (i memoryFileInfo) Sys() interface{} {
+	return nil
+}
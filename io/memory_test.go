@@ -0,0 +1,137 @@
+package io
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryFileIo_Files(t *testing.T) {
+	memory := NewMemoryFileIo()
+
+	err := memory.WriteFile("/missing/file.txt", []byte("x"), 0o644)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	assert.NoError(t, memory.CreateDirAll("/data/sub", 0o750))
+	assert.NoError(t, memory.WriteFile("/data/sub/file.txt", []byte("content"), 0o640))
+	assert.True(t, memory.FileExists("data/sub/file.txt"))
+
+	content, err := memory.ReadFile("/data/sub/file.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "content", string(content))
+
+	content[0] = 'X'
+	content, _ = memory.ReadFile("/data/sub/file.txt")
+	assert.Equal(t, "content", string(content))
+
+	partial, err := memory.ReadBufferedFile("/data/sub/file.txt", 1, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, "ont", string(partial))
+
+	_, err = memory.ReadBufferedFile("/data/sub/file.txt", 9, 0)
+	assert.ErrorIs(t, err, ErrInvalidRange)
+
+	info, err := memory.FileInfo("/data/sub/file.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "file.txt", info.Name())
+	assert.Equal(t, fs.FileMode(0o640), info.Mode())
+	assert.Equal(t, int64(7), info.Size())
+
+	checksum, err := memory.Checksum("/data/sub/file.txt", ChecksumSHA256)
+	assert.NoError(t, err)
+	assert.Equal(t, "ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73", checksum)
+
+	_, err = memory.ReadFile("/data")
+	assert.ErrorIs(t, err, ErrIsDirectory)
+	assert.ErrorIs(t, memory.DeleteFile("/data"), ErrIsDirectory)
+
+	var fileIoErr *FileIoError
+	_, err = memory.ReadFile("/data/other.txt")
+	assert.ErrorAs(t, err, &fileIoErr)
+	assert.Equal(t, BackendMemory, fileIoErr.Backend)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestMemoryFileIo_Directories(t *testing.T) {
+	memory := NewMemoryFileIo()
+
+	assert.ErrorIs(t, memory.CreateDir("/a/b", 0o755), fs.ErrNotExist)
+	assert.NoError(t, memory.CreateDirAll("/a/b", 0o700, 0o755))
+	assert.ErrorIs(t, memory.CreateDir("/a", 0o755), fs.ErrExist)
+	assert.NoError(t, memory.EnsureDir("/a/b", 0o700))
+	assert.ErrorIs(t, memory.EnsureDir("/a/b", 0o777), ErrIncompatibleMode)
+
+	parent, _ := memory.FileInfo("/a")
+	assert.Equal(t, fs.FileMode(0o755), parent.Mode().Perm())
+	assert.True(t, parent.IsDir())
+
+	assert.NoError(t, memory.WriteFile("/a/z.txt", []byte("z"), 0o644))
+	assert.NoError(t, memory.WriteFile("/a/b/c.txt", []byte("c"), 0o644))
+	assert.ErrorIs(t, memory.CreateDirAll("/a/z.txt/d", 0o755), ErrNotDirectory)
+
+	entries, err := memory.ReadDir("/a")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "z.txt"}, entryNames(entries))
+	assert.True(t, entries[0].IsDir())
+
+	assert.NoError(t, memory.CopyDir("/a", "/copy"))
+	content, err := memory.ReadFile("/copy/b/c.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "c", string(content))
+
+	assert.ErrorIs(t, memory.DeleteDir("/a/z.txt"), ErrNotDirectory)
+	assert.NoError(t, memory.DeleteDir("/a"))
+	assert.NoError(t, memory.DeleteDir("/a"))
+	assert.False(t, memory.FileExists("/a/b/c.txt"))
+	assert.True(t, memory.FileExists("/copy/b/c.txt"))
+}
+
+func TestMemoryFileIo_Move(t *testing.T) {
+	memory := NewMemoryFileIo()
+	assert.NoError(t, memory.CreateDirAll("/source/sub", 0o755))
+	assert.NoError(t, memory.WriteFile("/source/sub/file.txt", []byte("moved"), 0o644))
+	assert.NoError(t, memory.WriteFile("/taken.txt", []byte("taken"), 0o644))
+
+	assert.ErrorIs(t, memory.Move("/source/sub/file.txt", "/taken.txt"), fs.ErrExist)
+	assert.NoError(t, memory.Move("/source/sub/file.txt", "/taken.txt", OverwriteReplace))
+	content, _ := memory.ReadFile("/taken.txt")
+	assert.Equal(t, "moved", string(content))
+
+	assert.NoError(t, memory.Move("/source", "/destination"))
+	assert.False(t, memory.FileExists("/source"))
+	assert.True(t, memory.DirExists("/destination/sub"))
+}
+
+func TestMemoryFileIo_Temp(t *testing.T) {
+	memory := NewMemoryFileIo()
+
+	dir, cleanup, err := memory.TempDir("", "work-*-dir")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(dir, "/tmp/work-"))
+	assert.True(t, strings.HasSuffix(dir, "-dir"))
+	assert.True(t, memory.DirExists(dir))
+
+	file, fileCleanup, err := memory.TempFile(dir, "file")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(file, dir+"/file"))
+	assert.NoError(t, fileCleanup())
+	assert.NoError(t, fileCleanup())
+
+	assert.NoError(t, cleanup())
+	assert.False(t, memory.DirExists(dir))
+
+	_, _, err = memory.TempDir("/missing", "x")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestMemoryFileIo_Lock(t *testing.T) {
+	memory := NewMemoryFileIo()
+
+	lock, err := memory.TryLock("/file")
+	assert.NoError(t, err)
+	_, err = memory.TryLock("/file")
+	assert.ErrorIs(t, err, ErrLocked)
+	assert.NoError(t, lock.Unlock())
+}
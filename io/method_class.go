@@ -0,0 +1,38 @@
+package io
+
+// MethodClass groups FileIo methods by their effect on the file system
+type MethodClass int
+
+const (
+	// MethodClassRead methods only inspect the file system
+	MethodClassRead MethodClass = iota
+	// MethodClassWrite methods create or modify files and directories
+	MethodClassWrite
+	// MethodClassDelete methods remove files and directories
+	MethodClassDelete
+)
+
+func (c MethodClass) String() string {
+	switch c {
+	case MethodClassWrite:
+		return "write"
+	case MethodClassDelete:
+		return "delete"
+	default:
+		return "read"
+	}
+}
+
+// MethodClassOf returns the class of the FileIo method named method, unknown
+// methods are reads
+func MethodClassOf(method string) MethodClass {
+	switch method {
+	case "CreateDir", "CreateDirAll", "EnsureDir", "WriteFile", "WriteBufferedFile",
+		"CopyFile", "CopyDir", "Move", "TempDir", "TempFile", "CreateFile":
+		return MethodClassWrite
+	case "DeleteFile", "DeleteDir":
+		return MethodClassDelete
+	default:
+		return MethodClassRead
+	}
+}
@@ -0,0 +1,148 @@
+package io
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets are the upper bounds of the latency histogram kept by
+// MemoryMetricsSink when no other buckets are given
+var DefaultLatencyBuckets = []time.Duration{
+	100 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+}
+
+// CallMetrics describes one finished FileIo call
+type CallMetrics struct {
+	Method       string
+	Path         string
+	Destination  string
+	Duration     time.Duration
+	BytesRead    int64
+	BytesWritten int64
+	Err          error
+}
+
+// MetricsSink receives the metrics of every call made through an
+// InstrumentedFileIo, it must be safe for concurrent use
+type MetricsSink interface {
+	RecordCall(call CallMetrics)
+}
+
+// Tracer is called around every call made through an InstrumentedFileIo.
+// StartSpan receives the method and its path attributes and returns the
+// function that ends the span once the call finished.
+type Tracer interface {
+	StartSpan(method string, attributes map[string]string) func(call CallMetrics)
+}
+
+// TracerFunc adapts a function to the Tracer interface
+type TracerFunc func(method string, attributes map[string]string) func(call CallMetrics)
+
+func (t TracerFunc) StartSpan(method string, attributes map[string]string) func(call CallMetrics) {
+	return t(method, attributes)
+}
+
+// MethodMetrics are the totals recorded for a method, BucketCounts holds the
+// number of calls that fell in each latency bucket, the last one counting
+// calls slower than every bound
+type MethodMetrics struct {
+	Calls         int64
+	Errors        int64
+	BytesRead     int64
+	BytesWritten  int64
+	TotalDuration time.Duration
+	MaxDuration   time.Duration
+	BucketCounts  []int64
+}
+
+// MemoryMetricsSink aggregates call metrics per method in memory
+type MemoryMetricsSink struct {
+	mu      sync.Mutex
+	buckets []time.Duration
+	methods map[string]*MethodMetrics
+}
+
+// NewMemoryMetricsSink returns an empty sink using buckets as latency
+// histogram bounds, DefaultLatencyBuckets when omitted
+func NewMemoryMetricsSink(buckets ...time.Duration) *MemoryMetricsSink {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+
+	sorted := append([]time.Duration{}, buckets...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	return &MemoryMetricsSink{
+		buckets: sorted,
+		methods: map[string]*MethodMetrics{},
+	}
+}
+
+func (s *MemoryMetricsSink) RecordCall(call CallMetrics) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	metrics, ok := s.methods[call.Method]
+	if !ok {
+		metrics = &MethodMetrics{
+			BucketCounts: make([]int64, len(s.buckets)+1),
+		}
+		s.methods[call.Method] = metrics
+	}
+
+	metrics.Calls++
+	if call.Err != nil {
+		metrics.Errors++
+	}
+	metrics.BytesRead += call.BytesRead
+	metrics.BytesWritten += call.BytesWritten
+	metrics.TotalDuration += call.Duration
+	if call.Duration > metrics.MaxDuration {
+		metrics.MaxDuration = call.Duration
+	}
+
+	bucket := sort.Search(len(s.buckets), func(i int) bool {
+		return call.Duration <= s.buckets[i]
+	})
+	metrics.BucketCounts[bucket]++
+}
+
+// Buckets returns the latency histogram bounds
+func (s *MemoryMetricsSink) Buckets() []time.Duration {
+	return append([]time.Duration{}, s.buckets...)
+}
+
+// Snapshot returns a copy of the metrics recorded so far, by method
+func (s *MemoryMetricsSink) Snapshot() map[string]MethodMetrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := make(map[string]MethodMetrics, len(s.methods))
+	for method, metrics := range s.methods {
+		copied := *metrics
+		copied.BucketCounts = append([]int64{}, metrics.BucketCounts...)
+		snapshot[method] = copied
+	}
+
+	return snapshot
+}
+
+// Reset forgets every recorded call
+func (s *MemoryMetricsSink) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.methods = map[string]*MethodMetrics{}
+}
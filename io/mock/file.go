@@ -23,12 +23,14 @@ type MockFuncArgument struct {
 }
 
 type MockFileIo struct {
-	mocks []*MockOperation
+	mocks  []*MockOperation
+	locker *helpers_io.MemoryLocker
 }
 
 func NewMockFileIo() *MockFileIo {
 	return &MockFileIo{
-		mocks: []*MockOperation{},
+		mocks:  []*MockOperation{},
+		locker: helpers_io.NewMemoryLocker(),
 	}
 }
 
@@ -128,6 +130,61 @@ func (f MockFileIo) CreateDir(folderPath string, mode os.FileMode) error {
 	return nil
 }
 
+func (f MockFileIo) CreateDirAll(folderPath string, mode os.FileMode, parentMode ...os.FileMode) error {
+	for _, op := range f.mocks {
+		if op.Method == "CreateDirAll" {
+			if op.Func != nil {
+				op.CalledWith = []MockFuncArgument{}
+				argument1 := MockFuncArgument{
+					Name:  "folderPath",
+					Value: folderPath,
+				}
+				argument2 := MockFuncArgument{
+					Name:  "mode",
+					Value: mode,
+				}
+				argument3 := MockFuncArgument{
+					Name:  "parentMode",
+					Value: mode,
+				}
+				if len(parentMode) == 1 {
+					argument3.Value = parentMode[0]
+				}
+				op.CalledWith = append(op.CalledWith, argument1, argument2, argument3)
+				return processFunction[error](op.Func, argument1, argument2, argument3)
+			} else {
+				return processResult[error](op.ReturnValue)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (f MockFileIo) EnsureDir(folderPath string, mode os.FileMode) error {
+	for _, op := range f.mocks {
+		if op.Method == "EnsureDir" {
+			if op.Func != nil {
+				op.CalledWith = []MockFuncArgument{}
+				argument1 := MockFuncArgument{
+					Name:  "folderPath",
+					Value: folderPath,
+				}
+				argument2 := MockFuncArgument{
+					Name:  "mode",
+					Value: mode,
+				}
+				op.CalledWith = append(op.CalledWith, argument1, argument2)
+				return processFunction[error](op.Func, argument1, argument2)
+			} else {
+				return processResult[error](op.ReturnValue)
+			}
+		}
+	}
+
+	return nil
+}
+
 func (f MockFileIo) GetExecutionPath() string {
 	for _, op := range f.mocks {
 		if op.Method == "GetExecutionPath" {
@@ -193,7 +250,7 @@ func (f MockFileIo) ReadFile(path string) ([]byte, error) {
 		}
 	}
 
-	return nil, os.ErrNotExist
+	return nil, helpers_io.NewFileIoError(helpers_io.BackendMock, "ReadFile", path, fs.ErrNotExist)
 }
 
 func (f MockFileIo) ReadBufferedFile(path string, from, to int) ([]byte, error) {
@@ -221,7 +278,7 @@ func (f MockFileIo) ReadBufferedFile(path string, from, to int) ([]byte, error)
 		}
 	}
 
-	return nil, os.ErrNotExist
+	return nil, helpers_io.NewFileIoError(helpers_io.BackendMock, "ReadBufferedFile", path, fs.ErrNotExist)
 }
 
 func (f MockFileIo) WriteFile(path string, data []byte, mode os.FileMode) error {
@@ -297,7 +354,7 @@ func (f MockFileIo) ReadDir(path string) ([]fs.DirEntry, error) {
 		}
 	}
 
-	return nil, os.ErrNotExist
+	return nil, helpers_io.NewFileIoError(helpers_io.BackendMock, "ReadDir", path, fs.ErrNotExist)
 }
 
 func (f MockFileIo) JoinPath(parts ...string) string {
@@ -366,6 +423,41 @@ func (f MockFileIo) DeleteFile(path string) error {
 	return nil
 }
 
+func (f MockFileIo) Move(source, destination string, policy ...helpers_io.OverwritePolicy) error {
+	if len(policy) > 1 {
+		return helpers_io.NewFileIoLinkError(helpers_io.BackendMock, "Move", source, destination, helpers_io.ErrTooManyPolicies)
+	}
+
+	for _, op := range f.mocks {
+		if op.Method == "Move" {
+			if op.Func != nil {
+				op.CalledWith = []MockFuncArgument{}
+				argument1 := MockFuncArgument{
+					Name:  "source",
+					Value: source,
+				}
+				argument2 := MockFuncArgument{
+					Name:  "destination",
+					Value: destination,
+				}
+				argument3 := MockFuncArgument{
+					Name:  "policy",
+					Value: helpers_io.OverwriteFail,
+				}
+				if len(policy) > 0 {
+					argument3.Value = policy[0]
+				}
+				op.CalledWith = append(op.CalledWith, argument1, argument2, argument3)
+				return processFunction[error](op.Func, argument1, argument2, argument3)
+			} else {
+				return processResult[error](op.ReturnValue)
+			}
+		}
+	}
+
+	return nil
+}
+
 func (f MockFileIo) CopyDir(source, destination string) error {
 	for _, op := range f.mocks {
 		if op.Method == "CopyDir" {
@@ -431,7 +523,7 @@ func (f MockFileIo) Checksum(path string, method helpers_io.ChecksumMethod) (str
 		}
 	}
 
-	return "", os.ErrNotExist
+	return "", helpers_io.NewFileIoError(helpers_io.BackendMock, "Checksum", path, fs.ErrNotExist)
 }
 
 func (f MockFileIo) FileInfo(path string) (os.FileInfo, error) {
@@ -451,7 +543,7 @@ func (f MockFileIo) FileInfo(path string) (os.FileInfo, error) {
 		}
 	}
 
-	return nil, os.ErrNotExist
+	return nil, helpers_io.NewFileIoError(helpers_io.BackendMock, "FileInfo", path, fs.ErrNotExist)
 }
 
 func processFunction[T any](fn func(args ...MockFuncArgument) interface{}, args ...MockFuncArgument) T {
@@ -1448,3 +1448,138 @@ func TestMockFileIo_FileInfo(t *testing.T) {
 		assert.Empty(t, content)
 	})
 }
+
+func TestMockFileIo_Move(t *testing.T) {
+	t.Run("Mock no Function", func(t *testing.T) {
+		mockFileIo := MockFileIo{}
+		err := mockFileIo.Move("source", "destination")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Mock Function", func(t *testing.T) {
+		mockFileIo := NewMockFileIo()
+		op := mockFileIo.On(MockOperation{
+			Method: "Move",
+			Func: func(args ...MockFuncArgument) interface{} {
+				policy, _ := GetMockFuncArgumentValue[helpers_io.OverwritePolicy](args, "policy")
+				if policy == helpers_io.OverwriteFail {
+					return os.ErrExist
+				}
+				return nil
+			},
+		})
+
+		err := mockFileIo.Move("source", "destination")
+		assert.ErrorIs(t, err, os.ErrExist)
+		assert.Len(t, op.CalledWith, 3)
+
+		err = mockFileIo.Move("source", "destination", helpers_io.OverwriteMerge)
+		assert.NoError(t, err)
+		policy, _ := GetMockFuncArgumentValue[helpers_io.OverwritePolicy](op.CalledWith, "policy")
+		assert.Equal(t, helpers_io.OverwriteMerge, policy)
+	})
+
+	t.Run("Mock Result", func(t *testing.T) {
+		mockFileIo := NewMockFileIo()
+		mockFileIo.On(MockOperation{
+			Method:      "Move",
+			ReturnValue: errors.New("error"),
+		})
+
+		err := mockFileIo.Move("source", "destination")
+		assert.Error(t, err)
+	})
+}
+
+func TestMockFileIo_CreateDirAll(t *testing.T) {
+	t.Run("Mock no Function", func(t *testing.T) {
+		mockFileIo := MockFileIo{}
+		err := mockFileIo.CreateDirAll("a/b/c", os.ModePerm)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Mock Function", func(t *testing.T) {
+		mockFileIo := NewMockFileIo()
+		op := mockFileIo.On(MockOperation{
+			Method: "CreateDirAll",
+			Func: func(args ...MockFuncArgument) interface{} {
+				return nil
+			},
+		})
+
+		err := mockFileIo.CreateDirAll("a/b/c", 0o700, 0o755)
+		assert.NoError(t, err)
+		parentMode, _ := GetMockFuncArgumentValue[os.FileMode](op.CalledWith, "parentMode")
+		assert.Equal(t, os.FileMode(0o755), parentMode)
+
+		err = mockFileIo.CreateDirAll("a/b/c", 0o700)
+		assert.NoError(t, err)
+		parentMode, _ = GetMockFuncArgumentValue[os.FileMode](op.CalledWith, "parentMode")
+		assert.Equal(t, os.FileMode(0o700), parentMode)
+	})
+
+	t.Run("Mock Result", func(t *testing.T) {
+		mockFileIo := NewMockFileIo()
+		mockFileIo.On(MockOperation{
+			Method:      "CreateDirAll",
+			ReturnValue: errors.New("error"),
+		})
+
+		err := mockFileIo.CreateDirAll("a/b/c", os.ModePerm)
+		assert.Error(t, err)
+	})
+}
+
+func TestMockFileIo_EnsureDir(t *testing.T) {
+	t.Run("Mock no Function", func(t *testing.T) {
+		mockFileIo := MockFileIo{}
+		err := mockFileIo.EnsureDir("a", os.ModePerm)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Mock Function", func(t *testing.T) {
+		mockFileIo := NewMockFileIo()
+		op := mockFileIo.On(MockOperation{
+			Method: "EnsureDir",
+			Func: func(args ...MockFuncArgument) interface{} {
+				return errors.New("error")
+			},
+		})
+
+		err := mockFileIo.EnsureDir("a", 0o700)
+		assert.Error(t, err)
+		assert.Len(t, op.CalledWith, 2)
+	})
+
+	t.Run("Mock Result", func(t *testing.T) {
+		mockFileIo := NewMockFileIo()
+		mockFileIo.On(MockOperation{
+			Method:      "EnsureDir",
+			ReturnValue: errors.New("error"),
+		})
+
+		err := mockFileIo.EnsureDir("a", os.ModePerm)
+		assert.Error(t, err)
+	})
+}
+
+func TestMockFileIo_DefaultErrors(t *testing.T) {
+	mockFileIo := MockFileIo{}
+
+	_, err := mockFileIo.ReadFile("file.txt")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	var fileIoErr *helpers_io.FileIoError
+	assert.ErrorAs(t, err, &fileIoErr)
+	assert.Equal(t, helpers_io.BackendMock, fileIoErr.Backend)
+	assert.Equal(t, "ReadFile", fileIoErr.Op)
+	assert.Equal(t, "file.txt", fileIoErr.Path)
+
+	_, err = mockFileIo.Checksum("file.txt", helpers_io.ChecksumMD5)
+	assert.ErrorAs(t, err, &fileIoErr)
+	assert.Equal(t, "Checksum", fileIoErr.Op)
+
+	_, err = mockFileIo.ReadDir("dir")
+	assert.ErrorAs(t, err, &fileIoErr)
+	assert.Equal(t, "ReadDir", fileIoErr.Op)
+}
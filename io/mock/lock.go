@@ -0,0 +1,35 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	helpers_io "github.com/cjlapao/common-go-helpers/io"
+)
+
+// defaultLocker is shared by mocks that were not built with NewMockFileIo
+var defaultLocker = helpers_io.NewMemoryLocker()
+
+func (f MockFileIo) getLocker() *helpers_io.MemoryLocker {
+	if f.locker != nil {
+		return f.locker
+	}
+
+	return defaultLocker
+}
+
+func (f MockFileIo) Lock(path string, mode ...helpers_io.LockMode) (helpers_io.FileLock, error) {
+	return f.getLocker().Lock(path, mode...)
+}
+
+func (f MockFileIo) TryLock(path string, mode ...helpers_io.LockMode) (helpers_io.FileLock, error) {
+	return f.getLocker().TryLock(path, mode...)
+}
+
+func (f MockFileIo) LockContext(ctx context.Context, path string, mode ...helpers_io.LockMode) (helpers_io.FileLock, error) {
+	return f.getLocker().LockContext(ctx, path, mode...)
+}
+
+func (f MockFileIo) LockTimeout(path string, timeout time.Duration, mode ...helpers_io.LockMode) (helpers_io.FileLock, error) {
+	return f.getLocker().LockTimeout(path, timeout, mode...)
+}
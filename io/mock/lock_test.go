@@ -0,0 +1,34 @@
+package mock
+
+import (
+	"testing"
+
+	helpers_io "github.com/cjlapao/common-go-helpers/io"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockFileIo_Lock(t *testing.T) {
+	t.Run("Lock Is Held In Process", func(t *testing.T) {
+		mockFileIo := NewMockFileIo()
+
+		lock, err := mockFileIo.Lock("file.txt")
+		assert.NoError(t, err)
+
+		_, err = mockFileIo.TryLock("file.txt")
+		assert.ErrorIs(t, err, helpers_io.ErrLocked)
+
+		assert.NoError(t, lock.Unlock())
+	})
+
+	t.Run("Zero Value Mock", func(t *testing.T) {
+		mockFileIo := MockFileIo{}
+
+		lock, err := mockFileIo.Lock("file.txt", helpers_io.LockShared)
+		assert.NoError(t, err)
+		assert.Equal(t, helpers_io.LockShared, lock.Mode())
+
+		_, err = mockFileIo.LockTimeout("file.txt", 0)
+		assert.Error(t, err)
+		assert.NoError(t, lock.Unlock())
+	})
+}
@@ -0,0 +1,36 @@
+package mock
+
+import (
+	"io/fs"
+	"testing"
+
+	helpers_io "github.com/cjlapao/common-go-helpers/io"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRootedFileIo_OverMock(t *testing.T) {
+	mockFileIo := NewMockFileIo()
+	op := mockFileIo.On(MockOperation{
+		Method: "ReadFile",
+		FuncWithErr: func(args ...MockFuncArgument) (interface{}, error) {
+			return []byte("content"), nil
+		},
+	})
+	rooted := helpers_io.NewRootedFileIo(mockFileIo, "/srv/data")
+
+	content, err := rooted.ReadFile("../data/../data/users/me.json")
+	assert.ErrorIs(t, err, helpers_io.ErrPathEscapesRoot)
+	assert.Nil(t, content)
+	assert.Len(t, op.CalledWith, 0)
+
+	content, err = rooted.ReadFile("/users/me.json")
+	assert.NoError(t, err)
+	assert.Equal(t, "content", string(content))
+	assert.Equal(t, "/srv/data/users/me.json", op.CalledWith[0].Value)
+
+	_, err = rooted.FileInfo("users/missing.json")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	var fileIoErr *helpers_io.FileIoError
+	assert.ErrorAs(t, err, &fileIoErr)
+	assert.Equal(t, "users/missing.json", fileIoErr.Path)
+}
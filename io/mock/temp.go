@@ -0,0 +1,79 @@
+package mock
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	helpers_io "github.com/cjlapao/common-go-helpers/io"
+)
+
+var tempCounter uint64
+
+func (f MockFileIo) TempDir(dir, pattern string) (string, helpers_io.CleanupFunc, error) {
+	return f.temp("TempDir", dir, pattern)
+}
+
+func (f MockFileIo) TempFile(dir, pattern string) (string, helpers_io.CleanupFunc, error) {
+	return f.temp("TempFile", dir, pattern)
+}
+
+// temp serves TempDir and TempFile, when there is no mock for the method it
+// returns a unique path built like os.MkdirTemp would without touching disk
+func (f MockFileIo) temp(method, dir, pattern string) (string, helpers_io.CleanupFunc, error) {
+	cleanup := f.tempCleanup(method)
+	for _, op := range f.mocks {
+		if op.Method == method {
+			if op.FuncWithErr != nil {
+				op.CalledWith = []MockFuncArgument{}
+				argument1 := MockFuncArgument{
+					Name:  "dir",
+					Value: dir,
+				}
+				argument2 := MockFuncArgument{
+					Name:  "pattern",
+					Value: pattern,
+				}
+				op.CalledWith = append(op.CalledWith, argument1, argument2)
+				path, err := processFunctionWithErr[string](op.FuncWithErr, op.ReturnError, argument1, argument2)
+				return path, cleanup, err
+			} else {
+				return processResult[string](op.ReturnValue), cleanup, op.ReturnError
+			}
+		}
+	}
+
+	if dir == "" {
+		dir = os.TempDir()
+	}
+
+	random := strconv.FormatUint(atomic.AddUint64(&tempCounter, 1), 10)
+	var name string
+	if i := strings.LastIndex(pattern, "*"); i >= 0 {
+		name = pattern[:i] + random + pattern[i+1:]
+	} else {
+		name = pattern + random
+	}
+
+	return filepath.Join(dir, name), cleanup, nil
+}
+
+// tempCleanup returns a cleanup that reports to the "<method>Cleanup" mock
+// when there is one
+func (f MockFileIo) tempCleanup(method string) helpers_io.CleanupFunc {
+	return func() error {
+		for _, op := range f.mocks {
+			if op.Method == method+"Cleanup" {
+				if op.Func != nil {
+					return processFunction[error](op.Func)
+				} else {
+					return processResult[error](op.ReturnValue)
+				}
+			}
+		}
+
+		return nil
+	}
+}
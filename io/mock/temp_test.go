@@ -0,0 +1,79 @@
+package mock
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockFileIo_TempDir(t *testing.T) {
+	t.Run("Mock no Function", func(t *testing.T) {
+		mockFileIo := MockFileIo{}
+		first, cleanup, err := mockFileIo.TempDir("/scratch", "build-*-dir")
+		assert.NoError(t, err)
+		assert.NoError(t, cleanup())
+		assert.Equal(t, "/scratch", filepath.Dir(first))
+		assert.Regexp(t, `^build-\d+-dir$`, filepath.Base(first))
+
+		second, _, _ := mockFileIo.TempDir("/scratch", "build-*-dir")
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("Mock Function", func(t *testing.T) {
+		mockFileIo := NewMockFileIo()
+		op := mockFileIo.On(MockOperation{
+			Method: "TempDir",
+			FuncWithErr: func(args ...MockFuncArgument) (interface{}, error) {
+				pattern, _ := GetMockFuncArgumentValue[string](args, "pattern")
+				return "/tmp/" + pattern, nil
+			},
+		})
+		cleanupErr := errors.New("cleanup failed")
+		mockFileIo.On(MockOperation{
+			Method:      "TempDirCleanup",
+			ReturnValue: cleanupErr,
+		})
+
+		path, cleanup, err := mockFileIo.TempDir("", "fixed")
+		assert.NoError(t, err)
+		assert.Equal(t, "/tmp/fixed", path)
+		assert.Len(t, op.CalledWith, 2)
+		assert.ErrorIs(t, cleanup(), cleanupErr)
+	})
+
+	t.Run("Mock Result", func(t *testing.T) {
+		mockFileIo := NewMockFileIo()
+		mockFileIo.On(MockOperation{
+			Method:      "TempDir",
+			ReturnError: errors.New("error"),
+		})
+
+		path, _, err := mockFileIo.TempDir("", "fixed")
+		assert.Error(t, err)
+		assert.Equal(t, "", path)
+	})
+}
+
+func TestMockFileIo_TempFile(t *testing.T) {
+	t.Run("Mock no Function", func(t *testing.T) {
+		mockFileIo := MockFileIo{}
+		path, _, err := mockFileIo.TempFile("/scratch", "config")
+		assert.NoError(t, err)
+		assert.Regexp(t, `^/scratch/config\d+$`, path)
+	})
+
+	t.Run("Mock Result", func(t *testing.T) {
+		mockFileIo := NewMockFileIo()
+		mockFileIo.On(MockOperation{
+			Method:      "TempFile",
+			ReturnValue: "/tmp/file.txt",
+		})
+
+		path, cleanup, err := mockFileIo.TempFile("", "file")
+		assert.NoError(t, err)
+		assert.NoError(t, cleanup())
+		assert.Equal(t, "/tmp/file.txt", path)
+	})
+}
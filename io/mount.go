@@ -0,0 +1,579 @@
+package io
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cjlapao/common-go-helpers/io/paths"
+)
+
+// MountFileIo routes calls to the FileIo mounted on the longest prefix of
+// the path, like a mount table. Paths are slash separated and absolute, each
+// backend sees the path relative to its mount point with a leading slash, so
+// disk directories are usually mounted through a RootedFileIo.
+//
+// Directories holding mount points are listed by ReadDir even when no
+// backend has them, and copies or moves between mounts stream the content
+// from one backend to the other.
+type MountFileIo struct {
+	mu     sync.RWMutex
+	mounts map[string]FileIo
+}
+
+// mountPointEntry is the directory entry shown for mount points and their
+// parents
+type mountPointEntry struct {
+	name string
+}
+
+func NewMountFileIo() *MountFileIo {
+	return &MountFileIo{
+		mounts: map[string]FileIo{},
+	}
+}
+
+// Mount serves everything below mountPoint from fileIo, it fails with
+// fs.ErrExist when something is already mounted there
+func (f *MountFileIo) Mount(mountPoint string, fileIo FileIo) error {
+	key := slashPath(mountPoint)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.mounts[key]; ok {
+		return NewFileIoError(BackendMount, "Mount", mountPoint, fs.ErrExist)
+	}
+
+	f.mounts[key] = fileIo
+	return nil
+}
+
+// Unmount removes the FileIo mounted on mountPoint
+func (f *MountFileIo) Unmount(mountPoint string) error {
+	key := slashPath(mountPoint)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.mounts[key]; !ok {
+		return NewFileIoError(BackendMount, "Unmount", mountPoint, fs.ErrNotExist)
+	}
+
+	delete(f.mounts, key)
+	return nil
+}
+
+// MountPoints returns the mount points, sorted
+func (f *MountFileIo) MountPoints() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	mountPoints := make([]string, 0, len(f.mounts))
+	for mountPoint := range f.mounts {
+		mountPoints = append(mountPoints, mountPoint)
+	}
+	sort.Strings(mountPoints)
+
+	return mountPoints
+}
+
+// Resolve returns the FileIo serving path and the path to give it
+func (f *MountFileIo) Resolve(path string) (FileIo, string, error) {
+	_, backend, inner, err := f.route("Resolve", path)
+	return backend, inner, err
+}
+
+func (f *MountFileIo) GetOperatingSystem() OperatingSystem {
+	return UnknownOs
+}
+
+func (f *MountFileIo) FileExists(path string) bool {
+	if f.isVirtualDir(path) {
+		return true
+	}
+
+	_, backend, inner, err := f.route("FileExists", path)
+	return err == nil && backend.FileExists(inner)
+}
+
+func (f *MountFileIo) DirExists(folderPath string) bool {
+	if f.isVirtualDir(folderPath) {
+		return true
+	}
+
+	_, backend, inner, err := f.route("DirExists", folderPath)
+	return err == nil && backend.DirExists(inner)
+}
+
+func (f *MountFileIo) CreateDir(folderPath string, mode fs.FileMode) error {
+	if f.isVirtualDir(folderPath) {
+		return NewFileIoError(BackendMount, "CreateDir", folderPath, fs.ErrExist)
+	}
+
+	mountPoint, backend, inner, err := f.route("CreateDir", folderPath)
+	if err != nil {
+		return err
+	}
+
+	return mountError(mountPoint, backend.CreateDir(inner, mode))
+}
+
+func (f *MountFileIo) CreateDirAll(folderPath string, mode fs.FileMode, parentMode ...fs.FileMode) error {
+	if f.isVirtualDir(folderPath) {
+		return nil
+	}
+
+	mountPoint, backend, inner, err := f.route("CreateDirAll", folderPath)
+	if err != nil {
+		return err
+	}
+
+	return mountError(mountPoint, backend.CreateDirAll(inner, mode, parentMode...))
+}
+
+func (f *MountFileIo) EnsureDir(folderPath string, mode fs.FileMode) error {
+	if f.isVirtualDir(folderPath) {
+		return nil
+	}
+
+	mountPoint, backend, inner, err := f.route("EnsureDir", folderPath)
+	if err != nil {
+		return err
+	}
+
+	return mountError(mountPoint, backend.EnsureDir(inner, mode))
+}
+
+func (f *MountFileIo) GetExecutionPath() string {
+	return os.Args[0]
+}
+
+func (f *MountFileIo) ToOsPath(path string) string {
+	return paths.Convert(path, paths.Windows, paths.Posix)
+}
+
+func (f *MountFileIo) GetOsPathSeparator() string {
+	return paths.Posix.Separator()
+}
+
+func (f *MountFileIo) ReadFile(path string) ([]byte, error) {
+	mountPoint, backend, inner, err := f.routeFile("ReadFile", path)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := backend.ReadFile(inner)
+	return content, mountError(mountPoint, err)
+}
+
+func (f *MountFileIo) ReadBufferedFile(path string, from, to int) ([]byte, error) {
+	mountPoint, backend, inner, err := f.routeFile("ReadBufferedFile", path)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := backend.ReadBufferedFile(inner, from, to)
+	return content, mountError(mountPoint, err)
+}
+
+func (f *MountFileIo) WriteFile(path string, data []byte, mode os.FileMode) error {
+	mountPoint, backend, inner, err := f.routeFile("WriteFile", path)
+	if err != nil {
+		return err
+	}
+
+	return mountError(mountPoint, backend.WriteFile(inner, data, mode))
+}
+
+func (f *MountFileIo) WriteBufferedFile(path string, data []byte, bufferSize int, mode os.FileMode) error {
+	mountPoint, backend, inner, err := f.routeFile("WriteBufferedFile", path)
+	if err != nil {
+		return err
+	}
+
+	return mountError(mountPoint, backend.WriteBufferedFile(inner, data, bufferSize, mode))
+}
+
+// ReadDir lists path from its backend and adds the mount points found below
+// it, a mount point hides a backend entry with the same name
+func (f *MountFileIo) ReadDir(path string) ([]fs.DirEntry, error) {
+	merged := map[string]fs.DirEntry{}
+	for _, name := range f.mountedChildren(path) {
+		merged[name] = mountPointEntry{name: name}
+	}
+
+	mountPoint, backend, inner, err := f.route("ReadDir", path)
+	if err == nil {
+		var entries []fs.DirEntry
+		entries, err = backend.ReadDir(inner)
+		for _, entry := range entries {
+			if _, ok := merged[entry.Name()]; !ok {
+				merged[entry.Name()] = entry
+			}
+		}
+	}
+
+	if err != nil && len(merged) == 0 {
+		return nil, mountError(mountPoint, err)
+	}
+
+	result := make([]fs.DirEntry, 0, len(merged))
+	for _, entry := range merged {
+		result = append(result, entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name() < result[j].Name()
+	})
+
+	return result, nil
+}
+
+func (f *MountFileIo) JoinPath(parts ...string) string {
+	return paths.Posix.Join(parts...)
+}
+
+// CopyFile copies within a backend when both paths share a mount and
+// streams the content between backends otherwise
+func (f *MountFileIo) CopyFile(source, destination string) error {
+	sourceMount, sourceBackend, sourceInner, err := f.routeFile("CopyFile", source)
+	if err != nil {
+		return err
+	}
+
+	destinationMount, _, destinationInner, err := f.routeFile("CopyFile", destination)
+	if err != nil {
+		return err
+	}
+
+	if sourceMount == destinationMount {
+		return mountError(sourceMount, sourceBackend.CopyFile(sourceInner, destinationInner))
+	}
+
+	// streaming through the mount table keeps the mount points in errors
+	return copyStream(BackendMount, f, source, f, destination)
+}
+
+func (f *MountFileIo) DeleteFile(path string) error {
+	mountPoint, backend, inner, err := f.routeFile("DeleteFile", path)
+	if err != nil {
+		return err
+	}
+
+	return mountError(mountPoint, backend.DeleteFile(inner))
+}
+
+func (f *MountFileIo) Move(source, destination string, policy ...OverwritePolicy) error {
+	overwrite, err := overwritePolicy(policy)
+	if err != nil {
+		return NewFileIoLinkError(BackendMount, "Move", source, destination, err)
+	}
+
+	if mountPoint, backend, sourceInner, destinationInner, ok := f.sameMount(source, destination); ok {
+		return mountError(mountPoint, backend.Move(sourceInner, destinationInner, overwrite))
+	}
+
+	return moveByCopy(f, BackendMount, source, destination, overwrite)
+}
+
+// CopyDir copies within a backend when the whole tree is served by one mount,
+// otherwise it walks the tree and copies file by file
+func (f *MountFileIo) CopyDir(source, destination string) error {
+	if mountPoint, backend, sourceInner, destinationInner, ok := f.sameMount(source, destination); ok {
+		return mountError(mountPoint, backend.CopyDir(sourceInner, destinationInner))
+	}
+
+	info, err := f.FileInfo(source)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return NewFileIoLinkError(BackendMount, "CopyDir", source, destination, ErrNotDirectory)
+	}
+
+	if err := f.CreateDirAll(destination, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	entries, err := f.ReadDir(source)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		sourcePath := paths.Posix.Join(source, entry.Name())
+		destinationPath := paths.Posix.Join(destination, entry.Name())
+		if entry.IsDir() {
+			err = f.CopyDir(sourcePath, destinationPath)
+		} else {
+			err = f.CopyFile(sourcePath, destinationPath)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteDir refuses to delete mount points or directories holding them
+func (f *MountFileIo) DeleteDir(path string) error {
+	if f.isMountPoint(path) || len(f.mountedChildren(path)) > 0 {
+		return NewFileIoError(BackendMount, "DeleteDir", path, fs.ErrPermission)
+	}
+
+	mountPoint, backend, inner, err := f.route("DeleteDir", path)
+	if err != nil {
+		return err
+	}
+
+	return mountError(mountPoint, backend.DeleteDir(inner))
+}
+
+func (f *MountFileIo) Checksum(path string, method ChecksumMethod) (string, error) {
+	mountPoint, backend, inner, err := f.routeFile("Checksum", path)
+	if err != nil {
+		return "", err
+	}
+
+	checksum, err := backend.Checksum(inner, method)
+	return checksum, mountError(mountPoint, err)
+}
+
+func (f *MountFileIo) FileInfo(path string) (os.FileInfo, error) {
+	if f.isVirtualDir(path) {
+		return mountPointEntry{name: paths.Posix.Base(slashPath(path))}, nil
+	}
+
+	mountPoint, backend, inner, err := f.route("FileInfo", path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := backend.FileInfo(inner)
+	return info, mountError(mountPoint, err)
+}
+
+// TempDir creates the directory in dir, or in /tmp when dir is empty
+func (f *MountFileIo) TempDir(dir, pattern string) (string, CleanupFunc, error) {
+	if dir == "" {
+		dir = "/tmp"
+	}
+
+	mountPoint, backend, inner, err := f.route("TempDir", dir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	path, cleanup, err := backend.TempDir(inner, pattern)
+	if err != nil {
+		return "", nil, mountError(mountPoint, err)
+	}
+
+	return paths.Posix.Join(mountPoint, path), cleanup, nil
+}
+
+// TempFile creates the file in dir, or in /tmp when dir is empty
+func (f *MountFileIo) TempFile(dir, pattern string) (string, CleanupFunc, error) {
+	if dir == "" {
+		dir = "/tmp"
+	}
+
+	mountPoint, backend, inner, err := f.route("TempFile", dir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	path, cleanup, err := backend.TempFile(inner, pattern)
+	if err != nil {
+		return "", nil, mountError(mountPoint, err)
+	}
+
+	return paths.Posix.Join(mountPoint, path), cleanup, nil
+}
+
+func (f *MountFileIo) OpenFile(path string) (io.ReadCloser, error) {
+	mountPoint, backend, inner, err := f.routeFile("OpenFile", path)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := OpenReader(backend, inner)
+	return reader, mountError(mountPoint, err)
+}
+
+func (f *MountFileIo) CreateFile(path string, mode fs.FileMode) (io.WriteCloser, error) {
+	mountPoint, backend, inner, err := f.routeFile("CreateFile", path)
+	if err != nil {
+		return nil, err
+	}
+
+	writer, err := CreateWriter(backend, inner, mode)
+	if err != nil {
+		return nil, mountError(mountPoint, err)
+	}
+
+	return &mountWriter{WriteCloser: writer, mountPoint: mountPoint}, nil
+}
+
+// route finds the mount with the longest prefix of path
+func (f *MountFileIo) route(op, path string) (string, FileIo, string, error) {
+	key := slashPath(path)
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	best := ""
+	for mountPoint := range f.mounts {
+		if len(mountPoint) > len(best) && isMountedOn(mountPoint, key) {
+			best = mountPoint
+		}
+	}
+
+	backend, ok := f.mounts[best]
+	if !ok {
+		return "", nil, "", NewFileIoError(BackendMount, op, path, fs.ErrNotExist)
+	}
+
+	return best, backend, slashPath(strings.TrimPrefix(key, best)), nil
+}
+
+// routeFile is route for operations on files, failing on directories only
+// made of mount points
+func (f *MountFileIo) routeFile(op, path string) (string, FileIo, string, error) {
+	if f.isVirtualDir(path) {
+		return "", nil, "", NewFileIoError(BackendMount, op, path, ErrIsDirectory)
+	}
+
+	return f.route(op, path)
+}
+
+// sameMount reports whether source and destination are served by the same
+// backend without being or holding mount points themselves
+func (f *MountFileIo) sameMount(source, destination string) (string, FileIo, string, string, bool) {
+	if f.isVirtualDir(source) || f.isVirtualDir(destination) {
+		return "", nil, "", "", false
+	}
+
+	sourceMount, backend, sourceInner, err := f.route("", source)
+	if err != nil {
+		return "", nil, "", "", false
+	}
+
+	destinationMount, _, destinationInner, err := f.route("", destination)
+	if err != nil || sourceMount != destinationMount {
+		return "", nil, "", "", false
+	}
+
+	return sourceMount, backend, sourceInner, destinationInner, true
+}
+
+func (f *MountFileIo) isMountPoint(path string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	_, ok := f.mounts[slashPath(path)]
+	return ok
+}
+
+// isVirtualDir reports whether path is a mount point or one of their parents
+func (f *MountFileIo) isVirtualDir(path string) bool {
+	return f.isMountPoint(path) || len(f.mountedChildren(path)) > 0
+}
+
+// mountedChildren returns the names of the entries of path leading to mount
+// points below it
+func (f *MountFileIo) mountedChildren(path string) []string {
+	key := slashPath(path)
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	names := []string{}
+	seen := map[string]bool{}
+	for mountPoint := range f.mounts {
+		if mountPoint == key || !isMountedOn(key, mountPoint) {
+			continue
+		}
+
+		rest := strings.TrimPrefix(strings.TrimPrefix(mountPoint, key), "/")
+		name := strings.SplitN(rest, "/", 2)[0]
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// isMountedOn reports whether key is mountPoint or below it
+func isMountedOn(mountPoint, key string) bool {
+	return mountPoint == "/" || key == mountPoint || strings.HasPrefix(key, mountPoint+"/")
+}
+
+// mountError reports the paths of err as seen through the mount table
+func mountError(mountPoint string, err error) error {
+	fileIoErr, ok := err.(*FileIoError)
+	if !ok {
+		return err
+	}
+
+	mountErr := *fileIoErr
+	if mountErr.Path != "" {
+		mountErr.Path = paths.Posix.Join(mountPoint, mountErr.Path)
+	}
+	if mountErr.Destination != "" {
+		mountErr.Destination = paths.Posix.Join(mountPoint, mountErr.Destination)
+	}
+
+	return &mountErr
+}
+
+// mountWriter reports the errors of Close through the mount table, backends
+// that buffer only write the file then
+type mountWriter struct {
+	io.WriteCloser
+	mountPoint string
+}
+
+func (w *mountWriter) Close() error {
+	return mountError(w.mountPoint, w.WriteCloser.Close())
+}
+
+func (e mountPointEntry) Name() string {
+	return e.name
+}
+
+func (e mountPointEntry) IsDir() bool {
+	return true
+}
+
+func (e mountPointEntry) Type() fs.FileMode {
+	return fs.ModeDir
+}
+
+func (e mountPointEntry) Info() (fs.FileInfo, error) {
+	return e, nil
+}
+
+func (e mountPointEntry) Size() int64 {
+	return 0
+}
+
+func (e mountPointEntry) Mode() fs.FileMode {
+	return fs.ModeDir | 0o555
+}
+
+func (e mountPointEntry) ModTime() time.Time {
+	return time.Time{}
+}
+
+func (e mountPointEntry) Sys() interface{} {
+	return nil
+}
@@ -0,0 +1,156 @@
+package io
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newMountFixture(t *testing.T) (*MountFileIo, string, *MemoryFileIo) {
+	t.Helper()
+
+	data := t.TempDir()
+	writeTestTree(t, data, map[string]string{
+		"users/me.json": `{"name":"me"}`,
+	})
+
+	memory := NewMemoryFileIo()
+	mount := NewMountFileIo()
+	assert.NoError(t, mount.Mount("/config", NewFSFileIo(newTestFS())))
+	assert.NoError(t, mount.Mount("/data", NewRootedFileIo(Default(), data)))
+	assert.NoError(t, mount.Mount("/tmp", memory))
+	assert.NoError(t, mount.Mount("/srv/cache/blobs", NewMemoryFileIo()))
+
+	return mount, data, memory
+}
+
+func TestMountFileIo_Routing(t *testing.T) {
+	mount, data, memory := newMountFixture(t)
+
+	content, err := mount.ReadFile("/config/config/app.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "name: app", string(content))
+
+	content, err = mount.ReadFile("/data/users/me.json")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"me"}`, string(content))
+
+	assert.NoError(t, mount.WriteFile("/tmp/scratch.txt", []byte("scratch"), 0o644))
+	assert.True(t, memory.FileExists("/scratch.txt"))
+
+	assert.NoError(t, mount.WriteFile("/data/users/you.json", []byte("{}"), 0o644))
+	assertFileContent(t, filepath.Join(data, "users", "you.json"), "{}")
+
+	backend, inner, err := mount.Resolve("/srv/cache/blobs/a/b")
+	assert.NoError(t, err)
+	assert.Equal(t, "/a/b", inner)
+	assert.IsType(t, &MemoryFileIo{}, backend)
+
+	_, err = mount.ReadFile("/unmounted/file")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	assert.ErrorIs(t, mount.WriteFile("/config/new.yaml", nil, 0o644), ErrReadOnly)
+	assert.ErrorIs(t, mount.Mount("/tmp", NewMemoryFileIo()), fs.ErrExist)
+}
+
+func TestMountFileIo_LongestPrefix(t *testing.T) {
+	outer := NewMemoryFileIo()
+	inner := NewMemoryFileIo()
+	mount := NewMountFileIo()
+	assert.NoError(t, mount.Mount("/", outer))
+	assert.NoError(t, mount.Mount("/data/inner", inner))
+
+	assert.NoError(t, mount.CreateDirAll("/data/other", 0o755))
+	assert.NoError(t, mount.WriteFile("/data/inner-file", []byte("outer"), 0o644))
+	assert.NoError(t, mount.WriteFile("/data/inner/file", []byte("inner"), 0o644))
+
+	assert.True(t, outer.FileExists("/data/inner-file"))
+	assert.True(t, inner.FileExists("/file"))
+	assert.False(t, outer.FileExists("/data/inner/file"))
+
+	assert.NoError(t, mount.Unmount("/data/inner"))
+	assert.False(t, mount.FileExists("/data/inner/file"))
+	assert.Equal(t, []string{"/"}, mount.MountPoints())
+}
+
+func TestMountFileIo_ReadDir(t *testing.T) {
+	mount, _, _ := newMountFixture(t)
+
+	entries, err := mount.ReadDir("/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"config", "data", "srv", "tmp"}, entryNames(entries))
+	assert.True(t, entries[2].IsDir())
+
+	entries, err = mount.ReadDir("/srv/cache")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"blobs"}, entryNames(entries))
+
+	entries, err = mount.ReadDir("/data")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"users"}, entryNames(entries))
+
+	info, err := mount.FileInfo("/srv")
+	assert.NoError(t, err)
+	assert.True(t, info.IsDir())
+	assert.True(t, mount.DirExists("/srv/cache"))
+
+	_, err = mount.ReadDir("/nothing")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	_, err = mount.ReadFile("/srv")
+	assert.ErrorIs(t, err, ErrIsDirectory)
+	assert.ErrorIs(t, mount.DeleteDir("/srv"), fs.ErrPermission)
+	assert.ErrorIs(t, mount.DeleteDir("/tmp"), fs.ErrPermission)
+}
+
+func TestMountFileIo_CrossMount(t *testing.T) {
+	mount, data, memory := newMountFixture(t)
+
+	assert.NoError(t, mount.CopyFile("/config/config/app.yaml", "/tmp/app.yaml"))
+	content, err := memory.ReadFile("/app.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "name: app", string(content))
+
+	assert.NoError(t, mount.CopyDir("/config/config", "/data/config"))
+	assertFileContent(t, filepath.Join(data, "config", "env", "prod.yaml"), "env: prod")
+	info, err := os.Stat(filepath.Join(data, "config", "env", "prod.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	assert.NoError(t, mount.Move("/data/users", "/tmp/users"))
+	assert.NoDirExists(t, filepath.Join(data, "users"))
+	content, err = memory.ReadFile("/users/me.json")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"me"}`, string(content))
+
+	assert.NoError(t, mount.Move("/tmp/app.yaml", "/tmp/moved.yaml"))
+	assert.True(t, memory.FileExists("/moved.yaml"))
+
+	err = mount.Move("/config/config/app.yaml", "/tmp/app.yaml")
+	assert.ErrorIs(t, err, ErrReadOnly)
+
+	var fileIoErr *FileIoError
+	err = mount.CopyFile("/config/missing.yaml", "/tmp/missing.yaml")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.ErrorAs(t, err, &fileIoErr)
+	assert.Equal(t, "/config/missing.yaml", fileIoErr.Path)
+
+	err = mount.CopyFile("/config/config/app.yaml", "/tmp/nothing/app.yaml")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.ErrorAs(t, err, &fileIoErr)
+	assert.Equal(t, "/tmp/nothing/app.yaml", fileIoErr.Path)
+}
+
+func TestMountFileIo_Temp(t *testing.T) {
+	mount, _, memory := newMountFixture(t)
+
+	path, cleanup, err := mount.TempFile("", "upload-*")
+	assert.NoError(t, err)
+	assert.Regexp(t, `^/tmp/upload-\d+$`, path)
+	assert.True(t, mount.FileExists(path))
+	assert.NoError(t, cleanup())
+	assert.False(t, memory.FileExists(path[len("/tmp"):]))
+}
@@ -0,0 +1,319 @@
+package io
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// OverwritePolicy defines what Move does when the destination already exists
+type OverwritePolicy int
+
+const (
+	// OverwriteFail returns an fs.ErrExist error
+	OverwriteFail OverwritePolicy = iota
+	// OverwriteReplace replaces the destination, which is only removed once
+	// the source took its place
+	OverwriteReplace
+	// OverwriteMerge moves the content of a source directory into an existing
+	// destination directory, replacing files with the same name, for anything
+	// else it behaves like OverwriteReplace
+	OverwriteMerge
+)
+
+// overwritePolicy returns the optional policy passed to Move, OverwriteFail
+// when there is none
+func overwritePolicy(policy []OverwritePolicy) (OverwritePolicy, error) {
+	switch len(policy) {
+	case 0:
+		return OverwriteFail, nil
+	case 1:
+		return policy[0], nil
+	default:
+		return OverwriteFail, ErrTooManyPolicies
+	}
+}
+
+// osRename is swapped in tests to simulate moves across devices
+var osRename = os.Rename
+
+func (f DefaultFileIo) Move(source, destination string, policy ...OverwritePolicy) error {
+	overwrite, err := overwritePolicy(policy)
+	if err == nil {
+		err = f.moveWithPolicy(source, destination, overwrite)
+	}
+
+	return NewFileIoLinkError(BackendDefault, "Move", source, destination, err)
+}
+
+func (f DefaultFileIo) moveWithPolicy(source, destination string, overwrite OverwritePolicy) error {
+	sourceInfo, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+
+	destinationInfo, err := os.Stat(destination)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return f.move(source, destination, sourceInfo)
+	}
+
+	if os.SameFile(sourceInfo, destinationInfo) {
+		return nil
+	}
+
+	switch overwrite {
+	case OverwriteReplace:
+	case OverwriteMerge:
+		if sourceInfo.IsDir() && destinationInfo.IsDir() {
+			return f.mergeDir(source, destination)
+		}
+	default:
+		return os.ErrExist
+	}
+
+	if !sourceInfo.IsDir() && !destinationInfo.IsDir() {
+		return f.replaceFile(source, destination)
+	}
+
+	return f.replaceBySwap(source, destination, sourceInfo)
+}
+
+// replaceFile renames source over the destination file, which replaces it
+// atomically. Across devices the copy is staged next to the destination and
+// renamed over it, so the destination is never missing.
+func (f DefaultFileIo) replaceFile(source, destination string) error {
+	err := osRename(source, destination)
+	if err == nil || !isCrossDeviceError(err) {
+		return err
+	}
+
+	staged, err := siblingPath(destination, "move")
+	if err != nil {
+		return err
+	}
+
+	if err := f.copyFileVerified(source, staged); err != nil {
+		_ = os.Remove(staged)
+		return err
+	}
+
+	if err := osRename(staged, destination); err != nil {
+		_ = os.Remove(staged)
+		return err
+	}
+
+	return os.Remove(source)
+}
+
+// replaceBySwap moves source next to the destination, then swaps it with the
+// destination, which is only removed once the swap succeeded
+func (f DefaultFileIo) replaceBySwap(source, destination string, sourceInfo os.FileInfo) error {
+	staged, err := siblingPath(destination, "move")
+	if err != nil {
+		return err
+	}
+
+	if err := f.move(source, staged, sourceInfo); err != nil {
+		return err
+	}
+
+	previous, err := siblingPath(destination, "old")
+	if err == nil {
+		err = osRename(destination, previous)
+	}
+	if err != nil {
+		_ = f.move(staged, source, sourceInfo)
+		return err
+	}
+
+	if err := osRename(staged, destination); err != nil {
+		_ = osRename(previous, destination)
+		_ = f.move(staged, source, sourceInfo)
+		return err
+	}
+
+	return os.RemoveAll(previous)
+}
+
+// siblingPath returns an unused name in the directory of path to stage a
+// replacement in
+func siblingPath(path, purpose string) (string, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(path), "."+filepath.Base(path)+"."+purpose+"-*")
+	if err != nil {
+		return "", err
+	}
+
+	return dir, os.Remove(dir)
+}
+
+// move renames source into a destination that does not exist, falling back
+// to copy, verify and delete when the rename crosses devices
+func (f DefaultFileIo) move(source, destination string, sourceInfo os.FileInfo) error {
+	err := osRename(source, destination)
+	if err == nil || !isCrossDeviceError(err) {
+		return err
+	}
+
+	if sourceInfo.IsDir() {
+		err = f.copyDirVerified(source, destination)
+	} else {
+		err = f.copyFileVerified(source, destination)
+	}
+
+	if err != nil {
+		_ = os.RemoveAll(destination)
+		return err
+	}
+
+	return os.RemoveAll(source)
+}
+
+func (f DefaultFileIo) mergeDir(source, destination string) error {
+	entries, err := os.ReadDir(source)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		sourcePath := filepath.Join(source, entry.Name())
+		destinationPath := filepath.Join(destination, entry.Name())
+		if err := f.moveWithPolicy(sourcePath, destinationPath, OverwriteMerge); err != nil {
+			return err
+		}
+	}
+
+	return os.Remove(source)
+}
+
+func (f DefaultFileIo) copyFileVerified(source, destination string) error {
+	if err := f.CopyFile(source, destination); err != nil {
+		return err
+	}
+
+	sourceChecksum, err := f.Checksum(source, ChecksumSHA256)
+	if err != nil {
+		return err
+	}
+
+	destinationChecksum, err := f.Checksum(destination, ChecksumSHA256)
+	if err != nil {
+		return err
+	}
+
+	if sourceChecksum != destinationChecksum {
+		return NewFileIoLinkError(BackendDefault, "Move", source, destination, ErrVerificationFailed)
+	}
+
+	return nil
+}
+
+func (f DefaultFileIo) copyDirVerified(source, destination string) error {
+	sourceInfo, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(destination, sourceInfo.Mode()); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(source)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		sourcePath := filepath.Join(source, entry.Name())
+		destinationPath := filepath.Join(destination, entry.Name())
+		if entry.IsDir() {
+			err = f.copyDirVerified(sourcePath, destinationPath)
+		} else {
+			err = f.copyFileVerified(sourcePath, destinationPath)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// moveByCopy moves source to destination by copying it through fileIo and
+// deleting the source, for backends that have no rename of their own
+func moveByCopy(fileIo FileIo, backend, source, destination string, overwrite OverwritePolicy) error {
+	flavour := fileIo.GetOperatingSystem().PathFlavour()
+	if flavour.Clean(source) == flavour.Clean(destination) {
+		return nil
+	}
+
+	sourceInfo, err := fileIo.FileInfo(source)
+	if err != nil {
+		return err
+	}
+
+	if destinationInfo, err := fileIo.FileInfo(destination); err == nil {
+		switch {
+		case overwrite == OverwriteMerge && sourceInfo.IsDir() && destinationInfo.IsDir():
+			return mergeDirByCopy(fileIo, backend, source, destination)
+		case overwrite == OverwriteFail:
+			return NewFileIoLinkError(backend, "Move", source, destination, fs.ErrExist)
+		}
+
+		if err := deletePath(fileIo, destination); err != nil {
+			return err
+		}
+	}
+
+	if sourceInfo.IsDir() {
+		if err := fileIo.CopyDir(source, destination); err != nil {
+			return err
+		}
+		return fileIo.DeleteDir(source)
+	}
+
+	if err := fileIo.CopyFile(source, destination); err != nil {
+		return err
+	}
+
+	return fileIo.DeleteFile(source)
+}
+
+func mergeDirByCopy(fileIo FileIo, backend, source, destination string) error {
+	entries, err := fileIo.ReadDir(source)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		sourcePath := fileIo.JoinPath(source, entry.Name())
+		destinationPath := fileIo.JoinPath(destination, entry.Name())
+		if err := moveByCopy(fileIo, backend, sourcePath, destinationPath, OverwriteMerge); err != nil {
+			return err
+		}
+	}
+
+	return fileIo.DeleteDir(source)
+}
+
+// deletePath removes path from fileIo whether it is a file or a directory,
+// a missing path is not an error
+func deletePath(fileIo FileIo, path string) error {
+	info, err := fileIo.FileInfo(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	if info.IsDir() {
+		return fileIo.DeleteDir(path)
+	}
+
+	return fileIo.DeleteFile(path)
+}
@@ -0,0 +1,241 @@
+package io
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func simulateCrossDevice(t *testing.T) {
+	osRename = func(oldpath, newpath string) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+	}
+	t.Cleanup(func() {
+		osRename = os.Rename
+	})
+}
+
+func writeTestTree(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func assertFileContent(t *testing.T, path, expected string) {
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, string(content))
+}
+
+func TestMove(t *testing.T) {
+	t.Run("Move File", func(t *testing.T) {
+		defaultClient := Default()
+		root := t.TempDir()
+		writeTestTree(t, root, map[string]string{"source.txt": "source"})
+
+		err := defaultClient.Move(filepath.Join(root, "source.txt"), filepath.Join(root, "destination.txt"))
+		assert.NoError(t, err)
+		assert.NoFileExists(t, filepath.Join(root, "source.txt"))
+		assertFileContent(t, filepath.Join(root, "destination.txt"), "source")
+	})
+
+	t.Run("Move Non-Existent Source", func(t *testing.T) {
+		defaultClient := Default()
+		root := t.TempDir()
+
+		err := defaultClient.Move(filepath.Join(root, "missing"), filepath.Join(root, "destination"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("Fail When Destination Exists", func(t *testing.T) {
+		defaultClient := Default()
+		root := t.TempDir()
+		writeTestTree(t, root, map[string]string{"source.txt": "source", "destination.txt": "destination"})
+
+		err := defaultClient.Move(filepath.Join(root, "source.txt"), filepath.Join(root, "destination.txt"))
+		assert.ErrorIs(t, err, os.ErrExist)
+		assertFileContent(t, filepath.Join(root, "destination.txt"), "destination")
+		assert.FileExists(t, filepath.Join(root, "source.txt"))
+	})
+
+	t.Run("Too Many Policies", func(t *testing.T) {
+		defaultClient := Default()
+		root := t.TempDir()
+		writeTestTree(t, root, map[string]string{"source.txt": "source", "destination.txt": "destination"})
+
+		err := defaultClient.Move(filepath.Join(root, "source.txt"), filepath.Join(root, "destination.txt"), OverwriteFail, OverwriteReplace)
+		assert.ErrorIs(t, err, ErrTooManyPolicies)
+		assertFileContent(t, filepath.Join(root, "destination.txt"), "destination")
+		assert.FileExists(t, filepath.Join(root, "source.txt"))
+	})
+
+	t.Run("Replace Destination Directory", func(t *testing.T) {
+		defaultClient := Default()
+		root := t.TempDir()
+		writeTestTree(t, root, map[string]string{
+			"source/a.txt":      "a",
+			"destination/b.txt": "b",
+		})
+
+		err := defaultClient.Move(filepath.Join(root, "source"), filepath.Join(root, "destination"), OverwriteReplace)
+		assert.NoError(t, err)
+		assertFileContent(t, filepath.Join(root, "destination", "a.txt"), "a")
+		assert.NoFileExists(t, filepath.Join(root, "destination", "b.txt"))
+		assert.NoDirExists(t, filepath.Join(root, "source"))
+	})
+
+	t.Run("Merge Directories", func(t *testing.T) {
+		defaultClient := Default()
+		root := t.TempDir()
+		writeTestTree(t, root, map[string]string{
+			"source/a.txt":          "new a",
+			"source/sub/c.txt":      "c",
+			"destination/a.txt":     "old a",
+			"destination/b.txt":     "b",
+			"destination/sub/d.txt": "d",
+		})
+
+		err := defaultClient.Move(filepath.Join(root, "source"), filepath.Join(root, "destination"), OverwriteMerge)
+		assert.NoError(t, err)
+		assertFileContent(t, filepath.Join(root, "destination", "a.txt"), "new a")
+		assertFileContent(t, filepath.Join(root, "destination", "b.txt"), "b")
+		assertFileContent(t, filepath.Join(root, "destination", "sub", "c.txt"), "c")
+		assertFileContent(t, filepath.Join(root, "destination", "sub", "d.txt"), "d")
+		assert.NoDirExists(t, filepath.Join(root, "source"))
+	})
+
+	t.Run("Merge File Replaces", func(t *testing.T) {
+		defaultClient := Default()
+		root := t.TempDir()
+		writeTestTree(t, root, map[string]string{"source.txt": "source", "destination.txt": "destination"})
+
+		err := defaultClient.Move(filepath.Join(root, "source.txt"), filepath.Join(root, "destination.txt"), OverwriteMerge)
+		assert.NoError(t, err)
+		assertFileContent(t, filepath.Join(root, "destination.txt"), "source")
+	})
+
+	t.Run("Cross Device File", func(t *testing.T) {
+		simulateCrossDevice(t)
+		defaultClient := Default()
+		root := t.TempDir()
+		writeTestTree(t, root, map[string]string{"source.txt": "source"})
+
+		err := defaultClient.Move(filepath.Join(root, "source.txt"), filepath.Join(root, "destination.txt"))
+		assert.NoError(t, err)
+		assert.NoFileExists(t, filepath.Join(root, "source.txt"))
+		assertFileContent(t, filepath.Join(root, "destination.txt"), "source")
+	})
+
+	t.Run("Cross Device Directory", func(t *testing.T) {
+		simulateCrossDevice(t)
+		defaultClient := Default()
+		root := t.TempDir()
+		writeTestTree(t, root, map[string]string{
+			"source/a.txt":     "a",
+			"source/sub/b.txt": "b",
+		})
+
+		err := defaultClient.Move(filepath.Join(root, "source"), filepath.Join(root, "destination"))
+		assert.NoError(t, err)
+		assert.NoDirExists(t, filepath.Join(root, "source"))
+		assertFileContent(t, filepath.Join(root, "destination", "a.txt"), "a")
+		assertFileContent(t, filepath.Join(root, "destination", "sub", "b.txt"), "b")
+	})
+
+	t.Run("Other Rename Errors Are Returned", func(t *testing.T) {
+		osRename = func(oldpath, newpath string) error {
+			return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrPermission}
+		}
+		t.Cleanup(func() {
+			osRename = os.Rename
+		})
+		defaultClient := Default()
+		root := t.TempDir()
+		writeTestTree(t, root, map[string]string{"source.txt": "source"})
+
+		err := defaultClient.Move(filepath.Join(root, "source.txt"), filepath.Join(root, "destination.txt"))
+		assert.ErrorIs(t, err, os.ErrPermission)
+		assert.FileExists(t, filepath.Join(root, "source.txt"))
+	})
+
+	t.Run("Cross Device Replace", func(t *testing.T) {
+		root := t.TempDir()
+		source := filepath.Join(root, "source.txt")
+		osRename = func(oldpath, newpath string) error {
+			if oldpath == source {
+				return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+			}
+			return os.Rename(oldpath, newpath)
+		}
+		t.Cleanup(func() {
+			osRename = os.Rename
+		})
+		writeTestTree(t, root, map[string]string{"source.txt": "source", "destination.txt": "destination"})
+
+		assert.NoError(t, Default().Move(source, filepath.Join(root, "destination.txt"), OverwriteReplace))
+		assertFileContent(t, filepath.Join(root, "destination.txt"), "source")
+		entries, err := os.ReadDir(root)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"destination.txt"}, entryNames(entries))
+	})
+
+	t.Run("Failed Replace Keeps Destination", func(t *testing.T) {
+		osRename = func(oldpath, newpath string) error {
+			return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrPermission}
+		}
+		t.Cleanup(func() {
+			osRename = os.Rename
+		})
+		defaultClient := Default()
+		root := t.TempDir()
+		writeTestTree(t, root, map[string]string{
+			"source.txt":           "source",
+			"destination.txt":      "destination",
+			"source/file.txt":      "source",
+			"destination/file.txt": "destination",
+		})
+
+		err := defaultClient.Move(filepath.Join(root, "source.txt"), filepath.Join(root, "destination.txt"), OverwriteReplace)
+		assert.ErrorIs(t, err, os.ErrPermission)
+		err = defaultClient.Move(filepath.Join(root, "source"), filepath.Join(root, "destination"), OverwriteReplace)
+		assert.ErrorIs(t, err, os.ErrPermission)
+
+		assertFileContent(t, filepath.Join(root, "destination.txt"), "destination")
+		assertFileContent(t, filepath.Join(root, "destination", "file.txt"), "destination")
+		assertFileContent(t, filepath.Join(root, "source.txt"), "source")
+		assertFileContent(t, filepath.Join(root, "source", "file.txt"), "source")
+		entries, err := os.ReadDir(root)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 4)
+	})
+
+	t.Run("Failed Cross Device Replace Keeps Destination", func(t *testing.T) {
+		simulateCrossDevice(t)
+		defaultClient := Default()
+		root := t.TempDir()
+		writeTestTree(t, root, map[string]string{
+			"source.txt":      "source",
+			"destination.txt": "destination",
+			"source/file.txt": "source",
+			"destination.dir": "destination",
+		})
+
+		err := defaultClient.Move(filepath.Join(root, "source.txt"), filepath.Join(root, "destination.txt"), OverwriteReplace)
+		assert.ErrorIs(t, err, syscall.EXDEV)
+		err = defaultClient.Move(filepath.Join(root, "source"), filepath.Join(root, "destination.dir"), OverwriteReplace)
+		assert.ErrorIs(t, err, syscall.EXDEV)
+
+		assertFileContent(t, filepath.Join(root, "destination.txt"), "destination")
+		assertFileContent(t, filepath.Join(root, "destination.dir"), "destination")
+		assertFileContent(t, filepath.Join(root, "source.txt"), "source")
+		assertFileContent(t, filepath.Join(root, "source", "file.txt"), "source")
+		entries, err := os.ReadDir(root)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 4)
+	})
+}
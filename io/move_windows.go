@@ -0,0 +1,13 @@
+package io
+
+import (
+	"errors"
+	"syscall"
+)
+
+// errorNotSameDevice is ERROR_NOT_SAME_DEVICE returned by MoveFileEx
+const errorNotSameDevice = syscall.Errno(17)
+
+func isCrossDeviceError(err error) bool {
+	return errors.Is(err, errorNotSameDevice) || errors.Is(err, syscall.EXDEV)
+}
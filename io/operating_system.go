@@ -1,9 +1,10 @@
 package io
 
 import (
-	"os"
 	"runtime"
 	"strings"
+
+	"github.com/cjlapao/common-go-helpers/io/paths"
 )
 
 // OperatingSystem enum
@@ -15,8 +16,48 @@ const (
 	WindowsOs
 	LinuxOs
 	MacOs
+	FreeBSDOs
+	OpenBSDOs
+	NetBSDOs
+	DragonFlyOs
 )
 
+func (o OperatingSystem) String() string {
+	switch o {
+	case WindowsOs:
+		return "windows"
+	case LinuxOs:
+		return "linux"
+	case MacOs:
+		return "darwin"
+	case FreeBSDOs:
+		return "freebsd"
+	case OpenBSDOs:
+		return "openbsd"
+	case NetBSDOs:
+		return "netbsd"
+	case DragonFlyOs:
+		return "dragonfly"
+	default:
+		return "unknown"
+	}
+}
+
+// IsBSD reports whether the operating system is one of the BSD variants
+func (o OperatingSystem) IsBSD() bool {
+	return o == FreeBSDOs || o == OpenBSDOs || o == NetBSDOs || o == DragonFlyOs
+}
+
+// PathFlavour returns the path rules used by the operating system, Posix
+// for anything that is not Windows
+func (o OperatingSystem) PathFlavour() paths.Flavour {
+	if o == WindowsOs {
+		return paths.Windows
+	}
+
+	return paths.Posix
+}
+
 // getOperatingSystem returns the operating system
 /*
 Get the operating system name and return it as an OperatingSystem constant.
@@ -27,20 +68,26 @@ Returns:
 	The operating system.
 */
 func getOperatingSystem() OperatingSystem {
-	envOSOverride := strings.ToLower(os.Getenv("TEST_OS_OVERRIDE"))
-	var os string
-	if envOSOverride != "" {
-		os = envOSOverride
-	} else {
-		os = runtime.GOOS
-	}
-	switch strings.ToLower(os) {
+	return operatingSystemFromName(runtime.GOOS)
+}
+
+// operatingSystemFromName maps a GOOS name to its OperatingSystem
+func operatingSystemFromName(name string) OperatingSystem {
+	switch strings.ToLower(name) {
 	case "linux":
 		return LinuxOs
 	case "windows":
 		return WindowsOs
 	case "darwin":
 		return MacOs
+	case "freebsd":
+		return FreeBSDOs
+	case "openbsd":
+		return OpenBSDOs
+	case "netbsd":
+		return NetBSDOs
+	case "dragonfly":
+		return DragonFlyOs
 	default:
 		return UnknownOs
 	}
@@ -1,25 +1,45 @@
 package io
 
 import (
-	"os"
 	"runtime"
 	"strings"
 	"testing"
 )
 
 func TestGetOperatingSystem(t *testing.T) {
-	t.Run("Override Environment Variable", func(t *testing.T) {
-		os.Setenv("TEST_OS_OVERRIDE", "linux")
+	t.Run("Linux Name", func(t *testing.T) {
 		expectedOS := LinuxOs
-		actualOS := getOperatingSystem()
+		actualOS := operatingSystemFromName("linux")
 
 		if actualOS != expectedOS {
 			t.Errorf("Expected operating system to be %v, but got %v", expectedOS, actualOS)
 		}
 	})
 
+	t.Run("BSD Variants", func(t *testing.T) {
+		expected := map[string]OperatingSystem{
+			"freebsd":   FreeBSDOs,
+			"openbsd":   OpenBSDOs,
+			"netbsd":    NetBSDOs,
+			"dragonfly": DragonFlyOs,
+		}
+
+		for name, expectedOS := range expected {
+			actualOS := operatingSystemFromName(name)
+
+			if actualOS != expectedOS {
+				t.Errorf("Expected operating system to be %v, but got %v", expectedOS, actualOS)
+			}
+			if actualOS.String() != name {
+				t.Errorf("Expected operating system name to be %v, but got %v", name, actualOS.String())
+			}
+			if !actualOS.IsBSD() {
+				t.Errorf("Expected %v to be a BSD variant", actualOS)
+			}
+		}
+	})
+
 	t.Run("Default Operating System", func(t *testing.T) {
-		os.Setenv("TEST_OS_OVERRIDE", "")
 		expectedOS := getExpectedDefaultOperatingSystem()
 		actualOS := getOperatingSystem()
 
@@ -37,7 +57,27 @@ func getExpectedDefaultOperatingSystem() OperatingSystem {
 		return WindowsOs
 	case "darwin":
 		return MacOs
+	case "freebsd":
+		return FreeBSDOs
+	case "openbsd":
+		return OpenBSDOs
+	case "netbsd":
+		return NetBSDOs
+	case "dragonfly":
+		return DragonFlyOs
 	default:
 		return UnknownOs
 	}
 }
+
+func TestOperatingSystem_PathFlavour(t *testing.T) {
+	if WindowsOs.PathFlavour().Name() != "windows" {
+		t.Errorf("Expected windows path flavour for WindowsOs")
+	}
+
+	for _, operatingSystem := range []OperatingSystem{LinuxOs, MacOs, UnknownOs} {
+		if operatingSystem.PathFlavour().Name() != "posix" {
+			t.Errorf("Expected posix path flavour for %v", operatingSystem)
+		}
+	}
+}
@@ -0,0 +1,639 @@
+package io
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/cjlapao/common-go-helpers/io/paths"
+)
+
+// OverlayFileIo layers a writable upper FileIo over a base FileIo that is
+// never modified until Commit. Reads return the upper version of a path when
+// there is one and fall through to the base otherwise, writes always land in
+// the upper layer, copying up the parent directories they need.
+//
+// Deleting something that exists in the base records a whiteout hiding it,
+// and a directory created again over a whiteout becomes opaque so the base
+// content below it stays hidden. A file written over a whiteout replaces the
+// base entry, whatever its type, so Commit deletes that entry first. Both
+// layers must use the same paths, the
+// upper layer is usually a RootedFileIo over a scratch directory.
+type OverlayFileIo struct {
+	base      FileIo
+	upper     FileIo
+	mu        sync.Mutex
+	whiteouts map[string]bool
+	opaque    map[string]bool
+	replaced  map[string]bool
+	written   map[string]bool
+}
+
+// NewOverlayFileIo returns an overlay writing to upper on top of base
+func NewOverlayFileIo(base, upper FileIo) *OverlayFileIo {
+	return &OverlayFileIo{
+		base:      base,
+		upper:     upper,
+		whiteouts: map[string]bool{},
+		opaque:    map[string]bool{},
+		replaced:  map[string]bool{},
+		written:   map[string]bool{},
+	}
+}
+
+// Whiteouts returns the base paths hidden by deletions, sorted
+func (f *OverlayFileIo) Whiteouts() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return sortedKeys(f.whiteouts)
+}
+
+// Commit applies the upper layer onto the base: whiteouts and replaced
+// entries are deleted from the base, opaque directories are emptied and
+// every path written to the upper layer is copied over. The upper layer is discarded afterwards.
+func (f *OverlayFileIo) Commit() error {
+	f.mu.Lock()
+	whiteouts := sortedKeys(f.whiteouts)
+	opaque := sortedKeys(f.opaque)
+	replaced := sortedKeys(f.replaced)
+	written := sortedKeys(f.written)
+	f.mu.Unlock()
+
+	for _, path := range append(append(whiteouts, opaque...), replaced...) {
+		if err := deletePath(f.base, path); err != nil {
+			return commitError(path, err)
+		}
+	}
+
+	// parents sort before their children so directories exist when needed
+	for _, path := range written {
+		info, err := f.upper.FileInfo(path)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return commitError(path, err)
+		}
+
+		if info.IsDir() {
+			err = f.base.CreateDirAll(path, info.Mode().Perm())
+		} else {
+			var content []byte
+			content, err = f.upper.ReadFile(path)
+			if err == nil {
+				err = f.base.WriteFile(path, content, info.Mode().Perm())
+			}
+		}
+
+		if err != nil {
+			return commitError(path, err)
+		}
+	}
+
+	return f.Discard()
+}
+
+// commitError reports a failed Commit as an overlay error, keeping the
+// error of the layer that failed as its cause
+func commitError(path string, err error) error {
+	return &FileIoError{Op: "Commit", Path: path, Backend: BackendOverlay, Err: err}
+}
+
+// Discard removes everything written to the upper layer and forgets the
+// whiteouts, leaving the overlay showing the base as is
+func (f *OverlayFileIo) Discard() error {
+	f.mu.Lock()
+	written := sortedKeys(f.written)
+	f.whiteouts = map[string]bool{}
+	f.opaque = map[string]bool{}
+	f.replaced = map[string]bool{}
+	f.written = map[string]bool{}
+	f.mu.Unlock()
+
+	// children first so their directories are empty when deleted
+	for i := len(written) - 1; i >= 0; i-- {
+		if err := deletePath(f.upper, written[i]); err != nil {
+			return NewFileIoError(BackendOverlay, "Discard", written[i], err)
+		}
+	}
+
+	return nil
+}
+
+func (f *OverlayFileIo) GetOperatingSystem() OperatingSystem {
+	return f.base.GetOperatingSystem()
+}
+
+func (f *OverlayFileIo) FileExists(path string) bool {
+	_, _, err := f.stat("FileExists", path)
+	return err == nil
+}
+
+func (f *OverlayFileIo) DirExists(folderPath string) bool {
+	_, _, err := f.stat("DirExists", folderPath)
+	return err == nil
+}
+
+func (f *OverlayFileIo) CreateDir(folderPath string, mode fs.FileMode) error {
+	if _, _, err := f.stat("CreateDir", folderPath); err == nil {
+		return NewFileIoError(BackendOverlay, "CreateDir", folderPath, fs.ErrExist)
+	}
+
+	if err := f.copyUpParent("CreateDir", folderPath); err != nil {
+		return err
+	}
+
+	if err := f.upper.CreateDir(folderPath, mode); err != nil {
+		return err
+	}
+
+	f.markWritten(folderPath, true)
+	return nil
+}
+
+func (f *OverlayFileIo) CreateDirAll(folderPath string, mode fs.FileMode, parentMode ...fs.FileMode) error {
+	parent := mode
+	if len(parentMode) == 1 {
+		parent = parentMode[0]
+	}
+
+	flavour := f.flavour()
+	missing := []string{}
+	current := flavour.Clean(folderPath)
+	for {
+		info, _, err := f.stat("CreateDirAll", current)
+		if err == nil {
+			if !info.IsDir() {
+				return NewFileIoError(BackendOverlay, "CreateDirAll", current, ErrNotDirectory)
+			}
+			break
+		}
+
+		missing = append(missing, current)
+		next := flavour.Dir(current)
+		if next == current {
+			break
+		}
+		current = next
+	}
+
+	for i := len(missing) - 1; i >= 0; i-- {
+		dirMode := parent
+		if i == 0 {
+			dirMode = mode
+		}
+
+		if err := f.CreateDir(missing[i], dirMode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *OverlayFileIo) EnsureDir(folderPath string, mode fs.FileMode) error {
+	info, _, err := f.stat("EnsureDir", folderPath)
+	if err != nil {
+		return f.CreateDirAll(folderPath, mode)
+	}
+
+	if !info.IsDir() {
+		return NewFileIoError(BackendOverlay, "EnsureDir", folderPath, ErrNotDirectory)
+	}
+
+	if info.Mode().Perm()&mode.Perm() != mode.Perm() {
+		return NewFileIoError(BackendOverlay, "EnsureDir", folderPath, ErrIncompatibleMode)
+	}
+
+	return nil
+}
+
+func (f *OverlayFileIo) GetExecutionPath() string {
+	return f.base.GetExecutionPath()
+}
+
+func (f *OverlayFileIo) ToOsPath(path string) string {
+	return f.base.ToOsPath(path)
+}
+
+func (f *OverlayFileIo) GetOsPathSeparator() string {
+	return f.base.GetOsPathSeparator()
+}
+
+func (f *OverlayFileIo) ReadFile(path string) ([]byte, error) {
+	_, layer, err := f.stat("ReadFile", path)
+	if err != nil {
+		return nil, err
+	}
+
+	return layer.ReadFile(path)
+}
+
+func (f *OverlayFileIo) ReadBufferedFile(path string, from, to int) ([]byte, error) {
+	_, layer, err := f.stat("ReadBufferedFile", path)
+	if err != nil {
+		return nil, err
+	}
+
+	return layer.ReadBufferedFile(path, from, to)
+}
+
+func (f *OverlayFileIo) WriteFile(path string, data []byte, mode os.FileMode) error {
+	if err := f.prepareWrite("WriteFile", path); err != nil {
+		return err
+	}
+
+	if err := f.upper.WriteFile(path, data, mode); err != nil {
+		return err
+	}
+
+	f.markWritten(path, false)
+	return nil
+}
+
+func (f *OverlayFileIo) WriteBufferedFile(path string, data []byte, bufferSize int, mode os.FileMode) error {
+	if err := f.prepareWrite("WriteBufferedFile", path); err != nil {
+		return err
+	}
+
+	if err := f.upper.WriteBufferedFile(path, data, bufferSize, mode); err != nil {
+		return err
+	}
+
+	f.markWritten(path, false)
+	return nil
+}
+
+// ReadDir merges the entries of both layers, upper entries replace base
+// entries with the same name and whiteouts are left out
+func (f *OverlayFileIo) ReadDir(path string) ([]fs.DirEntry, error) {
+	info, _, err := f.stat("ReadDir", path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, NewFileIoError(BackendOverlay, "ReadDir", path, ErrNotDirectory)
+	}
+
+	merged := map[string]fs.DirEntry{}
+	if f.upperHas(path) {
+		entries, err := f.upper.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			merged[entry.Name()] = entry
+		}
+	}
+
+	if !f.hiddenInBase(path) && !f.isOpaque(path) && f.base.DirExists(path) {
+		entries, err := f.base.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+
+		flavour := f.flavour()
+		for _, entry := range entries {
+			if _, ok := merged[entry.Name()]; ok {
+				continue
+			}
+			if f.hiddenInBase(flavour.Join(path, entry.Name())) {
+				continue
+			}
+			merged[entry.Name()] = entry
+		}
+	}
+
+	result := make([]fs.DirEntry, 0, len(merged))
+	for _, entry := range merged {
+		result = append(result, entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name() < result[j].Name()
+	})
+
+	return result, nil
+}
+
+func (f *OverlayFileIo) JoinPath(parts ...string) string {
+	return f.base.JoinPath(parts...)
+}
+
+func (f *OverlayFileIo) CopyFile(source, destination string) error {
+	info, layer, err := f.stat("CopyFile", source)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return NewFileIoLinkError(BackendOverlay, "CopyFile", source, destination, ErrIsDirectory)
+	}
+
+	content, err := layer.ReadFile(source)
+	if err != nil {
+		return err
+	}
+
+	return f.WriteFile(destination, content, info.Mode().Perm())
+}
+
+func (f *OverlayFileIo) DeleteFile(path string) error {
+	info, _, err := f.stat("DeleteFile", path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return NewFileIoError(BackendOverlay, "DeleteFile", path, ErrIsDirectory)
+	}
+
+	return f.delete(path, f.upper.DeleteFile)
+}
+
+func (f *OverlayFileIo) Move(source, destination string, policy ...OverwritePolicy) error {
+	overwrite, err := overwritePolicy(policy)
+	if err != nil {
+		return NewFileIoLinkError(BackendOverlay, "Move", source, destination, err)
+	}
+
+	return moveByCopy(f, BackendOverlay, source, destination, overwrite)
+}
+
+func (f *OverlayFileIo) CopyDir(source, destination string) error {
+	info, _, err := f.stat("CopyDir", source)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return NewFileIoLinkError(BackendOverlay, "CopyDir", source, destination, ErrNotDirectory)
+	}
+
+	if err := f.CreateDirAll(destination, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	entries, err := f.ReadDir(source)
+	if err != nil {
+		return err
+	}
+
+	flavour := f.flavour()
+	for _, entry := range entries {
+		sourcePath := flavour.Join(source, entry.Name())
+		destinationPath := flavour.Join(destination, entry.Name())
+		if entry.IsDir() {
+			err = f.CopyDir(sourcePath, destinationPath)
+		} else {
+			err = f.CopyFile(sourcePath, destinationPath)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *OverlayFileIo) DeleteDir(path string) error {
+	info, _, err := f.stat("DeleteDir", path)
+	if err != nil {
+		// like os.RemoveAll deleting a missing directory is not an error
+		return nil
+	}
+	if !info.IsDir() {
+		return NewFileIoError(BackendOverlay, "DeleteDir", path, ErrNotDirectory)
+	}
+
+	return f.delete(path, f.upper.DeleteDir)
+}
+
+func (f *OverlayFileIo) Checksum(path string, method ChecksumMethod) (string, error) {
+	_, layer, err := f.stat("Checksum", path)
+	if err != nil {
+		return "", err
+	}
+
+	return layer.Checksum(path, method)
+}
+
+func (f *OverlayFileIo) FileInfo(path string) (os.FileInfo, error) {
+	info, _, err := f.stat("FileInfo", path)
+	return info, err
+}
+
+// TempDir creates the directory in the upper layer, it is removed by
+// Discard and copied to the base by Commit unless cleaned up before
+func (f *OverlayFileIo) TempDir(dir, pattern string) (string, CleanupFunc, error) {
+	return f.temp("TempDir", dir, pattern, f.upper.TempDir)
+}
+
+// TempFile creates the file in the upper layer, it is removed by Discard and
+// copied to the base by Commit unless cleaned up before
+func (f *OverlayFileIo) TempFile(dir, pattern string) (string, CleanupFunc, error) {
+	return f.temp("TempFile", dir, pattern, f.upper.TempFile)
+}
+
+func (f *OverlayFileIo) temp(op, dir, pattern string, create func(dir, pattern string) (string, CleanupFunc, error)) (string, CleanupFunc, error) {
+	if dir != "" {
+		if err := f.copyUp(op, dir); err != nil {
+			return "", nil, err
+		}
+	}
+
+	path, cleanup, err := create(dir, pattern)
+	if err != nil {
+		return "", nil, err
+	}
+
+	f.markWritten(path, false)
+	return path, func() error {
+		f.forget(path, false)
+		return cleanup()
+	}, nil
+}
+
+func (f *OverlayFileIo) flavour() paths.Flavour {
+	return f.base.GetOperatingSystem().PathFlavour()
+}
+
+func (f *OverlayFileIo) key(path string) string {
+	return f.flavour().Clean(path)
+}
+
+// stat finds which layer serves path
+func (f *OverlayFileIo) stat(op, path string) (os.FileInfo, FileIo, error) {
+	if info, err := f.upper.FileInfo(path); err == nil {
+		return info, f.upper, nil
+	}
+
+	if f.hiddenInBase(path) {
+		return nil, nil, NewFileIoError(BackendOverlay, op, path, fs.ErrNotExist)
+	}
+
+	info, err := f.base.FileInfo(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return info, f.base, nil
+}
+
+func (f *OverlayFileIo) upperHas(path string) bool {
+	_, err := f.upper.FileInfo(path)
+	return err == nil
+}
+
+// hiddenInBase reports whether the base version of path is covered by a
+// whiteout on it or one of its parents, or by an opaque or replaced parent
+func (f *OverlayFileIo) hiddenInBase(path string) bool {
+	flavour := f.flavour()
+	current := f.key(path)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.whiteouts[current] || f.replaced[current] {
+		return true
+	}
+
+	for {
+		parent := flavour.Dir(current)
+		if parent == current {
+			return false
+		}
+		if f.whiteouts[parent] || f.opaque[parent] || f.replaced[parent] {
+			return true
+		}
+		current = parent
+	}
+}
+
+func (f *OverlayFileIo) isOpaque(path string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.opaque[f.key(path)]
+}
+
+// coversBase reports whether path hides a base entry deleted before it was
+// written again
+func (f *OverlayFileIo) coversBase(path string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := f.key(path)
+	return f.opaque[key] || f.replaced[key]
+}
+
+// prepareWrite checks path can be written as a file and copies up its
+// parent directory
+func (f *OverlayFileIo) prepareWrite(op, path string) error {
+	if info, _, err := f.stat(op, path); err == nil && info.IsDir() {
+		return NewFileIoError(BackendOverlay, op, path, ErrIsDirectory)
+	}
+
+	return f.copyUpParent(op, path)
+}
+
+func (f *OverlayFileIo) copyUpParent(op, path string) error {
+	flavour := f.flavour()
+	parent := flavour.Dir(flavour.Clean(path))
+	if parent == flavour.Clean(path) {
+		return nil
+	}
+
+	return f.copyUp(op, parent)
+}
+
+// copyUp makes sure the directory dir exists in the upper layer, creating it
+// and its parents with the modes they have in the base
+func (f *OverlayFileIo) copyUp(op, dir string) error {
+	if f.upper.DirExists(dir) {
+		return nil
+	}
+
+	info, _, err := f.stat(op, dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return NewFileIoError(BackendOverlay, op, dir, ErrNotDirectory)
+	}
+
+	if err := f.copyUpParent(op, dir); err != nil {
+		return err
+	}
+
+	if err := f.upper.CreateDir(dir, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	f.markWritten(dir, false)
+	return nil
+}
+
+// markWritten records path as created in the upper layer, a directory
+// created over a whiteout becomes opaque and a file replaces the base entry
+func (f *OverlayFileIo) markWritten(path string, dir bool) {
+	key := f.key(path)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.whiteouts[key] {
+		delete(f.whiteouts, key)
+		if dir {
+			f.opaque[key] = true
+		} else {
+			f.replaced[key] = true
+		}
+	}
+
+	f.written[key] = true
+}
+
+func (f *OverlayFileIo) delete(path string, deleteUpper func(path string) error) error {
+	inBase := !f.hiddenInBase(path) && f.base.FileExists(path)
+	if f.upperHas(path) {
+		if err := deleteUpper(path); err != nil {
+			return err
+		}
+	}
+
+	f.forget(path, inBase || f.coversBase(path))
+	return nil
+}
+
+// forget drops what is known about path and everything below it, adding a
+// whiteout when the base still has it
+func (f *OverlayFileIo) forget(path string, whiteout bool) {
+	flavour := f.flavour()
+	key := f.key(path)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, entries := range []map[string]bool{f.whiteouts, f.opaque, f.replaced, f.written} {
+		for entry := range entries {
+			if isWithin(flavour, key, entry) {
+				delete(entries, entry)
+			}
+		}
+	}
+
+	if whiteout {
+		f.whiteouts[key] = true
+	}
+}
+
+func sortedKeys(values map[string]bool) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,213 @@
+package io
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newOverlayFixture(t *testing.T) (*OverlayFileIo, string, string) {
+	t.Helper()
+
+	base := t.TempDir()
+	scratch := t.TempDir()
+	writeTestTree(t, base, map[string]string{
+		"keep.txt":          "base keep",
+		"change.txt":        "base change",
+		"remove.txt":        "base remove",
+		"dir/nested.txt":    "base nested",
+		"dir/sub/deep.txt":  "base deep",
+		"replace/old.txt":   "base old",
+		"replace/other.txt": "base other",
+	})
+
+	return NewOverlayFileIo(Default(), NewRootedFileIo(Default(), scratch)), base, scratch
+}
+
+func entryNames(entries []fs.DirEntry) []string {
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	return names
+}
+
+func TestOverlayFileIo_ReadsFallThrough(t *testing.T) {
+	overlay, base, _ := newOverlayFixture(t)
+
+	content, err := overlay.ReadFile(filepath.Join(base, "dir", "nested.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "base nested", string(content))
+	assert.True(t, overlay.DirExists(filepath.Join(base, "dir", "sub")))
+
+	_, err = overlay.ReadFile(filepath.Join(base, "missing.txt"))
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestOverlayFileIo_WritesLandInUpper(t *testing.T) {
+	overlay, base, scratch := newOverlayFixture(t)
+
+	assert.NoError(t, overlay.WriteFile(filepath.Join(base, "change.txt"), []byte("upper change"), 0o644))
+	assert.NoError(t, overlay.WriteFile(filepath.Join(base, "dir", "sub", "new.txt"), []byte("upper new"), 0o644))
+
+	content, err := overlay.ReadFile(filepath.Join(base, "change.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "upper change", string(content))
+
+	assertFileContent(t, filepath.Join(base, "change.txt"), "base change")
+	assert.NoFileExists(t, filepath.Join(base, "dir", "sub", "new.txt"))
+	assertFileContent(t, filepath.Join(scratch, base, "dir", "sub", "new.txt"), "upper new")
+
+	err = overlay.WriteFile(filepath.Join(base, "missing", "file.txt"), []byte("x"), 0o644)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	err = overlay.WriteFile(filepath.Join(base, "dir"), []byte("x"), 0o644)
+	assert.ErrorIs(t, err, ErrIsDirectory)
+}
+
+func TestOverlayFileIo_Whiteouts(t *testing.T) {
+	overlay, base, _ := newOverlayFixture(t)
+
+	assert.NoError(t, overlay.DeleteFile(filepath.Join(base, "remove.txt")))
+	assert.NoError(t, overlay.DeleteDir(filepath.Join(base, "replace")))
+	assert.FileExists(t, filepath.Join(base, "remove.txt"))
+	assert.False(t, overlay.FileExists(filepath.Join(base, "remove.txt")))
+	assert.False(t, overlay.FileExists(filepath.Join(base, "replace", "old.txt")))
+	assert.Equal(t, []string{filepath.Join(base, "remove.txt"), filepath.Join(base, "replace")}, overlay.Whiteouts())
+
+	err := overlay.DeleteFile(filepath.Join(base, "remove.txt"))
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	t.Run("Opaque Directory", func(t *testing.T) {
+		assert.NoError(t, overlay.CreateDir(filepath.Join(base, "replace"), 0o755))
+		assert.NoError(t, overlay.WriteFile(filepath.Join(base, "replace", "new.txt"), []byte("new"), 0o644))
+
+		entries, err := overlay.ReadDir(filepath.Join(base, "replace"))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"new.txt"}, entryNames(entries))
+		assert.False(t, overlay.FileExists(filepath.Join(base, "replace", "old.txt")))
+	})
+
+	t.Run("Written Again", func(t *testing.T) {
+		assert.NoError(t, overlay.WriteFile(filepath.Join(base, "remove.txt"), []byte("back"), 0o644))
+		content, err := overlay.ReadFile(filepath.Join(base, "remove.txt"))
+		assert.NoError(t, err)
+		assert.Equal(t, "back", string(content))
+	})
+}
+
+func TestOverlayFileIo_ReadDir(t *testing.T) {
+	overlay, base, _ := newOverlayFixture(t)
+
+	assert.NoError(t, overlay.WriteFile(filepath.Join(base, "added.txt"), []byte("added"), 0o644))
+	assert.NoError(t, overlay.WriteFile(filepath.Join(base, "keep.txt"), []byte("upper keep"), 0o644))
+	assert.NoError(t, overlay.DeleteFile(filepath.Join(base, "remove.txt")))
+	assert.NoError(t, overlay.CreateDirAll(filepath.Join(base, "made", "inner"), 0o755))
+
+	entries, err := overlay.ReadDir(base)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"added.txt", "change.txt", "dir", "keep.txt", "made", "replace"}, entryNames(entries))
+
+	info, err := entries[3].Info()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len("upper keep")), info.Size())
+}
+
+func TestOverlayFileIo_CopyAndMove(t *testing.T) {
+	overlay, base, _ := newOverlayFixture(t)
+
+	assert.NoError(t, overlay.CopyDir(filepath.Join(base, "dir"), filepath.Join(base, "copy")))
+	content, err := overlay.ReadFile(filepath.Join(base, "copy", "sub", "deep.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "base deep", string(content))
+
+	err = overlay.Move(filepath.Join(base, "keep.txt"), filepath.Join(base, "change.txt"))
+	assert.ErrorIs(t, err, fs.ErrExist)
+
+	assert.NoError(t, overlay.Move(filepath.Join(base, "keep.txt"), filepath.Join(base, "change.txt"), OverwriteReplace))
+	assert.False(t, overlay.FileExists(filepath.Join(base, "keep.txt")))
+	content, err = overlay.ReadFile(filepath.Join(base, "change.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "base keep", string(content))
+	assertFileContent(t, filepath.Join(base, "keep.txt"), "base keep")
+}
+
+func TestOverlayFileIo_Commit(t *testing.T) {
+	overlay, base, scratch := newOverlayFixture(t)
+
+	assert.NoError(t, overlay.WriteFile(filepath.Join(base, "change.txt"), []byte("upper change"), 0o600))
+	assert.NoError(t, overlay.WriteFile(filepath.Join(base, "dir", "sub", "new.txt"), []byte("upper new"), 0o644))
+	assert.NoError(t, overlay.DeleteFile(filepath.Join(base, "remove.txt")))
+	assert.NoError(t, overlay.DeleteDir(filepath.Join(base, "replace")))
+	assert.NoError(t, overlay.CreateDir(filepath.Join(base, "replace"), 0o755))
+	assert.NoError(t, overlay.WriteFile(filepath.Join(base, "replace", "new.txt"), []byte("new"), 0o644))
+
+	assert.NoError(t, overlay.Commit())
+
+	assertFileContent(t, filepath.Join(base, "change.txt"), "upper change")
+	assertFileContent(t, filepath.Join(base, "dir", "sub", "new.txt"), "upper new")
+	assertFileContent(t, filepath.Join(base, "dir", "sub", "deep.txt"), "base deep")
+	assertFileContent(t, filepath.Join(base, "replace", "new.txt"), "new")
+	assert.NoFileExists(t, filepath.Join(base, "remove.txt"))
+	assert.NoFileExists(t, filepath.Join(base, "replace", "old.txt"))
+	assert.Empty(t, overlay.Whiteouts())
+
+	leftovers, err := os.ReadDir(scratch)
+	assert.NoError(t, err)
+	assert.Empty(t, leftovers)
+}
+
+func TestOverlayFileIo_ReplaceDirWithFile(t *testing.T) {
+	overlay, base, _ := newOverlayFixture(t)
+	replace := filepath.Join(base, "replace")
+
+	assert.NoError(t, overlay.DeleteDir(replace))
+	assert.NoError(t, overlay.WriteFile(replace, []byte("now a file"), 0o644))
+	assert.False(t, overlay.FileExists(filepath.Join(replace, "old.txt")))
+	assert.Empty(t, overlay.Whiteouts())
+
+	assert.NoError(t, overlay.DeleteFile(replace))
+	assert.Equal(t, []string{replace}, overlay.Whiteouts())
+	assert.NoError(t, overlay.WriteFile(replace, []byte("now a file"), 0o644))
+
+	assert.NoError(t, overlay.Commit())
+	assertFileContent(t, replace, "now a file")
+}
+
+func TestOverlayFileIo_CommitErrors(t *testing.T) {
+	memory := NewMemoryFileIo()
+	overlay := NewOverlayFileIo(NewReadOnlyFileIo(memory), NewMemoryFileIo())
+	assert.NoError(t, overlay.WriteFile("/file.txt", []byte("x"), 0o644))
+
+	err := overlay.Commit()
+	assert.ErrorIs(t, err, ErrReadOnly)
+	var fileIoErr *FileIoError
+	assert.ErrorAs(t, err, &fileIoErr)
+	assert.Equal(t, BackendOverlay, fileIoErr.Backend)
+	assert.Equal(t, "Commit", fileIoErr.Op)
+}
+
+func TestOverlayFileIo_Discard(t *testing.T) {
+	overlay, base, scratch := newOverlayFixture(t)
+
+	assert.NoError(t, overlay.WriteFile(filepath.Join(base, "dir", "sub", "new.txt"), []byte("upper new"), 0o644))
+	assert.NoError(t, overlay.DeleteDir(filepath.Join(base, "dir")))
+
+	assert.NoError(t, overlay.WriteFile(filepath.Join(base, "change.txt"), []byte("upper change"), 0o644))
+	assert.NoError(t, overlay.DeleteFile(filepath.Join(base, "keep.txt")))
+	assert.NoError(t, overlay.Discard())
+
+	content, err := overlay.ReadFile(filepath.Join(base, "change.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "base change", string(content))
+	assert.True(t, overlay.FileExists(filepath.Join(base, "keep.txt")))
+	assert.True(t, overlay.FileExists(filepath.Join(base, "dir", "sub", "deep.txt")))
+
+	leftovers, err := os.ReadDir(scratch)
+	assert.NoError(t, err)
+	assert.Empty(t, leftovers)
+}
@@ -0,0 +1,198 @@
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+)
+
+// Environment provides the variables and home directories used by Expand
+type Environment interface {
+	LookupEnv(key string) (string, bool)
+	HomeDir() (string, error)
+	LookupUserHome(username string) (string, error)
+}
+
+// OsEnvironment reads the process environment and the system user database
+type OsEnvironment struct{}
+
+func (OsEnvironment) LookupEnv(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+func (OsEnvironment) HomeDir() (string, error) {
+	return os.UserHomeDir()
+}
+
+func (OsEnvironment) LookupUserHome(username string) (string, error) {
+	u, err := user.Lookup(username)
+	if err != nil {
+		return "", err
+	}
+
+	return u.HomeDir, nil
+}
+
+// StaticEnvironment is a fixed Environment, useful to make expansion
+// deterministic in tests
+type StaticEnvironment struct {
+	Variables map[string]string
+	Home      string
+	UserHomes map[string]string
+}
+
+func (e StaticEnvironment) LookupEnv(key string) (string, bool) {
+	value, ok := e.Variables[key]
+	return value, ok
+}
+
+func (e StaticEnvironment) HomeDir() (string, error) {
+	if e.Home == "" {
+		return "", errors.New("home directory is not defined")
+	}
+
+	return e.Home, nil
+}
+
+func (e StaticEnvironment) LookupUserHome(username string) (string, error) {
+	home, ok := e.UserHomes[username]
+	if !ok {
+		return "", fmt.Errorf("unknown user %s", username)
+	}
+
+	return home, nil
+}
+
+// Expand replaces a leading ~ or ~user with the home directory and expands
+// $VAR, ${VAR}, ${VAR:-default}, ${VAR-default} and %VAR% variables. Unset
+// $VAR variables expand to an empty string as in a shell while unknown %VAR%
+// references are kept as is, like cmd.exe does.
+func Expand(path string, env Environment) (string, error) {
+	if env == nil {
+		env = OsEnvironment{}
+	}
+
+	path, err := expandTilde(path, env)
+	if err != nil {
+		return "", err
+	}
+
+	return expandVariables(path, env), nil
+}
+
+func expandTilde(path string, env Environment) (string, error) {
+	if !strings.HasPrefix(path, "~") {
+		return path, nil
+	}
+
+	end := 1
+	for end < len(path) && !isWindowsSeparator(path[end]) {
+		end++
+	}
+
+	var home string
+	var err error
+	if username := path[1:end]; username == "" {
+		home, err = env.HomeDir()
+	} else {
+		home, err = env.LookupUserHome(username)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return home + path[end:], nil
+}
+
+func expandVariables(path string, env Environment) string {
+	var sb strings.Builder
+	for i := 0; i < len(path); {
+		c := path[i]
+		switch {
+		case c == '$' && i+1 < len(path) && path[i+1] == '{':
+			end := closingBrace(path, i+2)
+			if end < 0 {
+				sb.WriteString(path[i:])
+				return sb.String()
+			}
+			sb.WriteString(expandBraced(path[i+2:end], env))
+			i = end + 1
+		case c == '$' && i+1 < len(path) && isNameStart(path[i+1]):
+			end := i + 1
+			for end < len(path) && isNameChar(path[end]) {
+				end++
+			}
+			value, _ := env.LookupEnv(path[i+1 : end])
+			sb.WriteString(value)
+			i = end
+		case c == '%':
+			end := strings.IndexByte(path[i+1:], '%')
+			if end > 0 {
+				if value, ok := env.LookupEnv(path[i+1 : i+1+end]); ok {
+					sb.WriteString(value)
+					i += end + 2
+					continue
+				}
+			}
+			sb.WriteByte(c)
+			i++
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+
+	return sb.String()
+}
+
+// expandBraced expands the content of ${...}
+func expandBraced(expression string, env Environment) string {
+	end := 0
+	for end < len(expression) && isNameChar(expression[end]) {
+		end++
+	}
+
+	name := expression[:end]
+	operator := expression[end:]
+	value, ok := env.LookupEnv(name)
+	switch {
+	case strings.HasPrefix(operator, ":-"):
+		if !ok || value == "" {
+			return expandVariables(operator[2:], env)
+		}
+	case strings.HasPrefix(operator, "-"):
+		if !ok {
+			return expandVariables(operator[1:], env)
+		}
+	}
+
+	return value
+}
+
+// closingBrace returns the index of the brace closing the one before start
+func closingBrace(path string, start int) int {
+	depth := 1
+	for i := start; i < len(path); i++ {
+		switch path[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || '0' <= c && c <= '9'
+}
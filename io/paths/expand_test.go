@@ -0,0 +1,74 @@
+package paths
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testEnvironment() StaticEnvironment {
+	return StaticEnvironment{
+		Variables: map[string]string{
+			"XDG_DATA_HOME": "/home/me/.local/share",
+			"APPDATA":       `C:\Users\me\AppData\Roaming`,
+			"EMPTY":         "",
+			"APP":           "tool",
+		},
+		Home: "/home/me",
+		UserHomes: map[string]string{
+			"alice": "/home/alice",
+		},
+	}
+}
+
+func TestExpand(t *testing.T) {
+	tests := map[string]string{
+		"~":                              "/home/me",
+		"~/.cache/app":                   "/home/me/.cache/app",
+		`~\AppData`:                      `/home/me\AppData`,
+		"~alice/docs":                    "/home/alice/docs",
+		"$XDG_DATA_HOME/app":             "/home/me/.local/share/app",
+		"${XDG_DATA_HOME}/app":           "/home/me/.local/share/app",
+		"${MISSING:-/tmp}/app":           "/tmp/app",
+		"${EMPTY:-fallback}":             "fallback",
+		"${EMPTY-fallback}":              "",
+		"${MISSING-fallback}":            "fallback",
+		"${MISSING:-$HOME_DIR}/x":        "/x",
+		"${MISSING:-${APP}}/x":           "tool/x",
+		`%APPDATA%\app`:                  `C:\Users\me\AppData\Roaming\app`,
+		`%UNKNOWN%\app`:                  `%UNKNOWN%\app`,
+		"100%":                           "100%",
+		"$MISSING/app":                   "/app",
+		"/data/$APP/${APP}-1/%APP%.conf": "/data/tool/tool-1/tool.conf",
+		"/no/vars/here":                  "/no/vars/here",
+		"a~b":                            "a~b",
+		"$":                              "$",
+		"${unclosed":                     "${unclosed",
+	}
+
+	for input, expected := range tests {
+		actual, err := Expand(input, testEnvironment())
+		assert.NoError(t, err, "Expand(%q)", input)
+		assert.Equal(t, expected, actual, "Expand(%q)", input)
+	}
+}
+
+func TestExpand_Errors(t *testing.T) {
+	t.Run("Unknown User", func(t *testing.T) {
+		_, err := Expand("~bob/docs", testEnvironment())
+		assert.Error(t, err)
+	})
+
+	t.Run("No Home", func(t *testing.T) {
+		_, err := Expand("~/docs", StaticEnvironment{})
+		assert.Error(t, err)
+	})
+}
+
+func TestExpand_OsEnvironment(t *testing.T) {
+	t.Setenv("COMMON_GO_HELPERS_TEST", "value")
+
+	actual, err := Expand("/a/$COMMON_GO_HELPERS_TEST", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "/a/value", actual)
+}
@@ -0,0 +1,282 @@
+// Package paths manipulates Posix and Windows paths independently of the
+// operating system the code runs on
+package paths
+
+import (
+	"errors"
+	"strings"
+)
+
+// Flavour is a family of path syntax rules
+type Flavour interface {
+	Name() string
+	Separator() string
+	IsSeparator(c byte) bool
+	VolumeName(path string) string
+	IsAbs(path string) bool
+	Clean(path string) string
+	Join(elem ...string) string
+	Split(path string) (dir, file string)
+	Dir(path string) string
+	Base(path string) string
+	Ext(path string) string
+	Rel(basepath, targpath string) (string, error)
+	Abs(base, path string) string
+	ToSlash(path string) string
+	FromSlash(path string) string
+}
+
+var (
+	Posix   Flavour = PosixPath{}
+	Windows Flavour = WindowsPath{}
+)
+
+// rules holds what differs between flavours, the algorithms below are shared
+type rules struct {
+	separator     byte
+	isSeparator   func(c byte) bool
+	volumeNameLen func(path string) int
+	sameWord      func(a, b string) bool
+}
+
+func (r rules) clean(path string) string {
+	volumeLen := r.volumeNameLen(path)
+	volume := r.normalize(path[:volumeLen])
+	rest := path[volumeLen:]
+	if rest == "" {
+		if volumeLen > 2 {
+			return volume
+		}
+		return volume + "."
+	}
+
+	rooted := r.isSeparator(rest[0])
+	elements := []string{}
+	for _, element := range r.elements(rest) {
+		switch element {
+		case "", ".":
+		case "..":
+			switch {
+			case len(elements) > 0 && elements[len(elements)-1] != "..":
+				elements = elements[:len(elements)-1]
+			case !rooted:
+				elements = append(elements, "..")
+			}
+		default:
+			elements = append(elements, element)
+		}
+	}
+
+	cleaned := strings.Join(elements, string(r.separator))
+	if rooted {
+		cleaned = string(r.separator) + cleaned
+	}
+	if cleaned == "" {
+		cleaned = "."
+	}
+
+	return volume + cleaned
+}
+
+func (r rules) elements(path string) []string {
+	return strings.FieldsFunc(path, func(c rune) bool {
+		return c < 0x80 && r.isSeparator(byte(c))
+	})
+}
+
+// normalize replaces every separator with the canonical one
+func (r rules) normalize(path string) string {
+	buffer := []byte(path)
+	for i := range buffer {
+		if r.isSeparator(buffer[i]) {
+			buffer[i] = r.separator
+		}
+	}
+
+	return string(buffer)
+}
+
+func (r rules) join(elem ...string) string {
+	nonEmpty := []string{}
+	for _, e := range elem {
+		if e != "" {
+			nonEmpty = append(nonEmpty, e)
+		}
+	}
+
+	if len(nonEmpty) == 0 {
+		return ""
+	}
+
+	return r.clean(strings.Join(nonEmpty, string(r.separator)))
+}
+
+func (r rules) split(path string) (dir, file string) {
+	volumeLen := r.volumeNameLen(path)
+	i := len(path) - 1
+	for i >= volumeLen && !r.isSeparator(path[i]) {
+		i--
+	}
+
+	return path[:i+1], path[i+1:]
+}
+
+func (r rules) base(path string) string {
+	if path == "" {
+		return "."
+	}
+
+	for len(path) > 0 && r.isSeparator(path[len(path)-1]) {
+		path = path[:len(path)-1]
+	}
+
+	path = path[r.volumeNameLen(path):]
+	i := len(path) - 1
+	for i >= 0 && !r.isSeparator(path[i]) {
+		i--
+	}
+	if i >= 0 {
+		path = path[i+1:]
+	}
+
+	if path == "" {
+		return string(r.separator)
+	}
+
+	return path
+}
+
+func (r rules) dir(path string) string {
+	volumeLen := r.volumeNameLen(path)
+	volume := path[:volumeLen]
+	i := len(path) - 1
+	for i >= volumeLen && !r.isSeparator(path[i]) {
+		i--
+	}
+
+	dir := r.clean(path[volumeLen : i+1])
+	if dir == "." && volumeLen > 2 {
+		return r.normalize(volume)
+	}
+
+	return r.normalize(volume) + dir
+}
+
+func (r rules) ext(path string) string {
+	for i := len(path) - 1; i >= 0 && !r.isSeparator(path[i]); i-- {
+		if path[i] == '.' {
+			return path[i:]
+		}
+	}
+
+	return ""
+}
+
+func (r rules) rel(basepath, targpath string) (string, error) {
+	baseVolume := basepath[:r.volumeNameLen(basepath)]
+	targVolume := targpath[:r.volumeNameLen(targpath)]
+	base := r.clean(basepath)
+	targ := r.clean(targpath)
+	if r.sameWord(targ, base) {
+		return ".", nil
+	}
+
+	base = base[len(baseVolume):]
+	targ = targ[len(targVolume):]
+	if base == "." {
+		base = ""
+	} else if base == "" && len(baseVolume) > 2 {
+		base = string(r.separator)
+	}
+
+	separator := r.separator
+	baseRooted := len(base) > 0 && base[0] == separator
+	targRooted := len(targ) > 0 && targ[0] == separator
+	if baseRooted != targRooted || !r.sameWord(r.normalize(baseVolume), r.normalize(targVolume)) {
+		return "", errors.New("Rel: can't make " + targpath + " relative to " + basepath)
+	}
+
+	baseLen := len(base)
+	targLen := len(targ)
+	var b0, bi, t0, ti int
+	for {
+		for bi < baseLen && base[bi] != separator {
+			bi++
+		}
+		for ti < targLen && targ[ti] != separator {
+			ti++
+		}
+		if !r.sameWord(targ[t0:ti], base[b0:bi]) {
+			break
+		}
+		if bi < baseLen {
+			bi++
+		}
+		if ti < targLen {
+			ti++
+		}
+		b0 = bi
+		t0 = ti
+	}
+
+	if base[b0:bi] == ".." {
+		return "", errors.New("Rel: can't make " + targpath + " relative to " + basepath)
+	}
+
+	if b0 == baseLen {
+		return targ[t0:], nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("..")
+	for i := 0; i < strings.Count(base[b0:baseLen], string(separator)); i++ {
+		sb.WriteByte(separator)
+		sb.WriteString("..")
+	}
+	if t0 != targLen {
+		sb.WriteByte(separator)
+		sb.WriteString(targ[t0:])
+	}
+
+	return sb.String(), nil
+}
+
+// Convert rewrites path from one flavour to another. Windows drive letters
+// have no Posix equivalent and are dropped, UNC shares map to a path starting
+// with a double slash and back.
+func Convert(path string, from, to Flavour) string {
+	if from.Name() == to.Name() {
+		return path
+	}
+
+	volume := from.VolumeName(path)
+	rest := path[len(volume):]
+	if to.Name() == Windows.Name() {
+		volume = ""
+		if strings.HasPrefix(path, "//") && !strings.HasPrefix(path, "///") {
+			volume = Windows.VolumeName(path)
+			rest = path[len(volume):]
+		}
+		return Windows.FromSlash(volume) + Windows.FromSlash(rest)
+	}
+
+	return posixVolume(volume) + from.ToSlash(rest)
+}
+
+// posixVolume keeps the share of a Windows UNC volume and drops drive letters
+func posixVolume(volume string) string {
+	volume = Windows.ToSlash(volume)
+	switch {
+	case len(volume) > 8 && strings.EqualFold(volume[:8], "//?/UNC/"):
+		return "//" + volume[8:]
+	case strings.HasPrefix(volume, "//?/") || strings.HasPrefix(volume, "//./"):
+		if len(volume) == 6 && volume[5] == ':' {
+			return ""
+		}
+		return volume
+	case strings.HasPrefix(volume, "//"):
+		return volume
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,40 @@
+package paths
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		input    string
+		from, to Flavour
+		expected string
+	}{
+		{`C:\path\to\file`, Windows, Posix, `/path/to/file`},
+		{`\\server\share\file`, Windows, Posix, `//server/share/file`},
+		{`\\?\C:\long\path`, Windows, Posix, `/long/path`},
+		{`\\?\UNC\server\share\file`, Windows, Posix, `//server/share/file`},
+		{`relative\path`, Windows, Posix, `relative/path`},
+		{`/a/b:c/d`, Windows, Posix, `/a/b:c/d`},
+		{`/etc/hosts`, Posix, Windows, `\etc\hosts`},
+		{`//server/share/file`, Posix, Windows, `\\server\share\file`},
+		{`C:/path/to/file`, Posix, Windows, `C:\path\to\file`},
+		{`a/b`, Posix, Posix, `a/b`},
+	}
+
+	for _, test := range tests {
+		actual := Convert(test.input, test.from, test.to)
+		assert.Equal(t, test.expected, actual, "Convert(%q, %s, %s)", test.input, test.from.Name(), test.to.Name())
+	}
+}
+
+func TestFlavourNames(t *testing.T) {
+	assert.Equal(t, "posix", Posix.Name())
+	assert.Equal(t, "/", Posix.Separator())
+	assert.Equal(t, "windows", Windows.Name())
+	assert.Equal(t, "\\", Windows.Separator())
+	assert.True(t, Windows.IsSeparator('/'))
+	assert.False(t, Posix.IsSeparator('\\'))
+}
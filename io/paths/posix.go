@@ -0,0 +1,86 @@
+package paths
+
+import (
+	"strings"
+)
+
+// PosixPath implements the path rules used by Linux, macOS and the BSDs
+type PosixPath struct{}
+
+var posixRules = rules{
+	separator: '/',
+	isSeparator: func(c byte) bool {
+		return c == '/'
+	},
+	volumeNameLen: func(path string) int {
+		return 0
+	},
+	sameWord: func(a, b string) bool {
+		return a == b
+	},
+}
+
+func (PosixPath) Name() string {
+	return "posix"
+}
+
+func (PosixPath) Separator() string {
+	return "/"
+}
+
+func (PosixPath) IsSeparator(c byte) bool {
+	return posixRules.isSeparator(c)
+}
+
+func (PosixPath) VolumeName(path string) string {
+	return ""
+}
+
+func (PosixPath) IsAbs(path string) bool {
+	return strings.HasPrefix(path, "/")
+}
+
+func (PosixPath) Clean(path string) string {
+	return posixRules.clean(path)
+}
+
+func (PosixPath) Join(elem ...string) string {
+	return posixRules.join(elem...)
+}
+
+func (PosixPath) Split(path string) (dir, file string) {
+	return posixRules.split(path)
+}
+
+func (PosixPath) Dir(path string) string {
+	return posixRules.dir(path)
+}
+
+func (PosixPath) Base(path string) string {
+	return posixRules.base(path)
+}
+
+func (PosixPath) Ext(path string) string {
+	return posixRules.ext(path)
+}
+
+func (PosixPath) Rel(basepath, targpath string) (string, error) {
+	return posixRules.rel(basepath, targpath)
+}
+
+// Abs returns path made absolute against the absolute directory base
+func (p PosixPath) Abs(base, path string) string {
+	if p.IsAbs(path) {
+		return p.Clean(path)
+	}
+
+	return p.Join(base, path)
+}
+
+func (PosixPath) ToSlash(path string) string {
+	return path
+}
+
+func (PosixPath) FromSlash(path string) string {
+	return path
+}
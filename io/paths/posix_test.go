@@ -0,0 +1,68 @@
+package paths
+
+import (
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var posixSamples = []string{
+	"", ".", "..", "/", "//", "a", "/a", "a/", "/a/b/c", "a/b/../c", "/../a", "../../a",
+	"a/./b", "a//b", "/a/b/", "abc/def/../../..", "/etc/nginx/conf.d/site.conf", "a/b.tar.gz",
+	".hidden", "dir/.hidden",
+}
+
+func TestPosixPath_MatchesStandardLibrary(t *testing.T) {
+	for _, sample := range posixSamples {
+		assert.Equal(t, path.Clean(sample), Posix.Clean(sample), "Clean(%q)", sample)
+		assert.Equal(t, path.Base(sample), Posix.Base(sample), "Base(%q)", sample)
+		assert.Equal(t, path.Dir(sample), Posix.Dir(sample), "Dir(%q)", sample)
+		assert.Equal(t, path.Ext(sample), Posix.Ext(sample), "Ext(%q)", sample)
+		assert.Equal(t, path.IsAbs(sample), Posix.IsAbs(sample), "IsAbs(%q)", sample)
+
+		dir, file := path.Split(sample)
+		actualDir, actualFile := Posix.Split(sample)
+		assert.Equal(t, dir, actualDir, "Split(%q)", sample)
+		assert.Equal(t, file, actualFile, "Split(%q)", sample)
+	}
+}
+
+func TestPosixPath_Join(t *testing.T) {
+	assert.Equal(t, "/etc/nginx/conf.d", Posix.Join("/etc", "nginx/conf.d"))
+	assert.Equal(t, "a/b", Posix.Join("a", "", "b"))
+	assert.Equal(t, "", Posix.Join("", ""))
+	assert.Equal(t, "/b", Posix.Join("/a", "../b"))
+	assert.Equal(t, "a\\b/c", Posix.Join("a\\b", "c"))
+}
+
+func TestPosixPath_Rel(t *testing.T) {
+	tests := []struct {
+		base, target, expected string
+		fails                  bool
+	}{
+		{"/a/b", "/a/b/c/d", "c/d", false},
+		{"/a/b/c", "/a/d", "../../d", false},
+		{"/a", "/a", ".", false},
+		{"a/b", "a/c", "../c", false},
+		{".", "a/b", "a/b", false},
+		{"/a", "b", "", true},
+		{"../a", "b", "", true},
+	}
+
+	for _, test := range tests {
+		actual, err := Posix.Rel(test.base, test.target)
+		if test.fails {
+			assert.Error(t, err, "Rel(%q, %q)", test.base, test.target)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, actual, "Rel(%q, %q)", test.base, test.target)
+	}
+}
+
+func TestPosixPath_Abs(t *testing.T) {
+	assert.Equal(t, "/home/user/file", Posix.Abs("/home/user", "file"))
+	assert.Equal(t, "/home/file", Posix.Abs("/home/user", "../file"))
+	assert.Equal(t, "/etc/file", Posix.Abs("/home/user", "/etc/./file"))
+}
@@ -0,0 +1,175 @@
+package paths
+
+import (
+	"strings"
+)
+
+// WindowsPath implements the Windows path rules, both slashes are accepted as
+// separators and drive letters, UNC shares (\\server\share) and the \\?\ and
+// \\.\ prefixes are recognised as volumes
+type WindowsPath struct{}
+
+var windowsRules = rules{
+	separator:     '\\',
+	isSeparator:   isWindowsSeparator,
+	volumeNameLen: windowsVolumeNameLen,
+	sameWord:      strings.EqualFold,
+}
+
+func isWindowsSeparator(c byte) bool {
+	return c == '\\' || c == '/'
+}
+
+func isDriveLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// windowsVolumeNameLen returns the length of the leading volume of path
+func windowsVolumeNameLen(path string) int {
+	if len(path) >= 2 && path[1] == ':' && isDriveLetter(path[0]) {
+		return 2
+	}
+
+	if len(path) < 2 || !isWindowsSeparator(path[0]) || !isWindowsSeparator(path[1]) {
+		return 0
+	}
+
+	// \\?\ and \\.\ prefixes
+	if len(path) >= 4 && (path[2] == '?' || path[2] == '.') && isWindowsSeparator(path[3]) {
+		rest := path[4:]
+		if len(rest) >= 2 && rest[1] == ':' && isDriveLetter(rest[0]) {
+			return 6
+		}
+		if len(rest) >= 3 && strings.EqualFold(rest[:3], "UNC") && (len(rest) == 3 || isWindowsSeparator(rest[3])) {
+			if len(rest) == 3 {
+				return len(path)
+			}
+			return 8 + uncShareLen(rest[4:])
+		}
+		return 4 + nextSeparator(rest)
+	}
+
+	return 2 + uncShareLen(path[2:])
+}
+
+// uncShareLen returns the length of "server\share" at the start of path
+func uncShareLen(path string) int {
+	server := nextSeparator(path)
+	if server == 0 || server == len(path) {
+		return server
+	}
+
+	share := nextSeparator(path[server+1:])
+	if share == 0 {
+		return server
+	}
+
+	return server + 1 + share
+}
+
+func nextSeparator(path string) int {
+	for i := 0; i < len(path); i++ {
+		if isWindowsSeparator(path[i]) {
+			return i
+		}
+	}
+
+	return len(path)
+}
+
+func (WindowsPath) Name() string {
+	return "windows"
+}
+
+func (WindowsPath) Separator() string {
+	return "\\"
+}
+
+func (WindowsPath) IsSeparator(c byte) bool {
+	return isWindowsSeparator(c)
+}
+
+func (WindowsPath) VolumeName(path string) string {
+	return path[:windowsVolumeNameLen(path)]
+}
+
+func (WindowsPath) IsAbs(path string) bool {
+	volumeLen := windowsVolumeNameLen(path)
+	if volumeLen == 0 {
+		return false
+	}
+
+	if volumeLen > 2 {
+		return true
+	}
+
+	return len(path) > 2 && isWindowsSeparator(path[2])
+}
+
+func (WindowsPath) Clean(path string) string {
+	return windowsRules.clean(path)
+}
+
+// Join joins the elements, a leading bare drive such as "C:" stays drive
+// relative as in the standard library
+func (w WindowsPath) Join(elem ...string) string {
+	for i, e := range elem {
+		if e == "" {
+			continue
+		}
+
+		if len(e) == 2 && windowsVolumeNameLen(e) == 2 {
+			return e + windowsRules.join(elem[i+1:]...)
+		}
+		break
+	}
+
+	return windowsRules.join(elem...)
+}
+
+func (WindowsPath) Split(path string) (dir, file string) {
+	return windowsRules.split(path)
+}
+
+func (WindowsPath) Dir(path string) string {
+	return windowsRules.dir(path)
+}
+
+func (WindowsPath) Base(path string) string {
+	return windowsRules.base(path)
+}
+
+func (WindowsPath) Ext(path string) string {
+	return windowsRules.ext(path)
+}
+
+func (WindowsPath) Rel(basepath, targpath string) (string, error) {
+	return windowsRules.rel(basepath, targpath)
+}
+
+// Abs returns path made absolute against the absolute directory base, paths
+// rooted without a drive take the drive of base and drive relative paths on
+// another drive are resolved from that drive root
+func (w WindowsPath) Abs(base, path string) string {
+	if w.IsAbs(path) {
+		return w.Clean(path)
+	}
+
+	volume := w.VolumeName(path)
+	switch {
+	case volume == "" && len(path) > 0 && isWindowsSeparator(path[0]):
+		return w.Clean(w.VolumeName(base) + path)
+	case volume != "" && !strings.EqualFold(volume, w.VolumeName(base)):
+		return w.Clean(volume + "\\" + path[len(volume):])
+	default:
+		return w.Join(base, path[len(volume):])
+	}
+}
+
+func (WindowsPath) ToSlash(path string) string {
+	return strings.ReplaceAll(path, "\\", "/")
+}
+
+func (WindowsPath) FromSlash(path string) string {
+	return strings.ReplaceAll(path, "/", "\\")
+}
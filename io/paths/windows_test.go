@@ -0,0 +1,118 @@
+package paths
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindowsPath_VolumeName(t *testing.T) {
+	tests := map[string]string{
+		`C:\Windows`:                `C:`,
+		`c:`:                        `c:`,
+		`C:foo`:                     `C:`,
+		`\\server\share\dir`:        `\\server\share`,
+		`//server/share/dir`:        `//server/share`,
+		`\\server\share`:            `\\server\share`,
+		`\\?\C:\very\long\path`:     `\\?\C:`,
+		`\\?\UNC\server\share\file`: `\\?\UNC\server\share`,
+		`\\.\PIPE\name`:             `\\.\PIPE`,
+		`\Windows`:                  ``,
+		`relative\path`:             ``,
+		`1:\not\a\drive`:            ``,
+	}
+
+	for input, expected := range tests {
+		assert.Equal(t, expected, Windows.VolumeName(input), "VolumeName(%q)", input)
+	}
+}
+
+func TestWindowsPath_IsAbs(t *testing.T) {
+	assert.True(t, Windows.IsAbs(`C:\Windows`))
+	assert.True(t, Windows.IsAbs(`C:/Windows`))
+	assert.True(t, Windows.IsAbs(`\\server\share\file`))
+	assert.True(t, Windows.IsAbs(`\\?\C:\file`))
+	assert.False(t, Windows.IsAbs(`C:Windows`))
+	assert.False(t, Windows.IsAbs(`\Windows`))
+	assert.False(t, Windows.IsAbs(`Windows`))
+	assert.False(t, Windows.IsAbs(`C:`))
+}
+
+func TestWindowsPath_Clean(t *testing.T) {
+	tests := map[string]string{
+		``:                            `.`,
+		`C:`:                          `C:.`,
+		`C:\`:                         `C:\`,
+		`C:/a/b/../c`:                 `C:\a\c`,
+		`C:\..\a`:                     `C:\a`,
+		`C:a\..\..\b`:                 `C:..\b`,
+		`a//b\\c`:                     `a\b\c`,
+		`\\server\share`:              `\\server\share`,
+		`\\server\share\a\..\..`:      `\\server\share\`,
+		`//server/share/a`:            `\\server\share\a`,
+		`\\?\C:\a\.\b`:                `\\?\C:\a\b`,
+		`\\?\UNC\server\share\a\..\b`: `\\?\UNC\server\share\b`,
+	}
+
+	for input, expected := range tests {
+		assert.Equal(t, expected, Windows.Clean(input), "Clean(%q)", input)
+	}
+}
+
+func TestWindowsPath_Join(t *testing.T) {
+	assert.Equal(t, `C:\etc\nginx\conf.d`, Windows.Join(`C:\etc`, `nginx/conf.d`))
+	assert.Equal(t, `C:a`, Windows.Join(`C:`, `a`))
+	assert.Equal(t, `\\server\share\a\b`, Windows.Join(`\\server\share`, `a`, `b`))
+	assert.Equal(t, `path\to\file`, Windows.Join(`path\`, `to/`, `file`))
+	assert.Equal(t, ``, Windows.Join())
+}
+
+func TestWindowsPath_SplitDirBaseExt(t *testing.T) {
+	dir, file := Windows.Split(`C:\a\b.txt`)
+	assert.Equal(t, `C:\a\`, dir)
+	assert.Equal(t, `b.txt`, file)
+
+	dir, file = Windows.Split(`C:b.txt`)
+	assert.Equal(t, `C:`, dir)
+	assert.Equal(t, `b.txt`, file)
+
+	assert.Equal(t, `C:\a`, Windows.Dir(`C:\a\b.txt`))
+	assert.Equal(t, `C:\`, Windows.Dir(`C:\a`))
+	assert.Equal(t, `\\server\share\`, Windows.Dir(`\\server\share\file`))
+	assert.Equal(t, `b.txt`, Windows.Base(`C:\a\b.txt`))
+	assert.Equal(t, `a`, Windows.Base(`C:/a/`))
+	assert.Equal(t, `\`, Windows.Base(`C:\`))
+	assert.Equal(t, `.gz`, Windows.Ext(`C:\a\b.tar.gz`))
+	assert.Equal(t, ``, Windows.Ext(`C:\a.d\b`))
+}
+
+func TestWindowsPath_Rel(t *testing.T) {
+	tests := []struct {
+		base, target, expected string
+		fails                  bool
+	}{
+		{`C:\a\b`, `C:\a\b\c\d`, `c\d`, false},
+		{`C:\a\b\c`, `c:\A\d`, `..\..\d`, false},
+		{`\\server\share\a`, `\\SERVER\share\b`, `..\b`, false},
+		{`C:\a`, `D:\a`, ``, true},
+		{`C:\a`, `a`, ``, true},
+	}
+
+	for _, test := range tests {
+		actual, err := Windows.Rel(test.base, test.target)
+		if test.fails {
+			assert.Error(t, err, "Rel(%q, %q)", test.base, test.target)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, actual, "Rel(%q, %q)", test.base, test.target)
+	}
+}
+
+func TestWindowsPath_Abs(t *testing.T) {
+	assert.Equal(t, `C:\Users\me\file`, Windows.Abs(`C:\Users\me`, `file`))
+	assert.Equal(t, `C:\Windows`, Windows.Abs(`C:\Users\me`, `\Windows`))
+	assert.Equal(t, `C:\Users\me\file`, Windows.Abs(`C:\Users\me`, `c:file`))
+	assert.Equal(t, `D:\file`, Windows.Abs(`C:\Users\me`, `D:file`))
+	assert.Equal(t, `\\server\share\file`, Windows.Abs(`C:\Users`, `\\server\share\file`))
+}
@@ -0,0 +1,156 @@
+package io
+
+import (
+	"strings"
+)
+
+// PlatformInfo describes the platform the process runs on
+type PlatformInfo struct {
+	OperatingSystem     OperatingSystem
+	Architecture        string
+	Distribution        string
+	DistributionName    string
+	DistributionVersion string
+	KernelVersion       string
+	IsWSL               bool
+	IsContainer         bool
+	ContainerRuntime    string
+}
+
+const (
+	ContainerRuntimeDocker     = "docker"
+	ContainerRuntimePodman     = "podman"
+	ContainerRuntimeKubernetes = "kubernetes"
+	ContainerRuntimeContainerd = "containerd"
+	ContainerRuntimeLxc        = "lxc"
+)
+
+var osReleasePaths = []string{"/etc/os-release", "/usr/lib/os-release"}
+
+// DetectPlatform gathers the platform details, every file is read through
+// fileIo so detection can run against a fixture tree in tests. The
+// architecture comes from the platform provider of fileIo when it has one.
+func DetectPlatform(fileIo FileIo) PlatformInfo {
+	info := PlatformInfo{
+		OperatingSystem: fileIo.GetOperatingSystem(),
+		Architecture:    platformOf(fileIo).Architecture(),
+	}
+
+	if info.OperatingSystem == WindowsOs || info.OperatingSystem == UnknownOs {
+		return info
+	}
+
+	release := readOsRelease(fileIo)
+	info.Distribution = release["ID"]
+	info.DistributionName = release["NAME"]
+	info.DistributionVersion = release["VERSION_ID"]
+
+	if info.OperatingSystem != LinuxOs {
+		return info
+	}
+
+	if data, err := fileIo.ReadFile("/proc/sys/kernel/osrelease"); err == nil {
+		info.KernelVersion = strings.TrimSpace(string(data))
+	}
+	info.IsWSL = isWSL(fileIo, info.KernelVersion)
+	info.ContainerRuntime = detectContainerRuntime(fileIo)
+	info.IsContainer = info.ContainerRuntime != ""
+
+	return info
+}
+
+func readOsRelease(fileIo FileIo) map[string]string {
+	for _, path := range osReleasePaths {
+		if data, err := fileIo.ReadFile(path); err == nil {
+			return parseOsRelease(string(data))
+		}
+	}
+
+	return map[string]string{}
+}
+
+// parseOsRelease reads the KEY=value lines of os-release(5), values may be
+// quoted and use backslash escapes
+func parseOsRelease(content string) map[string]string {
+	values := map[string]string{}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+
+		values[strings.TrimSpace(key)] = unquoteOsReleaseValue(value)
+	}
+
+	return values
+}
+
+func unquoteOsReleaseValue(value string) string {
+	if len(value) < 2 || (value[0] != '"' && value[0] != '\'') || value[len(value)-1] != value[0] {
+		return value
+	}
+
+	quote := value[0]
+	value = value[1 : len(value)-1]
+	if quote == '\'' {
+		return value
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) && strings.IndexByte("\"\\$`", value[i+1]) >= 0 {
+			i++
+		}
+		sb.WriteByte(value[i])
+	}
+
+	return sb.String()
+}
+
+func isWSL(fileIo FileIo, kernelVersion string) bool {
+	kernel := strings.ToLower(kernelVersion)
+	if strings.Contains(kernel, "microsoft") || strings.Contains(kernel, "wsl") {
+		return true
+	}
+
+	if data, err := fileIo.ReadFile("/proc/version"); err == nil {
+		return strings.Contains(strings.ToLower(string(data)), "microsoft")
+	}
+
+	return false
+}
+
+func detectContainerRuntime(fileIo FileIo) string {
+	if fileIo.FileExists("/.dockerenv") {
+		return ContainerRuntimeDocker
+	}
+	if fileIo.FileExists("/run/.containerenv") {
+		return ContainerRuntimePodman
+	}
+
+	data, err := fileIo.ReadFile("/proc/1/cgroup")
+	if err != nil {
+		return ""
+	}
+
+	cgroup := string(data)
+	switch {
+	case strings.Contains(cgroup, "kubepods"):
+		return ContainerRuntimeKubernetes
+	case strings.Contains(cgroup, "docker"):
+		return ContainerRuntimeDocker
+	case strings.Contains(cgroup, "libpod"):
+		return ContainerRuntimePodman
+	case strings.Contains(cgroup, "containerd"):
+		return ContainerRuntimeContainerd
+	case strings.Contains(cgroup, "/lxc"):
+		return ContainerRuntimeLxc
+	default:
+		return ""
+	}
+}
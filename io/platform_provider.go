@@ -0,0 +1,46 @@
+package io
+
+import (
+	"runtime"
+)
+
+// PlatformProvider tells a FileIo which platform it works for
+type PlatformProvider interface {
+	OperatingSystem() OperatingSystem
+	Architecture() string
+}
+
+// RuntimePlatform reports the platform the binary was built for, use
+// WithOperatingSystem or WithPlatform to work for another one
+type RuntimePlatform struct{}
+
+func (RuntimePlatform) OperatingSystem() OperatingSystem {
+	return getOperatingSystem()
+}
+
+func (RuntimePlatform) Architecture() string {
+	return runtime.GOARCH
+}
+
+// StaticPlatform reports fixed values
+type StaticPlatform struct {
+	OperatingSystemValue OperatingSystem
+	ArchitectureValue    string
+}
+
+func (p StaticPlatform) OperatingSystem() OperatingSystem {
+	return p.OperatingSystemValue
+}
+
+func (p StaticPlatform) Architecture() string {
+	return p.ArchitectureValue
+}
+
+// platformOf returns the provider of fileIo when it exposes one
+func platformOf(fileIo FileIo) PlatformProvider {
+	if provider, ok := fileIo.(interface{ Platform() PlatformProvider }); ok {
+		return provider.Platform()
+	}
+
+	return RuntimePlatform{}
+}
@@ -0,0 +1,57 @@
+package io
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlatformProvider(t *testing.T) {
+	t.Run("Runtime Platform By Default", func(t *testing.T) {
+		defaultClient := Default()
+
+		assert.Equal(t, RuntimePlatform{}, defaultClient.Platform())
+		assert.Equal(t, getExpectedDefaultOperatingSystem(), defaultClient.GetOperatingSystem())
+		assert.Equal(t, runtime.GOARCH, defaultClient.Platform().Architecture())
+	})
+
+	t.Run("Zero Value Uses Runtime Platform", func(t *testing.T) {
+		defaultClient := DefaultFileIo{}
+
+		assert.Equal(t, getExpectedDefaultOperatingSystem(), defaultClient.GetOperatingSystem())
+	})
+
+	t.Run("Static Platform", func(t *testing.T) {
+		defaultClient := Default(WithPlatform(StaticPlatform{
+			OperatingSystemValue: MacOs,
+			ArchitectureValue:    "arm64",
+		}))
+
+		assert.Equal(t, MacOs, defaultClient.GetOperatingSystem())
+		assert.Equal(t, "arm64", defaultClient.Platform().Architecture())
+	})
+}
+
+func TestWithOperatingSystem(t *testing.T) {
+	t.Parallel()
+
+	windows := Default(WithOperatingSystem(WindowsOs))
+	linux := Default(WithOperatingSystem(LinuxOs))
+
+	t.Run("Windows Instance", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, WindowsOs, windows.GetOperatingSystem())
+		assert.Equal(t, "\\", windows.GetOsPathSeparator())
+		assert.Equal(t, "C:\\path\\to\\file", windows.ToOsPath("C:/path/to/file"))
+		assert.Equal(t, "a\\b", windows.JoinPath("a", "b"))
+	})
+
+	t.Run("Linux Instance", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, LinuxOs, linux.GetOperatingSystem())
+		assert.Equal(t, "/", linux.GetOsPathSeparator())
+		assert.Equal(t, "/path/to/file", linux.ToOsPath("C:\\path\\to\\file"))
+		assert.Equal(t, "a/b", linux.JoinPath("a", "b"))
+	})
+}
@@ -0,0 +1,121 @@
+package io
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fixtureFileIo serves absolute paths from a fixture directory
+type fixtureFileIo struct {
+	DefaultFileIo
+	root string
+}
+
+func (f fixtureFileIo) ReadFile(path string) ([]byte, error) {
+	return f.DefaultFileIo.ReadFile(filepath.Join(f.root, filepath.FromSlash(path)))
+}
+
+func (f fixtureFileIo) FileExists(path string) bool {
+	return f.DefaultFileIo.FileExists(filepath.Join(f.root, filepath.FromSlash(path)))
+}
+
+func newPlatformFixture(name string, operatingSystem OperatingSystem) fixtureFileIo {
+	return fixtureFileIo{
+		DefaultFileIo: Default(WithPlatform(StaticPlatform{
+			OperatingSystemValue: operatingSystem,
+			ArchitectureValue:    "arm64",
+		})),
+		root: filepath.Join(getTestPath(), "platform", name),
+	}
+}
+
+func TestDetectPlatform(t *testing.T) {
+	t.Run("Ubuntu On WSL", func(t *testing.T) {
+		info := DetectPlatform(newPlatformFixture("ubuntu_wsl", LinuxOs))
+
+		assert.Equal(t, LinuxOs, info.OperatingSystem)
+		assert.Equal(t, "arm64", info.Architecture)
+		assert.Equal(t, "ubuntu", info.Distribution)
+		assert.Equal(t, "Ubuntu", info.DistributionName)
+		assert.Equal(t, "22.04", info.DistributionVersion)
+		assert.Equal(t, "5.15.133.1-microsoft-standard-WSL2", info.KernelVersion)
+		assert.True(t, info.IsWSL)
+		assert.False(t, info.IsContainer)
+		assert.Equal(t, "", info.ContainerRuntime)
+	})
+
+	t.Run("Alpine In Docker", func(t *testing.T) {
+		info := DetectPlatform(newPlatformFixture("alpine_docker", LinuxOs))
+
+		assert.Equal(t, "alpine", info.Distribution)
+		assert.Equal(t, "Alpine Linux", info.DistributionName)
+		assert.Equal(t, "3.19.0", info.DistributionVersion)
+		assert.Equal(t, "6.5.0-14-generic", info.KernelVersion)
+		assert.False(t, info.IsWSL)
+		assert.True(t, info.IsContainer)
+		assert.Equal(t, ContainerRuntimeDocker, info.ContainerRuntime)
+	})
+
+	t.Run("Fedora In Podman", func(t *testing.T) {
+		info := DetectPlatform(newPlatformFixture("fedora_podman", LinuxOs))
+
+		assert.Equal(t, "fedora", info.Distribution)
+		assert.Equal(t, "Fedora Linux", info.DistributionName)
+		assert.Equal(t, "39", info.DistributionVersion)
+		assert.True(t, info.IsContainer)
+		assert.Equal(t, ContainerRuntimePodman, info.ContainerRuntime)
+	})
+
+	t.Run("Debian In Kubernetes", func(t *testing.T) {
+		info := DetectPlatform(newPlatformFixture("debian_kubernetes", LinuxOs))
+
+		assert.Equal(t, "debian", info.Distribution)
+		assert.Equal(t, "12", info.DistributionVersion)
+		assert.True(t, info.IsContainer)
+		assert.Equal(t, ContainerRuntimeKubernetes, info.ContainerRuntime)
+	})
+
+	t.Run("Empty Tree", func(t *testing.T) {
+		info := DetectPlatform(newPlatformFixture("missing", LinuxOs))
+
+		assert.Equal(t, LinuxOs, info.OperatingSystem)
+		assert.Equal(t, "", info.Distribution)
+		assert.Equal(t, "", info.KernelVersion)
+		assert.False(t, info.IsWSL)
+		assert.False(t, info.IsContainer)
+	})
+
+	t.Run("FreeBSD Skips Linux Files", func(t *testing.T) {
+		info := DetectPlatform(newPlatformFixture("alpine_docker", FreeBSDOs))
+
+		assert.Equal(t, FreeBSDOs, info.OperatingSystem)
+		assert.True(t, info.OperatingSystem.IsBSD())
+		assert.Equal(t, "alpine", info.Distribution)
+		assert.Equal(t, "", info.KernelVersion)
+		assert.False(t, info.IsContainer)
+	})
+
+	t.Run("Windows", func(t *testing.T) {
+		info := DetectPlatform(newPlatformFixture("ubuntu_wsl", WindowsOs))
+
+		assert.Equal(t, WindowsOs, info.OperatingSystem)
+		assert.Equal(t, "", info.Distribution)
+	})
+}
+
+func TestParseOsRelease(t *testing.T) {
+	values := parseOsRelease(`# comment
+NAME="Quoted \"Name\" \$HOME"
+ID=plain
+SINGLE='single $quoted'
+
+BROKEN
+`)
+
+	assert.Equal(t, `Quoted "Name" $HOME`, values["NAME"])
+	assert.Equal(t, "plain", values["ID"])
+	assert.Equal(t, "single $quoted", values["SINGLE"])
+	assert.NotContains(t, values, "BROKEN")
+}
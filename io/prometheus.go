@@ -0,0 +1,104 @@
+package io
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// PrometheusExporter writes the metrics of a MemoryMetricsSink in the
+// Prometheus text exposition format, it can be served directly as an
+// http.Handler
+type PrometheusExporter struct {
+	Sink *MemoryMetricsSink
+	// Namespace prefixes every metric name, "fileio" when empty
+	Namespace string
+}
+
+// NewPrometheusExporter returns an exporter for sink using the "fileio"
+// namespace
+func NewPrometheusExporter(sink *MemoryMetricsSink) PrometheusExporter {
+	return PrometheusExporter{
+		Sink:      sink,
+		Namespace: "fileio",
+	}
+}
+
+// WriteTo writes every metric family to w
+func (e PrometheusExporter) WriteTo(w io.Writer) (int64, error) {
+	namespace := e.Namespace
+	if namespace == "" {
+		namespace = "fileio"
+	}
+
+	snapshot := e.Sink.Snapshot()
+	methods := make([]string, 0, len(snapshot))
+	for method := range snapshot {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	counter := &countingWriter{writer: w}
+	buffered := bufio.NewWriter(counter)
+
+	counters := []struct {
+		name  string
+		help  string
+		value func(metrics MethodMetrics) int64
+	}{
+		{"calls_total", "Number of FileIo calls.", func(m MethodMetrics) int64 { return m.Calls }},
+		{"errors_total", "Number of FileIo calls that returned an error.", func(m MethodMetrics) int64 { return m.Errors }},
+		{"read_bytes_total", "Bytes read through FileIo calls.", func(m MethodMetrics) int64 { return m.BytesRead }},
+		{"written_bytes_total", "Bytes written through FileIo calls.", func(m MethodMetrics) int64 { return m.BytesWritten }},
+	}
+
+	for _, family := range counters {
+		name := namespace + "_" + family.name
+		fmt.Fprintf(buffered, "# HELP %s %s\n# TYPE %s counter\n", name, family.help, name)
+		for _, method := range methods {
+			fmt.Fprintf(buffered, "%s{method=%q} %d\n", name, method, family.value(snapshot[method]))
+		}
+	}
+
+	name := namespace + "_call_duration_seconds"
+	fmt.Fprintf(buffered, "# HELP %s Latency of FileIo calls.\n# TYPE %s histogram\n", name, name)
+	buckets := e.Sink.Buckets()
+	for _, method := range methods {
+		metrics := snapshot[method]
+		cumulative := int64(0)
+		for i, bound := range buckets {
+			cumulative += metrics.BucketCounts[i]
+			fmt.Fprintf(buffered, "%s_bucket{method=%q,le=%q} %d\n", name, method, formatSeconds(bound), cumulative)
+		}
+		fmt.Fprintf(buffered, "%s_bucket{method=%q,le=\"+Inf\"} %d\n", name, method, metrics.Calls)
+		fmt.Fprintf(buffered, "%s_sum{method=%q} %s\n", name, method, formatSeconds(metrics.TotalDuration))
+		fmt.Fprintf(buffered, "%s_count{method=%q} %d\n", name, method, metrics.Calls)
+	}
+
+	err := buffered.Flush()
+	return counter.written, err
+}
+
+func (e PrometheusExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = e.WriteTo(w)
+}
+
+func formatSeconds(duration time.Duration) string {
+	return strconv.FormatFloat(duration.Seconds(), 'g', -1, 64)
+}
+
+type countingWriter struct {
+	writer  io.Writer
+	written int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.written += int64(n)
+	return n, err
+}
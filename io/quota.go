@@ -0,0 +1,486 @@
+package io
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// QuotaResource names the resource a QuotaError is about
+type QuotaResource string
+
+const (
+	QuotaBytes QuotaResource = "bytes"
+	QuotaFiles QuotaResource = "files"
+)
+
+// QuotaLimits caps the usage beneath a QuotaFileIo root, zero values mean
+// unlimited
+type QuotaLimits struct {
+	// MaxBytes caps the total size of the files
+	MaxBytes int64
+	// MaxFiles caps the number of files and directories, the root excluded
+	MaxFiles int64
+}
+
+// QuotaUsage is the size and number of files and directories beneath a
+// QuotaFileIo root
+type QuotaUsage struct {
+	Bytes int64
+	Files int64
+}
+
+// QuotaError is returned, wrapped in a FileIoError, when an operation would
+// take the usage of a QuotaFileIo over one of its limits
+type QuotaError struct {
+	Resource  QuotaResource
+	Limit     int64
+	Used      int64
+	Requested int64
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s: %d more %s requested, %d of %d used", ErrQuotaExceeded, e.Requested, e.Resource, e.Used, e.Limit)
+}
+
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// QuotaFileIo wraps a FileIo and enforces QuotaLimits on everything written
+// beneath a root directory. Usage is scanned when the QuotaFileIo is created
+// and kept up to date by the calls made through it, Rescan rebuilds it after
+// the directory was changed behind its back. Operations on paths outside of
+// the root pass through untouched. Mutations are serialized so concurrent
+// writes cannot overrun the limits together.
+type QuotaFileIo struct {
+	fileIo FileIo
+	root   string
+	limits QuotaLimits
+
+	mu    sync.Mutex
+	usage QuotaUsage
+}
+
+// NewQuotaFileIo returns a FileIo enforcing limits beneath root on fileIo,
+// the current usage of root is scanned first
+func NewQuotaFileIo(fileIo FileIo, root string, limits QuotaLimits) (*QuotaFileIo, error) {
+	result := &QuotaFileIo{
+		fileIo: fileIo,
+		root:   fileIo.GetOperatingSystem().PathFlavour().Clean(root),
+		limits: limits,
+	}
+
+	if err := result.Rescan(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Root returns the directory the quota applies to
+func (f *QuotaFileIo) Root() string {
+	return f.root
+}
+
+// Limits returns the enforced limits
+func (f *QuotaFileIo) Limits() QuotaLimits {
+	return f.limits
+}
+
+// Usage returns the current usage beneath the root
+func (f *QuotaFileIo) Usage() QuotaUsage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.usage
+}
+
+// Rescan walks the root to rebuild the usage, a missing root uses nothing
+func (f *QuotaFileIo) Rescan() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	usage, err := f.measure(f.root)
+	if err != nil {
+		return NewFileIoError(BackendQuota, "Rescan", f.root, err)
+	}
+	if usage.Files > 0 {
+		usage.Files--
+	}
+
+	f.usage = usage
+	return nil
+}
+
+func (f *QuotaFileIo) GetOperatingSystem() OperatingSystem {
+	return f.fileIo.GetOperatingSystem()
+}
+
+func (f *QuotaFileIo) FileExists(path string) bool {
+	return f.fileIo.FileExists(path)
+}
+
+func (f *QuotaFileIo) DirExists(folderPath string) bool {
+	return f.fileIo.DirExists(folderPath)
+}
+
+func (f *QuotaFileIo) CreateDir(folderPath string, mode fs.FileMode) error {
+	return f.mutate("CreateDir", folderPath, QuotaUsage{Files: 1}, []string{folderPath}, func() error {
+		return f.fileIo.CreateDir(folderPath, mode)
+	})
+}
+
+func (f *QuotaFileIo) CreateDirAll(folderPath string, mode fs.FileMode, parentMode ...fs.FileMode) error {
+	return f.mutate("CreateDirAll", folderPath, f.missingDirs(folderPath), []string{folderPath}, func() error {
+		return f.fileIo.CreateDirAll(folderPath, mode, parentMode...)
+	})
+}
+
+func (f *QuotaFileIo) EnsureDir(folderPath string, mode fs.FileMode) error {
+	return f.mutate("EnsureDir", folderPath, f.missingDirs(folderPath), []string{folderPath}, func() error {
+		return f.fileIo.EnsureDir(folderPath, mode)
+	})
+}
+
+func (f *QuotaFileIo) GetExecutionPath() string {
+	return f.fileIo.GetExecutionPath()
+}
+
+func (f *QuotaFileIo) ToOsPath(path string) string {
+	return f.fileIo.ToOsPath(path)
+}
+
+func (f *QuotaFileIo) GetOsPathSeparator() string {
+	return f.fileIo.GetOsPathSeparator()
+}
+
+func (f *QuotaFileIo) ReadFile(path string) ([]byte, error) {
+	return f.fileIo.ReadFile(path)
+}
+
+func (f *QuotaFileIo) ReadBufferedFile(path string, from, to int) ([]byte, error) {
+	return f.fileIo.ReadBufferedFile(path, from, to)
+}
+
+func (f *QuotaFileIo) WriteFile(path string, data []byte, mode os.FileMode) error {
+	return f.mutate("WriteFile", path, f.writeDelta(path, int64(len(data))), []string{path}, func() error {
+		return f.fileIo.WriteFile(path, data, mode)
+	})
+}
+
+func (f *QuotaFileIo) WriteBufferedFile(path string, data []byte, bufferSize int, mode os.FileMode) error {
+	return f.mutate("WriteBufferedFile", path, f.writeDelta(path, int64(len(data))), []string{path}, func() error {
+		return f.fileIo.WriteBufferedFile(path, data, bufferSize, mode)
+	})
+}
+
+func (f *QuotaFileIo) ReadDir(path string) ([]fs.DirEntry, error) {
+	return f.fileIo.ReadDir(path)
+}
+
+func (f *QuotaFileIo) JoinPath(parts ...string) string {
+	return f.fileIo.JoinPath(parts...)
+}
+
+func (f *QuotaFileIo) CopyFile(source, destination string) error {
+	return f.mutate("CopyFile", destination, f.copyDelta(source, destination), []string{destination}, func() error {
+		return f.fileIo.CopyFile(source, destination)
+	})
+}
+
+func (f *QuotaFileIo) DeleteFile(path string) error {
+	return f.mutate("DeleteFile", path, QuotaUsage{}, []string{path}, func() error {
+		return f.fileIo.DeleteFile(path)
+	})
+}
+
+func (f *QuotaFileIo) Move(source, destination string, policy ...OverwritePolicy) error {
+	var incoming QuotaUsage
+	if f.inside(destination) && !f.inside(source) {
+		incoming, _ = f.measure(source)
+	}
+
+	return f.mutate("Move", destination, incoming, []string{source, destination}, func() error {
+		return f.fileIo.Move(source, destination, policy...)
+	})
+}
+
+func (f *QuotaFileIo) CopyDir(source, destination string) error {
+	return f.mutate("CopyDir", destination, f.copyDelta(source, destination), []string{destination}, func() error {
+		return f.fileIo.CopyDir(source, destination)
+	})
+}
+
+func (f *QuotaFileIo) DeleteDir(path string) error {
+	return f.mutate("DeleteDir", path, QuotaUsage{}, []string{path}, func() error {
+		return f.fileIo.DeleteDir(path)
+	})
+}
+
+func (f *QuotaFileIo) Checksum(path string, method ChecksumMethod) (string, error) {
+	return f.fileIo.Checksum(path, method)
+}
+
+func (f *QuotaFileIo) FileInfo(path string) (os.FileInfo, error) {
+	return f.fileIo.FileInfo(path)
+}
+
+func (f *QuotaFileIo) TempDir(dir, pattern string) (string, CleanupFunc, error) {
+	return f.temp("TempDir", dir, func() (string, CleanupFunc, error) {
+		return f.fileIo.TempDir(dir, pattern)
+	})
+}
+
+func (f *QuotaFileIo) TempFile(dir, pattern string) (string, CleanupFunc, error) {
+	return f.temp("TempFile", dir, func() (string, CleanupFunc, error) {
+		return f.fileIo.TempFile(dir, pattern)
+	})
+}
+
+func (f *QuotaFileIo) OpenFile(path string) (io.ReadCloser, error) {
+	return OpenReader(f.fileIo, path)
+}
+
+// CreateFile creates path for writing, every write is checked against the
+// byte limit as it happens
+func (f *QuotaFileIo) CreateFile(path string, mode fs.FileMode) (io.WriteCloser, error) {
+	if !f.inside(path) {
+		return CreateWriter(f.fileIo, path, mode)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	existing, _ := f.measure(path)
+	delta := QuotaUsage{Bytes: -existing.Bytes, Files: 1 - existing.Files}
+	if err := f.check("CreateFile", path, delta); err != nil {
+		return nil, err
+	}
+
+	writer, err := CreateWriter(f.fileIo, path, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	f.add(delta)
+	return &quotaWriter{quota: f, path: path, writer: writer}, nil
+}
+
+// mutate checks that delta fits in the limits when path is beneath the root,
+// runs operation and updates the usage with how much the usage of affected
+// changed, so that partial failures are accounted for
+func (f *QuotaFileIo) mutate(op, path string, delta QuotaUsage, affected []string, operation func() error) error {
+	tracked := false
+	for _, candidate := range affected {
+		tracked = tracked || f.inside(candidate)
+	}
+	if !tracked {
+		return operation()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.inside(path) {
+		if err := f.check(op, path, delta); err != nil {
+			return err
+		}
+	}
+
+	before := f.measureAll(affected)
+	err := operation()
+	after := f.measureAll(affected)
+	f.add(QuotaUsage{Bytes: after.Bytes - before.Bytes, Files: after.Files - before.Files})
+
+	return err
+}
+
+func (f *QuotaFileIo) temp(op, dir string, create func() (string, CleanupFunc, error)) (string, CleanupFunc, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if dir != "" && f.inside(dir) {
+		if err := f.check(op, dir, QuotaUsage{Files: 1}); err != nil {
+			return "", nil, err
+		}
+	}
+
+	path, cleanup, err := create()
+	if err != nil || !f.inside(path) {
+		return path, cleanup, err
+	}
+
+	f.add(QuotaUsage{Files: 1})
+	return path, func() error {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		before, _ := f.measure(path)
+		err := cleanup()
+		after, _ := f.measure(path)
+		f.add(QuotaUsage{Bytes: after.Bytes - before.Bytes, Files: after.Files - before.Files})
+		return err
+	}, nil
+}
+
+// check returns a QuotaError when adding delta to the usage would exceed a
+// limit, callers hold mu
+func (f *QuotaFileIo) check(op, path string, delta QuotaUsage) error {
+	if f.limits.MaxBytes > 0 && delta.Bytes > 0 && f.usage.Bytes+delta.Bytes > f.limits.MaxBytes {
+		return NewFileIoError(BackendQuota, op, path, &QuotaError{
+			Resource:  QuotaBytes,
+			Limit:     f.limits.MaxBytes,
+			Used:      f.usage.Bytes,
+			Requested: delta.Bytes,
+		})
+	}
+
+	if f.limits.MaxFiles > 0 && delta.Files > 0 && f.usage.Files+delta.Files > f.limits.MaxFiles {
+		return NewFileIoError(BackendQuota, op, path, &QuotaError{
+			Resource:  QuotaFiles,
+			Limit:     f.limits.MaxFiles,
+			Used:      f.usage.Files,
+			Requested: delta.Files,
+		})
+	}
+
+	return nil
+}
+
+func (f *QuotaFileIo) add(delta QuotaUsage) {
+	f.usage.Bytes += delta.Bytes
+	f.usage.Files += delta.Files
+}
+
+// inside reports whether path is beneath the root, the root excluded
+func (f *QuotaFileIo) inside(path string) bool {
+	flavour := f.fileIo.GetOperatingSystem().PathFlavour()
+	path = flavour.Clean(path)
+	return path != f.root && isWithin(flavour, f.root, path)
+}
+
+// measure returns the usage of path and everything beneath it, a missing
+// path uses nothing
+func (f *QuotaFileIo) measure(path string) (QuotaUsage, error) {
+	info, err := f.fileIo.FileInfo(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return QuotaUsage{}, nil
+		}
+		return QuotaUsage{}, err
+	}
+
+	if !info.IsDir() {
+		return QuotaUsage{Bytes: info.Size(), Files: 1}, nil
+	}
+
+	entries, err := f.fileIo.ReadDir(path)
+	if err != nil {
+		return QuotaUsage{}, err
+	}
+
+	usage := QuotaUsage{Files: 1}
+	for _, entry := range entries {
+		child, err := f.measure(f.fileIo.JoinPath(path, entry.Name()))
+		if err != nil {
+			return QuotaUsage{}, err
+		}
+		usage.Bytes += child.Bytes
+		usage.Files += child.Files
+	}
+
+	return usage, nil
+}
+
+// measureAll sums the usage of the paths beneath the root, errors count as
+// no usage
+func (f *QuotaFileIo) measureAll(affected []string) QuotaUsage {
+	var total QuotaUsage
+	for _, path := range affected {
+		if !f.inside(path) {
+			continue
+		}
+		usage, _ := f.measure(path)
+		total.Bytes += usage.Bytes
+		total.Files += usage.Files
+	}
+
+	return total
+}
+
+// writeDelta is the usage change of writing size bytes to path
+func (f *QuotaFileIo) writeDelta(path string, size int64) QuotaUsage {
+	existing, _ := f.measure(path)
+	return QuotaUsage{Bytes: size - existing.Bytes, Files: 1 - existing.Files}
+}
+
+// copyDelta is the usage change of copying source over destination, merging
+// directories
+func (f *QuotaFileIo) copyDelta(source, destination string) QuotaUsage {
+	info, err := f.fileIo.FileInfo(source)
+	if err != nil {
+		return QuotaUsage{}
+	}
+
+	if !info.IsDir() {
+		return f.writeDelta(destination, info.Size())
+	}
+
+	var delta QuotaUsage
+	if !f.fileIo.DirExists(destination) {
+		delta.Files++
+	}
+
+	entries, err := f.fileIo.ReadDir(source)
+	if err != nil {
+		return delta
+	}
+
+	for _, entry := range entries {
+		child := f.copyDelta(f.fileIo.JoinPath(source, entry.Name()), f.fileIo.JoinPath(destination, entry.Name()))
+		delta.Bytes += child.Bytes
+		delta.Files += child.Files
+	}
+
+	return delta
+}
+
+// missingDirs counts the directories CreateDirAll would create beneath the
+// root to make path exist
+func (f *QuotaFileIo) missingDirs(path string) QuotaUsage {
+	flavour := f.fileIo.GetOperatingSystem().PathFlavour()
+	var missing QuotaUsage
+	for current := flavour.Clean(path); f.inside(current) && !f.fileIo.DirExists(current); current = flavour.Dir(current) {
+		missing.Files++
+	}
+
+	return missing
+}
+
+// quotaWriter charges the quota for every byte written through it
+type quotaWriter struct {
+	quota  *QuotaFileIo
+	path   string
+	writer io.WriteCloser
+}
+
+func (w *quotaWriter) Write(p []byte) (int, error) {
+	w.quota.mu.Lock()
+	defer w.quota.mu.Unlock()
+
+	if err := w.quota.check("CreateFile", w.path, QuotaUsage{Bytes: int64(len(p))}); err != nil {
+		return 0, err
+	}
+
+	n, err := w.writer.Write(p)
+	w.quota.add(QuotaUsage{Bytes: int64(n)})
+	return n, err
+}
+
+func (w *quotaWriter) Close() error {
+	return w.writer.Close()
+}
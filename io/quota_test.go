@@ -0,0 +1,142 @@
+package io
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestQuota(t *testing.T, limits QuotaLimits) (*MemoryFileIo, *QuotaFileIo) {
+	memory := NewMemoryFileIo()
+	assert.NoError(t, memory.CreateDirAll("/tenants/a", 0o755))
+	quota, err := NewQuotaFileIo(memory, "/tenants/a", limits)
+	assert.NoError(t, err)
+
+	return memory, quota
+}
+
+func assertQuotaError(t *testing.T, err error, resource QuotaResource) {
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+	var quotaErr *QuotaError
+	if assert.True(t, errors.As(err, &quotaErr)) {
+		assert.Equal(t, resource, quotaErr.Resource)
+	}
+}
+
+func TestQuotaFileIo_Bytes(t *testing.T) {
+	memory, quota := newTestQuota(t, QuotaLimits{MaxBytes: 10})
+
+	assert.NoError(t, quota.WriteFile("/tenants/a/one.txt", []byte("123456"), 0o644))
+	assert.Equal(t, QuotaUsage{Bytes: 6, Files: 1}, quota.Usage())
+
+	err := quota.WriteFile("/tenants/a/two.txt", []byte("12345"), 0o644)
+	assertQuotaError(t, err, QuotaBytes)
+	assert.False(t, memory.FileExists("/tenants/a/two.txt"))
+	assert.Equal(t, "quota exceeded: 5 more bytes requested, 6 of 10 used", err.(*FileIoError).Err.Error())
+
+	assert.NoError(t, quota.WriteFile("/tenants/a/one.txt", []byte("1234567890"), 0o644))
+	assert.Equal(t, QuotaUsage{Bytes: 10, Files: 1}, quota.Usage())
+
+	assert.NoError(t, memory.WriteFile("/outside.txt", []byte("0123456789abc"), 0o644))
+	err = quota.CopyFile("/outside.txt", "/tenants/a/copy.txt")
+	assertQuotaError(t, err, QuotaBytes)
+	assert.NoError(t, quota.WriteFile("/elsewhere.txt", []byte("0123456789abc"), 0o644))
+
+	err = quota.WriteBufferedFile("/tenants/a/buffered.txt", []byte("x"), 1, 0o644)
+	assertQuotaError(t, err, QuotaBytes)
+
+	assert.NoError(t, quota.DeleteFile("/tenants/a/one.txt"))
+	assert.Equal(t, QuotaUsage{}, quota.Usage())
+	assert.NoError(t, quota.WriteBufferedFile("/tenants/a/buffered.txt", []byte("x"), 1, 0o644))
+}
+
+func TestQuotaFileIo_Files(t *testing.T) {
+	memory, quota := newTestQuota(t, QuotaLimits{MaxFiles: 3})
+
+	assert.NoError(t, quota.CreateDir("/tenants/a/dir", 0o755))
+	assert.NoError(t, quota.WriteFile("/tenants/a/dir/file.txt", []byte("x"), 0o644))
+	assert.Equal(t, QuotaUsage{Bytes: 1, Files: 2}, quota.Usage())
+
+	err := quota.CreateDirAll("/tenants/a/x/y", 0o755)
+	assertQuotaError(t, err, QuotaFiles)
+	assert.False(t, memory.DirExists("/tenants/a/x"))
+
+	assert.NoError(t, quota.WriteFile("/tenants/a/dir/file.txt", []byte("y"), 0o644))
+	assert.NoError(t, quota.CreateDir("/tenants/a/other", 0o755))
+	assertQuotaError(t, quota.CreateDir("/tenants/a/more", 0o755), QuotaFiles)
+
+	assert.NoError(t, quota.DeleteDir("/tenants/a/dir"))
+	assert.Equal(t, QuotaUsage{Files: 1}, quota.Usage())
+}
+
+func TestQuotaFileIo_CopyDirAndMove(t *testing.T) {
+	memory, quota := newTestQuota(t, QuotaLimits{MaxBytes: 8, MaxFiles: 10})
+	assert.NoError(t, memory.CreateDirAll("/src/sub", 0o755))
+	assert.NoError(t, memory.CreateDir("/big", 0o755))
+	assert.NoError(t, memory.WriteFile("/src/a.txt", []byte("1234"), 0o644))
+	assert.NoError(t, memory.WriteFile("/src/sub/b.txt", []byte("5678"), 0o644))
+	assert.NoError(t, memory.WriteFile("/big/c.txt", []byte("123456789"), 0o644))
+
+	assert.NoError(t, quota.CopyDir("/src", "/tenants/a/copy"))
+	assert.Equal(t, QuotaUsage{Bytes: 8, Files: 4}, quota.Usage())
+
+	assert.NoError(t, quota.CopyDir("/src", "/tenants/a/copy"))
+	assert.Equal(t, QuotaUsage{Bytes: 8, Files: 4}, quota.Usage())
+
+	assertQuotaError(t, quota.CopyDir("/src", "/tenants/a/second"), QuotaBytes)
+	assertQuotaError(t, quota.Move("/big", "/tenants/a/big"), QuotaBytes)
+	assert.True(t, memory.DirExists("/big"))
+
+	assert.NoError(t, quota.Move("/tenants/a/copy", "/moved"))
+	assert.Equal(t, QuotaUsage{}, quota.Usage())
+	assert.NoError(t, quota.Move("/moved", "/tenants/a/moved"))
+	assert.Equal(t, QuotaUsage{Bytes: 8, Files: 4}, quota.Usage())
+	assert.NoError(t, quota.Move("/tenants/a/moved/sub", "/tenants/a/sub"))
+	assert.Equal(t, QuotaUsage{Bytes: 8, Files: 4}, quota.Usage())
+}
+
+func TestQuotaFileIo_Streams(t *testing.T) {
+	_, quota := newTestQuota(t, QuotaLimits{MaxBytes: 5})
+
+	writer, err := quota.CreateFile("/tenants/a/stream.txt", 0o644)
+	assert.NoError(t, err)
+	_, err = io.WriteString(writer, "abc")
+	assert.NoError(t, err)
+	_, err = io.WriteString(writer, "def")
+	assertQuotaError(t, err, QuotaBytes)
+	assert.NoError(t, writer.Close())
+	assert.Equal(t, QuotaUsage{Bytes: 3, Files: 1}, quota.Usage())
+}
+
+func TestQuotaFileIo_Rescan(t *testing.T) {
+	root := t.TempDir()
+	writeTestTree(t, root, map[string]string{
+		"a.txt":     "12345",
+		"sub/b.txt": "123",
+	})
+
+	quota, err := NewQuotaFileIo(Default(), root, QuotaLimits{MaxBytes: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, QuotaUsage{Bytes: 8, Files: 3}, quota.Usage())
+	assertQuotaError(t, quota.WriteFile(filepath.Join(root, "c.txt"), []byte("123"), 0o644), QuotaBytes)
+
+	assert.NoError(t, os.Remove(filepath.Join(root, "a.txt")))
+	assert.NoError(t, quota.Rescan())
+	assert.Equal(t, QuotaUsage{Bytes: 3, Files: 2}, quota.Usage())
+	assert.NoError(t, quota.WriteFile(filepath.Join(root, "c.txt"), []byte("123"), 0o644))
+
+	path, cleanup, err := quota.TempFile(root, "tmp-*")
+	assert.NoError(t, err)
+	assert.FileExists(t, path)
+	assert.Equal(t, QuotaUsage{Bytes: 6, Files: 4}, quota.Usage())
+	assert.NoError(t, cleanup())
+	assert.Equal(t, QuotaUsage{Bytes: 6, Files: 3}, quota.Usage())
+
+	missing, err := NewQuotaFileIo(Default(), filepath.Join(root, "missing"), QuotaLimits{})
+	assert.NoError(t, err)
+	assert.Equal(t, QuotaUsage{}, missing.Usage())
+}
@@ -0,0 +1,623 @@
+package io
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+// TokenBucket is a token bucket rate limiter safe for concurrent use, share
+// one between decorators, readers and writers to give them a common budget.
+// Taking more tokens than available goes into debt, so large transfers are
+// allowed and the following ones wait for the bucket to refill.
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+	sleep  func(ctx context.Context, delay time.Duration) error
+}
+
+// NewTokenBucket returns a full bucket refilling rate tokens per second up to
+// burst tokens, a rate of zero or less never limits
+func NewTokenBucket(rate float64, burst int64) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+
+	result := &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+		sleep:  sleepContext,
+	}
+	result.last = result.now()
+
+	return result
+}
+
+// Burst returns the size of the bucket
+func (b *TokenBucket) Burst() int64 {
+	return int64(b.burst)
+}
+
+// Wait takes n tokens, waiting until the bucket allows it. It returns the
+// context error without taking anything when ctx is done, or its deadline
+// passes, before the tokens are available.
+func (b *TokenBucket) Wait(ctx context.Context, n int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if n <= 0 || b.rate <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	b.refill()
+	b.tokens -= float64(n)
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(math.Ceil(-b.tokens / b.rate * float64(time.Second)))
+	}
+	b.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+
+	err := context.DeadlineExceeded
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) >= delay {
+		err = b.sleep(ctx, delay)
+	}
+
+	if err != nil {
+		b.mu.Lock()
+		b.tokens += float64(n)
+		b.mu.Unlock()
+	}
+
+	return err
+}
+
+// TryTake takes n tokens if they are available right now
+func (b *TokenBucket) TryTake(n int64) bool {
+	if n <= 0 || b.rate <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	if b.tokens < float64(n) {
+		return false
+	}
+
+	b.tokens -= float64(n)
+	return true
+}
+
+// refill adds the tokens earned since the last call, callers hold mu
+func (b *TokenBucket) refill() {
+	now := b.now()
+	elapsed := now.Sub(b.last).Seconds()
+	b.last = now
+	if elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed*b.rate)
+	}
+}
+
+// NewRateLimitedReader returns a reader taking a token from bucket for every
+// byte read from reader, reads are split to fit in the bucket
+func NewRateLimitedReader(ctx context.Context, reader io.Reader, bucket *TokenBucket) io.Reader {
+	return &rateLimitedReader{ctx: ctx, reader: reader, bucket: bucket}
+}
+
+// NewRateLimitedWriter returns a writer taking a token from bucket for every
+// byte written to writer, writes are split to fit in the bucket
+func NewRateLimitedWriter(ctx context.Context, writer io.Writer, bucket *TokenBucket) io.Writer {
+	return &rateLimitedWriter{ctx: ctx, writer: writer, bucket: bucket}
+}
+
+// RateLimitOption configures a RateLimitedFileIo
+type RateLimitOption func(*RateLimitedFileIo)
+
+// WithByteLimit throttles the bytes read or written by the methods of
+// classes, every class when none is given, with bucket
+func WithByteLimit(bucket *TokenBucket, classes ...MethodClass) RateLimitOption {
+	return func(f *RateLimitedFileIo) {
+		for _, class := range classesOrAll(classes) {
+			f.bytes[class] = bucket
+		}
+	}
+}
+
+// WithOpLimit throttles the calls to the methods of classes, every class when
+// none is given, with bucket, each call taking one token
+func WithOpLimit(bucket *TokenBucket, classes ...MethodClass) RateLimitOption {
+	return func(f *RateLimitedFileIo) {
+		for _, class := range classesOrAll(classes) {
+			f.ops[class] = bucket
+		}
+	}
+}
+
+// RateLimitedFileIo wraps a FileIo and throttles the calls and the bytes
+// transferred per method class with token buckets. Copies and streams are
+// throttled as the data flows, CopyFile and CopyDir are performed through
+// the streaming API when read or write bytes are limited. Move only takes an
+// operation token, the copy a backend falls back to across devices is not
+// throttled. Waits stop as soon as the context set with WithContext is done.
+type RateLimitedFileIo struct {
+	fileIo FileIo
+	ctx    context.Context
+	bytes  map[MethodClass]*TokenBucket
+	ops    map[MethodClass]*TokenBucket
+}
+
+// NewRateLimitedFileIo returns a FileIo throttling the calls made to fileIo
+func NewRateLimitedFileIo(fileIo FileIo, options ...RateLimitOption) RateLimitedFileIo {
+	result := RateLimitedFileIo{
+		fileIo: fileIo,
+		ctx:    context.Background(),
+		bytes:  map[MethodClass]*TokenBucket{},
+		ops:    map[MethodClass]*TokenBucket{},
+	}
+
+	for _, option := range options {
+		option(&result)
+	}
+
+	return result
+}
+
+// WithContext returns a copy of f whose waits are bounded by ctx
+func (f RateLimitedFileIo) WithContext(ctx context.Context) RateLimitedFileIo {
+	f.ctx = ctx
+	return f
+}
+
+func (f RateLimitedFileIo) GetOperatingSystem() OperatingSystem {
+	return f.fileIo.GetOperatingSystem()
+}
+
+// FileExists cannot report a cancelled wait, it runs unthrottled then
+func (f RateLimitedFileIo) FileExists(path string) bool {
+	_ = f.op("FileExists", path)
+	return f.fileIo.FileExists(path)
+}
+
+// DirExists cannot report a cancelled wait, it runs unthrottled then
+func (f RateLimitedFileIo) DirExists(folderPath string) bool {
+	_ = f.op("DirExists", folderPath)
+	return f.fileIo.DirExists(folderPath)
+}
+
+func (f RateLimitedFileIo) CreateDir(folderPath string, mode fs.FileMode) error {
+	if err := f.op("CreateDir", folderPath); err != nil {
+		return err
+	}
+
+	return f.fileIo.CreateDir(folderPath, mode)
+}
+
+func (f RateLimitedFileIo) CreateDirAll(folderPath string, mode fs.FileMode, parentMode ...fs.FileMode) error {
+	if err := f.op("CreateDirAll", folderPath); err != nil {
+		return err
+	}
+
+	return f.fileIo.CreateDirAll(folderPath, mode, parentMode...)
+}
+
+func (f RateLimitedFileIo) EnsureDir(folderPath string, mode fs.FileMode) error {
+	if err := f.op("EnsureDir", folderPath); err != nil {
+		return err
+	}
+
+	return f.fileIo.EnsureDir(folderPath, mode)
+}
+
+func (f RateLimitedFileIo) GetExecutionPath() string {
+	return f.fileIo.GetExecutionPath()
+}
+
+func (f RateLimitedFileIo) ToOsPath(path string) string {
+	return f.fileIo.ToOsPath(path)
+}
+
+func (f RateLimitedFileIo) GetOsPathSeparator() string {
+	return f.fileIo.GetOsPathSeparator()
+}
+
+func (f RateLimitedFileIo) ReadFile(path string) ([]byte, error) {
+	if err := f.op("ReadFile", path); err != nil {
+		return nil, err
+	}
+
+	content, err := f.fileIo.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := f.transfer("ReadFile", path, len(content)); err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
+
+func (f RateLimitedFileIo) ReadBufferedFile(path string, from, to int) ([]byte, error) {
+	if err := f.op("ReadBufferedFile", path); err != nil {
+		return nil, err
+	}
+
+	content, err := f.fileIo.ReadBufferedFile(path, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := f.transfer("ReadBufferedFile", path, len(content)); err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
+
+func (f RateLimitedFileIo) WriteFile(path string, data []byte, mode os.FileMode) error {
+	if err := f.op("WriteFile", path); err != nil {
+		return err
+	}
+	if err := f.transfer("WriteFile", path, len(data)); err != nil {
+		return err
+	}
+
+	return f.fileIo.WriteFile(path, data, mode)
+}
+
+func (f RateLimitedFileIo) WriteBufferedFile(path string, data []byte, bufferSize int, mode os.FileMode) error {
+	if err := f.op("WriteBufferedFile", path); err != nil {
+		return err
+	}
+	if err := f.transfer("WriteBufferedFile", path, len(data)); err != nil {
+		return err
+	}
+
+	return f.fileIo.WriteBufferedFile(path, data, bufferSize, mode)
+}
+
+func (f RateLimitedFileIo) ReadDir(path string) ([]fs.DirEntry, error) {
+	if err := f.op("ReadDir", path); err != nil {
+		return nil, err
+	}
+
+	return f.fileIo.ReadDir(path)
+}
+
+func (f RateLimitedFileIo) JoinPath(parts ...string) string {
+	return f.fileIo.JoinPath(parts...)
+}
+
+func (f RateLimitedFileIo) CopyFile(source, destination string) error {
+	if err := f.op("CopyFile", source); err != nil {
+		return err
+	}
+
+	if f.bytes[MethodClassRead] == nil && f.bytes[MethodClassWrite] == nil {
+		return f.fileIo.CopyFile(source, destination)
+	}
+
+	return f.copyFile(source, destination)
+}
+
+func (f RateLimitedFileIo) DeleteFile(path string) error {
+	if err := f.op("DeleteFile", path); err != nil {
+		return err
+	}
+
+	return f.fileIo.DeleteFile(path)
+}
+
+func (f RateLimitedFileIo) Move(source, destination string, policy ...OverwritePolicy) error {
+	if err := f.op("Move", source); err != nil {
+		return err
+	}
+
+	return f.fileIo.Move(source, destination, policy...)
+}
+
+func (f RateLimitedFileIo) CopyDir(source, destination string) error {
+	if err := f.op("CopyDir", source); err != nil {
+		return err
+	}
+
+	if f.bytes[MethodClassRead] == nil && f.bytes[MethodClassWrite] == nil {
+		return f.fileIo.CopyDir(source, destination)
+	}
+
+	return f.copyDir(source, destination)
+}
+
+func (f RateLimitedFileIo) DeleteDir(path string) error {
+	if err := f.op("DeleteDir", path); err != nil {
+		return err
+	}
+
+	return f.fileIo.DeleteDir(path)
+}
+
+func (f RateLimitedFileIo) Checksum(path string, method ChecksumMethod) (string, error) {
+	if err := f.op("Checksum", path); err != nil {
+		return "", err
+	}
+
+	bucket := f.bytes[MethodClassRead]
+	if bucket == nil {
+		return f.fileIo.Checksum(path, method)
+	}
+
+	reader, err := OpenReader(f.fileIo, path)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	checksum, err := checksumReader(NewRateLimitedReader(f.ctx, reader, bucket), method)
+	if err != nil {
+		return "", NewFileIoError(BackendRateLimit, "Checksum", path, err)
+	}
+
+	return checksum, nil
+}
+
+func (f RateLimitedFileIo) FileInfo(path string) (os.FileInfo, error) {
+	if err := f.op("FileInfo", path); err != nil {
+		return nil, err
+	}
+
+	return f.fileIo.FileInfo(path)
+}
+
+func (f RateLimitedFileIo) TempDir(dir, pattern string) (string, CleanupFunc, error) {
+	if err := f.op("TempDir", dir); err != nil {
+		return "", nil, err
+	}
+
+	return f.fileIo.TempDir(dir, pattern)
+}
+
+func (f RateLimitedFileIo) TempFile(dir, pattern string) (string, CleanupFunc, error) {
+	if err := f.op("TempFile", dir); err != nil {
+		return "", nil, err
+	}
+
+	return f.fileIo.TempFile(dir, pattern)
+}
+
+// OpenFile opens path for reading, the reads are throttled as they happen
+func (f RateLimitedFileIo) OpenFile(path string) (io.ReadCloser, error) {
+	if err := f.op("OpenFile", path); err != nil {
+		return nil, err
+	}
+
+	reader, err := OpenReader(f.fileIo, path)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket := f.bytes[MethodClassRead]
+	if bucket == nil {
+		return reader, nil
+	}
+
+	return &rateLimitedReadCloser{
+		rateLimitedReader: rateLimitedReader{ctx: f.ctx, reader: reader, bucket: bucket},
+		closer:            reader,
+	}, nil
+}
+
+// CreateFile creates path for writing, the writes are throttled as they
+// happen
+func (f RateLimitedFileIo) CreateFile(path string, mode fs.FileMode) (io.WriteCloser, error) {
+	if err := f.op("CreateFile", path); err != nil {
+		return nil, err
+	}
+
+	writer, err := CreateWriter(f.fileIo, path, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket := f.bytes[MethodClassWrite]
+	if bucket == nil {
+		return writer, nil
+	}
+
+	return &rateLimitedWriteCloser{
+		rateLimitedWriter: rateLimitedWriter{ctx: f.ctx, writer: writer, bucket: bucket},
+		closer:            writer,
+	}, nil
+}
+
+// op takes a token for one call of method
+func (f RateLimitedFileIo) op(method, path string) error {
+	bucket := f.ops[MethodClassOf(method)]
+	if bucket == nil {
+		return nil
+	}
+
+	if err := bucket.Wait(f.ctx, 1); err != nil {
+		return NewFileIoError(BackendRateLimit, method, path, err)
+	}
+
+	return nil
+}
+
+// transfer takes a token for every byte moved by a call of method
+func (f RateLimitedFileIo) transfer(method, path string, size int) error {
+	bucket := f.bytes[MethodClassOf(method)]
+	if bucket == nil {
+		return nil
+	}
+
+	if err := bucket.Wait(f.ctx, int64(size)); err != nil {
+		return NewFileIoError(BackendRateLimit, method, path, err)
+	}
+
+	return nil
+}
+
+// copyFile streams source to destination through the read and write byte
+// limits, keeping the source permissions. A bucket shared by both classes is
+// only charged once per byte.
+func (f RateLimitedFileIo) copyFile(source, destination string) error {
+	info, err := f.fileIo.FileInfo(source)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return NewFileIoLinkError(BackendRateLimit, "CopyFile", source, destination, ErrIsDirectory)
+	}
+
+	reader, err := OpenReader(f.fileIo, source)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	writer, err := CreateWriter(f.fileIo, destination, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	var limitedReader io.Reader = reader
+	if bucket := f.bytes[MethodClassRead]; bucket != nil {
+		limitedReader = NewRateLimitedReader(f.ctx, reader, bucket)
+	}
+
+	var limitedWriter io.Writer = writer
+	if bucket := f.bytes[MethodClassWrite]; bucket != nil && bucket != f.bytes[MethodClassRead] {
+		limitedWriter = NewRateLimitedWriter(f.ctx, writer, bucket)
+	}
+
+	if _, err := io.Copy(limitedWriter, limitedReader); err != nil {
+		writer.Close()
+		return NewFileIoLinkError(BackendRateLimit, "CopyFile", source, destination, err)
+	}
+
+	return writer.Close()
+}
+
+// copyDir copies source into destination file by file through copyFile
+func (f RateLimitedFileIo) copyDir(source, destination string) error {
+	info, err := f.fileIo.FileInfo(source)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return NewFileIoLinkError(BackendRateLimit, "CopyDir", source, destination, ErrNotDirectory)
+	}
+
+	if err := f.fileIo.CreateDirAll(destination, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	entries, err := f.fileIo.ReadDir(source)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		sourcePath := f.fileIo.JoinPath(source, entry.Name())
+		destinationPath := f.fileIo.JoinPath(destination, entry.Name())
+		if entry.IsDir() {
+			err = f.copyDir(sourcePath, destinationPath)
+		} else {
+			err = f.copyFile(sourcePath, destinationPath)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func classesOrAll(classes []MethodClass) []MethodClass {
+	if len(classes) == 0 {
+		return []MethodClass{MethodClassRead, MethodClassWrite, MethodClassDelete}
+	}
+
+	return classes
+}
+
+type rateLimitedReader struct {
+	ctx    context.Context
+	reader io.Reader
+	bucket *TokenBucket
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	if burst := int(r.bucket.Burst()); len(p) > burst {
+		p = p[:burst]
+	}
+
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		if waitErr := r.bucket.Wait(r.ctx, int64(n)); waitErr != nil {
+			return n, waitErr
+		}
+	}
+
+	return n, err
+}
+
+type rateLimitedWriter struct {
+	ctx    context.Context
+	writer io.Writer
+	bucket *TokenBucket
+}
+
+func (w *rateLimitedWriter) Write(p []byte) (int, error) {
+	burst := int(w.bucket.Burst())
+	written := 0
+	for written < len(p) {
+		chunk := p[written:min(written+burst, len(p))]
+		if err := w.bucket.Wait(w.ctx, int64(len(chunk))); err != nil {
+			return written, err
+		}
+
+		n, err := w.writer.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
+
+type rateLimitedReadCloser struct {
+	rateLimitedReader
+	closer io.Closer
+}
+
+func (r *rateLimitedReadCloser) Close() error {
+	return r.closer.Close()
+}
+
+type rateLimitedWriteCloser struct {
+	rateLimitedWriter
+	closer io.Closer
+}
+
+func (w *rateLimitedWriteCloser) Close() error {
+	return w.closer.Close()
+}
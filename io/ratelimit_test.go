@@ -0,0 +1,219 @@
+package io
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeBucketClock drives a TokenBucket without sleeping, every wait moves
+// the clock forward and is recorded
+type fakeBucketClock struct {
+	mu      sync.Mutex
+	current time.Time
+	waits   []time.Duration
+}
+
+func newTestBucket(rate float64, burst int64) (*TokenBucket, *fakeBucketClock) {
+	clock := &fakeBucketClock{current: time.Unix(0, 0)}
+	bucket := NewTokenBucket(rate, burst)
+	bucket.now = func() time.Time {
+		clock.mu.Lock()
+		defer clock.mu.Unlock()
+		return clock.current
+	}
+	bucket.last = bucket.now()
+	bucket.sleep = func(ctx context.Context, delay time.Duration) error {
+		clock.mu.Lock()
+		defer clock.mu.Unlock()
+		clock.waits = append(clock.waits, delay)
+		clock.current = clock.current.Add(delay)
+		return ctx.Err()
+	}
+
+	return bucket, clock
+}
+
+func (c *fakeBucketClock) total() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var total time.Duration
+	for _, wait := range c.waits {
+		total += wait
+	}
+	return total
+}
+
+func TestTokenBucket(t *testing.T) {
+	bucket, clock := newTestBucket(10, 5)
+	ctx := context.Background()
+
+	assert.NoError(t, bucket.Wait(ctx, 5))
+	assert.Empty(t, clock.waits)
+	assert.False(t, bucket.TryTake(1))
+
+	assert.NoError(t, bucket.Wait(ctx, 2))
+	assert.Equal(t, []time.Duration{200 * time.Millisecond}, clock.waits)
+
+	assert.NoError(t, bucket.Wait(ctx, 20))
+	assert.Equal(t, 2200*time.Millisecond, clock.total())
+
+	clock.current = clock.current.Add(time.Hour)
+	assert.True(t, bucket.TryTake(5))
+	assert.False(t, bucket.TryTake(1))
+
+	unlimited := NewTokenBucket(0, 1)
+	assert.NoError(t, unlimited.Wait(ctx, 1<<40))
+	assert.True(t, unlimited.TryTake(1<<40))
+}
+
+func TestTokenBucket_Context(t *testing.T) {
+	bucket := NewTokenBucket(1, 1)
+	assert.True(t, bucket.TryTake(1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	assert.ErrorIs(t, bucket.Wait(ctx, 100), context.DeadlineExceeded)
+	assert.Less(t, time.Since(started), time.Second)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, bucket.Wait(cancelled, 1), context.Canceled)
+}
+
+func TestRateLimitedReaderWriter(t *testing.T) {
+	bucket, clock := newTestBucket(100, 10)
+	ctx := context.Background()
+
+	var output bytes.Buffer
+	writer := NewRateLimitedWriter(ctx, &output, bucket)
+	n, err := writer.Write(bytes.Repeat([]byte("x"), 35))
+	assert.NoError(t, err)
+	assert.Equal(t, 35, n)
+	assert.Equal(t, 35, output.Len())
+	assert.Equal(t, 250*time.Millisecond, clock.total())
+
+	reader := NewRateLimitedReader(ctx, strings.NewReader(strings.Repeat("y", 20)), bucket)
+	buffer := make([]byte, 64)
+	n, err = reader.Read(buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, n)
+	content, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Len(t, content, 10)
+	assert.Equal(t, 450*time.Millisecond, clock.total())
+}
+
+func TestRateLimitedFileIo_Bytes(t *testing.T) {
+	memory := NewMemoryFileIo()
+	bucket, clock := newTestBucket(1000, 100)
+	limited := NewRateLimitedFileIo(memory, WithByteLimit(bucket))
+
+	assert.NoError(t, limited.WriteFile("/file.txt", bytes.Repeat([]byte("a"), 600), 0o644))
+	assert.Equal(t, 500*time.Millisecond, clock.total())
+
+	content, err := limited.ReadFile("/file.txt")
+	assert.NoError(t, err)
+	assert.Len(t, content, 600)
+	assert.Equal(t, 1100*time.Millisecond, clock.total())
+
+	assert.NoError(t, memory.CreateDirAll("/backup/src/sub", 0o755))
+	assert.NoError(t, memory.WriteFile("/backup/src/a.txt", bytes.Repeat([]byte("b"), 200), 0o640))
+	assert.NoError(t, memory.WriteFile("/backup/src/sub/b.txt", bytes.Repeat([]byte("c"), 300), 0o644))
+	assert.NoError(t, limited.CopyDir("/backup/src", "/backup/dst"))
+	assert.Equal(t, 1600*time.Millisecond, clock.total())
+
+	copied, err := memory.ReadFile("/backup/dst/sub/b.txt")
+	assert.NoError(t, err)
+	assert.Len(t, copied, 300)
+	info, err := memory.FileInfo("/backup/dst/a.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "-rw-r-----", info.Mode().String())
+
+	assert.NoError(t, limited.CopyFile("/file.txt", "/copy.txt"))
+	assert.Equal(t, 2200*time.Millisecond, clock.total())
+
+	checksum, err := limited.Checksum("/copy.txt", ChecksumSHA256)
+	assert.NoError(t, err)
+	expected, _ := memory.Checksum("/file.txt", ChecksumSHA256)
+	assert.Equal(t, expected, checksum)
+	assert.Equal(t, 2800*time.Millisecond, clock.total())
+}
+
+func TestRateLimitedFileIo_Classes(t *testing.T) {
+	memory := NewMemoryFileIo()
+	writes, writeClock := newTestBucket(100, 10)
+	ops, opsClock := newTestBucket(1, 2)
+	limited := NewRateLimitedFileIo(memory,
+		WithByteLimit(writes, MethodClassWrite),
+		WithOpLimit(ops, MethodClassDelete),
+	)
+
+	writer, err := limited.CreateFile("/stream.txt", 0o644)
+	assert.NoError(t, err)
+	_, err = writer.Write(bytes.Repeat([]byte("s"), 30))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+	assert.Equal(t, 200*time.Millisecond, writeClock.total())
+
+	reader, err := limited.OpenFile("/stream.txt")
+	assert.NoError(t, err)
+	content, _ := io.ReadAll(reader)
+	assert.NoError(t, reader.Close())
+	assert.Len(t, content, 30)
+	assert.Equal(t, 200*time.Millisecond, writeClock.total())
+
+	for _, name := range []string{"/a", "/b", "/c"} {
+		assert.NoError(t, memory.WriteFile(name, []byte("x"), 0o644))
+		assert.NoError(t, limited.DeleteFile(name))
+	}
+	assert.Equal(t, time.Second, opsClock.total())
+	assert.True(t, limited.FileExists("/stream.txt"))
+	assert.Equal(t, time.Second, opsClock.total())
+}
+
+func TestRateLimitedFileIo_CopyReadLimit(t *testing.T) {
+	memory := NewMemoryFileIo()
+	reads, readClock := newTestBucket(1000, 100)
+	writes, writeClock := newTestBucket(100, 100)
+	assert.NoError(t, memory.CreateDirAll("/src/sub", 0o755))
+	assert.NoError(t, memory.WriteFile("/src/a.txt", bytes.Repeat([]byte("a"), 200), 0o644))
+	assert.NoError(t, memory.WriteFile("/src/sub/b.txt", bytes.Repeat([]byte("b"), 300), 0o644))
+
+	readLimited := NewRateLimitedFileIo(memory, WithByteLimit(reads, MethodClassRead))
+	assert.NoError(t, readLimited.CopyDir("/src", "/dst"))
+	assert.Equal(t, 400*time.Millisecond, readClock.total())
+	assert.NoError(t, readLimited.CopyFile("/src/a.txt", "/copy.txt"))
+	assert.Equal(t, 600*time.Millisecond, readClock.total())
+	copied, err := memory.ReadFile("/dst/sub/b.txt")
+	assert.NoError(t, err)
+	assert.Len(t, copied, 300)
+
+	bothLimited := NewRateLimitedFileIo(memory,
+		WithByteLimit(reads, MethodClassRead),
+		WithByteLimit(writes, MethodClassWrite),
+	)
+	assert.NoError(t, bothLimited.CopyFile("/src/sub/b.txt", "/both.txt"))
+	assert.Equal(t, 900*time.Millisecond, readClock.total())
+	assert.Equal(t, 2*time.Second, writeClock.total())
+}
+
+func TestRateLimitedFileIo_Context(t *testing.T) {
+	ops := NewTokenBucket(0.001, 1)
+	limited := NewRateLimitedFileIo(NewMemoryFileIo(), WithOpLimit(ops))
+	assert.NoError(t, limited.CreateDir("/dir", 0o755))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := limited.WithContext(ctx).CreateDir("/other", 0o755)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, BackendRateLimit, err.(*FileIoError).Backend)
+}
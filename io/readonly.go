@@ -0,0 +1,117 @@
+package io
+
+import (
+	"io/fs"
+	"os"
+)
+
+// ReadOnlyFileIo wraps a FileIo and only lets reads through, every operation
+// that would create, modify or delete something fails with an ErrReadOnly
+// FileIoError before reaching the wrapped FileIo
+type ReadOnlyFileIo struct {
+	fileIo FileIo
+}
+
+// NewReadOnlyFileIo returns a FileIo that can read from fileIo but never
+// modify it
+func NewReadOnlyFileIo(fileIo FileIo) ReadOnlyFileIo {
+	return ReadOnlyFileIo{
+		fileIo: fileIo,
+	}
+}
+
+func (f ReadOnlyFileIo) GetOperatingSystem() OperatingSystem {
+	return f.fileIo.GetOperatingSystem()
+}
+
+func (f ReadOnlyFileIo) FileExists(path string) bool {
+	return f.fileIo.FileExists(path)
+}
+
+func (f ReadOnlyFileIo) DirExists(folderPath string) bool {
+	return f.fileIo.DirExists(folderPath)
+}
+
+func (f ReadOnlyFileIo) CreateDir(folderPath string, mode fs.FileMode) error {
+	return NewFileIoError(BackendReadOnly, "CreateDir", folderPath, ErrReadOnly)
+}
+
+func (f ReadOnlyFileIo) CreateDirAll(folderPath string, mode fs.FileMode, parentMode ...fs.FileMode) error {
+	return NewFileIoError(BackendReadOnly, "CreateDirAll", folderPath, ErrReadOnly)
+}
+
+func (f ReadOnlyFileIo) EnsureDir(folderPath string, mode fs.FileMode) error {
+	return NewFileIoError(BackendReadOnly, "EnsureDir", folderPath, ErrReadOnly)
+}
+
+func (f ReadOnlyFileIo) GetExecutionPath() string {
+	return f.fileIo.GetExecutionPath()
+}
+
+func (f ReadOnlyFileIo) ToOsPath(path string) string {
+	return f.fileIo.ToOsPath(path)
+}
+
+func (f ReadOnlyFileIo) GetOsPathSeparator() string {
+	return f.fileIo.GetOsPathSeparator()
+}
+
+func (f ReadOnlyFileIo) ReadFile(path string) ([]byte, error) {
+	return f.fileIo.ReadFile(path)
+}
+
+func (f ReadOnlyFileIo) ReadBufferedFile(path string, from, to int) ([]byte, error) {
+	return f.fileIo.ReadBufferedFile(path, from, to)
+}
+
+func (f ReadOnlyFileIo) WriteFile(path string, data []byte, mode os.FileMode) error {
+	return NewFileIoError(BackendReadOnly, "WriteFile", path, ErrReadOnly)
+}
+
+func (f ReadOnlyFileIo) WriteBufferedFile(path string, data []byte, bufferSize int, mode os.FileMode) error {
+	return NewFileIoError(BackendReadOnly, "WriteBufferedFile", path, ErrReadOnly)
+}
+
+func (f ReadOnlyFileIo) ReadDir(path string) ([]fs.DirEntry, error) {
+	return f.fileIo.ReadDir(path)
+}
+
+func (f ReadOnlyFileIo) JoinPath(parts ...string) string {
+	return f.fileIo.JoinPath(parts...)
+}
+
+func (f ReadOnlyFileIo) CopyFile(source, destination string) error {
+	return NewFileIoLinkError(BackendReadOnly, "CopyFile", source, destination, ErrReadOnly)
+}
+
+func (f ReadOnlyFileIo) DeleteFile(path string) error {
+	return NewFileIoError(BackendReadOnly, "DeleteFile", path, ErrReadOnly)
+}
+
+func (f ReadOnlyFileIo) Move(source, destination string, policy ...OverwritePolicy) error {
+	return NewFileIoLinkError(BackendReadOnly, "Move", source, destination, ErrReadOnly)
+}
+
+func (f ReadOnlyFileIo) CopyDir(source, destination string) error {
+	return NewFileIoLinkError(BackendReadOnly, "CopyDir", source, destination, ErrReadOnly)
+}
+
+func (f ReadOnlyFileIo) DeleteDir(path string) error {
+	return NewFileIoError(BackendReadOnly, "DeleteDir", path, ErrReadOnly)
+}
+
+func (f ReadOnlyFileIo) Checksum(path string, method ChecksumMethod) (string, error) {
+	return f.fileIo.Checksum(path, method)
+}
+
+func (f ReadOnlyFileIo) FileInfo(path string) (os.FileInfo, error) {
+	return f.fileIo.FileInfo(path)
+}
+
+func (f ReadOnlyFileIo) TempDir(dir, pattern string) (string, CleanupFunc, error) {
+	return "", nil, NewFileIoError(BackendReadOnly, "TempDir", dir, ErrReadOnly)
+}
+
+func (f ReadOnlyFileIo) TempFile(dir, pattern string) (string, CleanupFunc, error) {
+	return "", nil, NewFileIoError(BackendReadOnly, "TempFile", dir, ErrReadOnly)
+}
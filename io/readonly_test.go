@@ -0,0 +1,80 @@
+package io
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadOnlyFileIo(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file.txt")
+	assert.NoError(t, os.WriteFile(file, []byte("content"), 0o644))
+	readOnly := NewReadOnlyFileIo(Default())
+
+	t.Run("Reads Pass Through", func(t *testing.T) {
+		content, err := readOnly.ReadFile(file)
+		assert.NoError(t, err)
+		assert.Equal(t, "content", string(content))
+
+		entries, err := readOnly.ReadDir(dir)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+
+		checksum, err := readOnly.Checksum(file, ChecksumMD5)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, checksum)
+
+		info, err := readOnly.FileInfo(file)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), info.Size())
+
+		assert.True(t, readOnly.FileExists(file))
+		assert.True(t, readOnly.DirExists(dir))
+	})
+
+	t.Run("Writes Are Rejected", func(t *testing.T) {
+		target := filepath.Join(dir, "other")
+		writes := map[string]func() error{
+			"CreateDir":         func() error { return readOnly.CreateDir(target, 0o755) },
+			"CreateDirAll":      func() error { return readOnly.CreateDirAll(target, 0o755) },
+			"EnsureDir":         func() error { return readOnly.EnsureDir(dir, 0o700) },
+			"WriteFile":         func() error { return readOnly.WriteFile(file, []byte("x"), 0o644) },
+			"WriteBufferedFile": func() error { return readOnly.WriteBufferedFile(file, []byte("x"), 1, 0o644) },
+			"CopyFile":          func() error { return readOnly.CopyFile(file, target) },
+			"CopyDir":           func() error { return readOnly.CopyDir(dir, target) },
+			"Move":              func() error { return readOnly.Move(file, target) },
+			"DeleteFile":        func() error { return readOnly.DeleteFile(file) },
+			"DeleteDir":         func() error { return readOnly.DeleteDir(dir) },
+			"TempDir": func() error {
+				_, _, err := readOnly.TempDir(dir, "tmp")
+				return err
+			},
+			"TempFile": func() error {
+				_, _, err := readOnly.TempFile(dir, "tmp")
+				return err
+			},
+		}
+
+		for op, write := range writes {
+			t.Run(op, func(t *testing.T) {
+				err := write()
+				assert.ErrorIs(t, err, ErrReadOnly)
+				assert.ErrorIs(t, err, fs.ErrPermission)
+
+				var fileIoErr *FileIoError
+				assert.True(t, errors.As(err, &fileIoErr))
+				assert.Equal(t, op, fileIoErr.Op)
+				assert.Equal(t, BackendReadOnly, fileIoErr.Backend)
+			})
+		}
+
+		content, _ := os.ReadFile(file)
+		assert.Equal(t, "content", string(content))
+		assert.NoDirExists(t, target)
+	})
+}
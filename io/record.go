@@ -0,0 +1,287 @@
+package io
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// RecordingFileIo wraps a FileIo, usually Default(), and captures every call
+// with its arguments and results in a Cassette. Path helpers like JoinPath
+// are not recorded, the cassette keeps the operating system they depend on
+// instead. Streams are recorded whole: OpenFile reads the file upfront and
+// CreateFile is recorded with the content written once the writer is
+// closed.
+type RecordingFileIo struct {
+	fileIo FileIo
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecordingFileIo returns a FileIo recording the calls made to fileIo
+func NewRecordingFileIo(fileIo FileIo) *RecordingFileIo {
+	return &RecordingFileIo{
+		fileIo: fileIo,
+		cassette: Cassette{
+			Version:         CassetteVersion,
+			OperatingSystem: fileIo.GetOperatingSystem(),
+			ExecutionPath:   fileIo.GetExecutionPath(),
+			Calls:           []CassetteCall{},
+		},
+	}
+}
+
+// Cassette returns a copy of the calls recorded so far
+func (f *RecordingFileIo) Cassette() *Cassette {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	cassette := f.cassette
+	cassette.Calls = append([]CassetteCall{}, f.cassette.Calls...)
+	return &cassette
+}
+
+func (f *RecordingFileIo) GetOperatingSystem() OperatingSystem {
+	return f.fileIo.GetOperatingSystem()
+}
+
+func (f *RecordingFileIo) FileExists(path string) bool {
+	exists := f.fileIo.FileExists(path)
+	f.record("FileExists", CassetteResult{Bool: exists}, path)
+	return exists
+}
+
+func (f *RecordingFileIo) DirExists(folderPath string) bool {
+	exists := f.fileIo.DirExists(folderPath)
+	f.record("DirExists", CassetteResult{Bool: exists}, folderPath)
+	return exists
+}
+
+func (f *RecordingFileIo) CreateDir(folderPath string, mode fs.FileMode) error {
+	err := f.fileIo.CreateDir(folderPath, mode)
+	f.record("CreateDir", CassetteResult{Error: newCassetteError(err)}, folderPath, mode)
+	return err
+}
+
+func (f *RecordingFileIo) CreateDirAll(folderPath string, mode fs.FileMode, parentMode ...fs.FileMode) error {
+	err := f.fileIo.CreateDirAll(folderPath, mode, parentMode...)
+	f.record("CreateDirAll", CassetteResult{Error: newCassetteError(err)}, folderPath, mode, parentMode)
+	return err
+}
+
+func (f *RecordingFileIo) EnsureDir(folderPath string, mode fs.FileMode) error {
+	err := f.fileIo.EnsureDir(folderPath, mode)
+	f.record("EnsureDir", CassetteResult{Error: newCassetteError(err)}, folderPath, mode)
+	return err
+}
+
+func (f *RecordingFileIo) GetExecutionPath() string {
+	return f.fileIo.GetExecutionPath()
+}
+
+func (f *RecordingFileIo) ToOsPath(path string) string {
+	return f.fileIo.ToOsPath(path)
+}
+
+func (f *RecordingFileIo) GetOsPathSeparator() string {
+	return f.fileIo.GetOsPathSeparator()
+}
+
+func (f *RecordingFileIo) ReadFile(path string) ([]byte, error) {
+	content, err := f.fileIo.ReadFile(path)
+	f.record("ReadFile", CassetteResult{Data: content, Error: newCassetteError(err)}, path)
+	return content, err
+}
+
+func (f *RecordingFileIo) ReadBufferedFile(path string, from, to int) ([]byte, error) {
+	content, err := f.fileIo.ReadBufferedFile(path, from, to)
+	f.record("ReadBufferedFile", CassetteResult{Data: content, Error: newCassetteError(err)}, path, from, to)
+	return content, err
+}
+
+func (f *RecordingFileIo) WriteFile(path string, data []byte, mode os.FileMode) error {
+	err := f.fileIo.WriteFile(path, data, mode)
+	f.record("WriteFile", CassetteResult{Error: newCassetteError(err)}, path, data, mode)
+	return err
+}
+
+func (f *RecordingFileIo) WriteBufferedFile(path string, data []byte, bufferSize int, mode os.FileMode) error {
+	err := f.fileIo.WriteBufferedFile(path, data, bufferSize, mode)
+	f.record("WriteBufferedFile", CassetteResult{Error: newCassetteError(err)}, path, data, bufferSize, mode)
+	return err
+}
+
+func (f *RecordingFileIo) ReadDir(path string) ([]fs.DirEntry, error) {
+	entries, err := f.fileIo.ReadDir(path)
+
+	result := CassetteResult{Error: newCassetteError(err)}
+	for _, entry := range entries {
+		info, infoErr := entry.Info()
+		if infoErr != nil {
+			result.Entries = append(result.Entries, CassetteFileInfo{FileName: entry.Name(), FileMode: entry.Type()})
+			continue
+		}
+		result.Entries = append(result.Entries, *newCassetteFileInfo(info))
+	}
+
+	f.record("ReadDir", result, path)
+	return entries, err
+}
+
+func (f *RecordingFileIo) JoinPath(parts ...string) string {
+	return f.fileIo.JoinPath(parts...)
+}
+
+func (f *RecordingFileIo) CopyFile(source, destination string) error {
+	err := f.fileIo.CopyFile(source, destination)
+	f.record("CopyFile", CassetteResult{Error: newCassetteError(err)}, source, destination)
+	return err
+}
+
+func (f *RecordingFileIo) DeleteFile(path string) error {
+	err := f.fileIo.DeleteFile(path)
+	f.record("DeleteFile", CassetteResult{Error: newCassetteError(err)}, path)
+	return err
+}
+
+func (f *RecordingFileIo) Move(source, destination string, policy ...OverwritePolicy) error {
+	err := f.fileIo.Move(source, destination, policy...)
+	f.record("Move", CassetteResult{Error: newCassetteError(err)}, source, destination, policy)
+	return err
+}
+
+func (f *RecordingFileIo) CopyDir(source, destination string) error {
+	err := f.fileIo.CopyDir(source, destination)
+	f.record("CopyDir", CassetteResult{Error: newCassetteError(err)}, source, destination)
+	return err
+}
+
+func (f *RecordingFileIo) DeleteDir(path string) error {
+	err := f.fileIo.DeleteDir(path)
+	f.record("DeleteDir", CassetteResult{Error: newCassetteError(err)}, path)
+	return err
+}
+
+func (f *RecordingFileIo) Checksum(path string, method ChecksumMethod) (string, error) {
+	checksum, err := f.fileIo.Checksum(path, method)
+	f.record("Checksum", CassetteResult{String: checksum, Error: newCassetteError(err)}, path, method)
+	return checksum, err
+}
+
+func (f *RecordingFileIo) FileInfo(path string) (os.FileInfo, error) {
+	info, err := f.fileIo.FileInfo(path)
+	f.record("FileInfo", CassetteResult{Info: newCassetteFileInfo(info), Error: newCassetteError(err)}, path)
+	return info, err
+}
+
+func (f *RecordingFileIo) TempDir(dir, pattern string) (string, CleanupFunc, error) {
+	path, cleanup, err := f.fileIo.TempDir(dir, pattern)
+	f.record("TempDir", CassetteResult{String: path, Error: newCassetteError(err)}, dir, pattern)
+	return path, f.recordCleanup(path, cleanup), err
+}
+
+func (f *RecordingFileIo) TempFile(dir, pattern string) (string, CleanupFunc, error) {
+	path, cleanup, err := f.fileIo.TempFile(dir, pattern)
+	f.record("TempFile", CassetteResult{String: path, Error: newCassetteError(err)}, dir, pattern)
+	return path, f.recordCleanup(path, cleanup), err
+}
+
+// OpenFile reads the whole of path to record it and serves it from memory
+func (f *RecordingFileIo) OpenFile(path string) (io.ReadCloser, error) {
+	content, err := readAll(BackendRecord, f.fileIo, path)
+	f.record("OpenFile", CassetteResult{Data: content, Error: newCassetteError(err)}, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+// CreateFile creates path on the wrapped FileIo, the call is recorded with
+// everything written once the writer is closed
+func (f *RecordingFileIo) CreateFile(path string, mode fs.FileMode) (io.WriteCloser, error) {
+	writer, err := CreateWriter(f.fileIo, path, mode)
+	if err != nil {
+		f.record("CreateFile", CassetteResult{Error: newCassetteError(err)}, path, mode)
+		return nil, err
+	}
+
+	return &recordingWriter{
+		writer: writer,
+		close: func(content []byte, err error) {
+			f.record("CreateFile", CassetteResult{Bool: true, Data: content, CloseError: newCassetteError(err)}, path, mode)
+		},
+	}, nil
+}
+
+func (f *RecordingFileIo) record(method string, result CassetteResult, args ...any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if result.Data != nil {
+		result.Data = append([]byte{}, result.Data...)
+	}
+
+	f.cassette.Calls = append(f.cassette.Calls, CassetteCall{
+		Method: method,
+		Args:   encodeArgs(args...),
+		Result: result,
+	})
+}
+
+func (f *RecordingFileIo) recordCleanup(path string, cleanup CleanupFunc) CleanupFunc {
+	if cleanup == nil {
+		return nil
+	}
+
+	return func() error {
+		err := cleanup()
+		f.record("Cleanup", CassetteResult{Error: newCassetteError(err)}, path)
+		return err
+	}
+}
+
+// readAll reads path on fileIo through its stream when it has one, a failed
+// read is reported under backend
+func readAll(backend string, fileIo FileIo, path string) ([]byte, error) {
+	reader, err := OpenReader(fileIo, path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, NewFileIoError(backend, "OpenFile", path, err)
+	}
+
+	return content, nil
+}
+
+// recordingWriter keeps a copy of everything written and reports it with the
+// close error
+type recordingWriter struct {
+	writer io.WriteCloser
+	buffer bytes.Buffer
+	close  func(content []byte, err error)
+	closed bool
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.buffer.Write(p[:n])
+	return n, err
+}
+
+func (w *recordingWriter) Close() error {
+	err := w.writer.Close()
+	if !w.closed {
+		w.closed = true
+		w.close(w.buffer.Bytes(), err)
+	}
+
+	return err
+}
@@ -0,0 +1,163 @@
+package io
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// copyConfig is the code under test of the record and replay tests
+func copyConfig(fileIo FileIo, source, destination string) (string, error) {
+	if !fileIo.FileExists(source) {
+		return "", fmt.Errorf("missing %s", source)
+	}
+
+	content, err := fileIo.ReadFile(source)
+	if err != nil {
+		return "", err
+	}
+
+	if err := fileIo.CreateDirAll(fileIo.JoinPath(destination, "conf"), 0o755); err != nil {
+		return "", err
+	}
+
+	target := fileIo.JoinPath(destination, "conf", "app.yaml")
+	if err := fileIo.WriteFile(target, append(content, "\ncopied: true"...), 0o644); err != nil {
+		return "", err
+	}
+
+	return fileIo.Checksum(target, ChecksumSHA256)
+}
+
+type recordingReporter struct {
+	messages []string
+}
+
+func (r *recordingReporter) Errorf(format string, args ...any) {
+	r.messages = append(r.messages, fmt.Sprintf(format, args...))
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	writeTestTree(t, dir, map[string]string{"app.yaml": "name: app"})
+	source := filepath.Join(dir, "app.yaml")
+	destination := filepath.Join(dir, "out")
+
+	recorder := NewRecordingFileIo(Default())
+	expected, err := copyConfig(recorder, source, destination)
+	assert.NoError(t, err)
+	_, err = recorder.ReadFile(filepath.Join(dir, "missing.yaml"))
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	cassettePath := filepath.Join(dir, "cassette.json")
+	assert.NoError(t, recorder.Cassette().Save(Default(), cassettePath))
+	cassette, err := LoadCassette(Default(), cassettePath)
+	assert.NoError(t, err)
+	assert.Equal(t, CassetteVersion, cassette.Version)
+	assert.Len(t, cassette.Calls, 6)
+	assert.Equal(t, "FileExists", cassette.Calls[0].Method)
+
+	assert.NoError(t, Default().DeleteDir(dir))
+
+	reporter := &recordingReporter{}
+	replay := NewReplayFileIo(cassette, WithReplayReporter(reporter))
+	checksum, err := copyConfig(replay, source, destination)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, checksum)
+
+	_, err = replay.ReadFile(filepath.Join(dir, "missing.yaml"))
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	var fileIoErr *FileIoError
+	assert.True(t, errors.As(err, &fileIoErr))
+	assert.Equal(t, BackendDefault, fileIoErr.Backend)
+	assert.Equal(t, "ReadFile", fileIoErr.Op)
+
+	assert.Empty(t, reporter.messages)
+	assert.NoError(t, replay.Verify())
+	assert.Equal(t, Default().JoinPath("a", "b"), replay.JoinPath("a", "b"))
+}
+
+func TestReplayFileIo_UnexpectedCalls(t *testing.T) {
+	memory := NewMemoryFileIo()
+	recorder := NewRecordingFileIo(memory)
+	assert.NoError(t, recorder.WriteFile("/file.txt", []byte("one"), 0o644))
+	assert.NoError(t, recorder.WriteFile("/file.txt", []byte("two"), 0o644))
+	_, _ = recorder.ReadFile("/file.txt")
+	_, _ = recorder.ReadFile("/file.txt")
+
+	reporter := &recordingReporter{}
+	replay := NewReplayFileIo(recorder.Cassette(), WithReplayReporter(reporter))
+
+	err := replay.WriteFile("/file.txt", []byte("three"), 0o644)
+	assert.ErrorIs(t, err, ErrUnexpectedCall)
+	assert.Equal(t, BackendReplay, err.(*FileIoError).Backend)
+	assert.False(t, replay.FileExists("/file.txt"))
+
+	assert.NoError(t, replay.WriteFile("/file.txt", []byte("two"), 0o644))
+	assert.NoError(t, replay.WriteFile("/file.txt", []byte("one"), 0o644))
+	content, err := replay.ReadFile("/file.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "two", string(content))
+
+	assert.Len(t, reporter.messages, 2)
+	assert.Contains(t, reporter.messages[0], `WriteFile("/file.txt","dGhyZWU=",420)`)
+	assert.Equal(t, []string{`WriteFile("/file.txt","dGhyZWU=",420)`, `FileExists("/file.txt")`}, replay.Unexpected())
+	assert.Len(t, replay.Remaining(), 1)
+
+	err = replay.Verify()
+	assert.ErrorContains(t, err, `unexpected FileExists("/file.txt")`)
+	assert.ErrorContains(t, err, `not replayed ReadFile("/file.txt")`)
+}
+
+func TestRecordAndReplay_Streams(t *testing.T) {
+	memory := NewMemoryFileIo()
+	assert.NoError(t, memory.WriteFile("/in.txt", []byte("input"), 0o644))
+	recorder := NewRecordingFileIo(memory)
+
+	assert.NoError(t, CopyStream(recorder, "/in.txt", recorder, "/out.txt"))
+	path, cleanup, err := recorder.TempFile("/", "tmp-*")
+	assert.NoError(t, err)
+	assert.NoError(t, cleanup())
+
+	replay := NewReplayFileIo(recorder.Cassette())
+	assert.NoError(t, CopyStream(replay, "/in.txt", replay, "/out.txt"))
+	replayedPath, replayedCleanup, err := replay.TempFile("/", "tmp-*")
+	assert.NoError(t, err)
+	assert.Equal(t, path, replayedPath)
+	assert.NoError(t, replayedCleanup())
+	assert.NoError(t, replay.Verify())
+
+	replay = NewReplayFileIo(recorder.Cassette())
+	writer, err := replay.CreateFile("/out.txt", 0o644)
+	assert.NoError(t, err)
+	_, _ = io.WriteString(writer, "other")
+	assert.ErrorIs(t, writer.Close(), ErrUnexpectedCall)
+}
+
+func TestRecordAndReplay_CreateFileCloseError(t *testing.T) {
+	memory := NewMemoryFileIo()
+	assert.NoError(t, memory.CreateDir("/dir", 0o755))
+	recorder := NewRecordingFileIo(memory)
+
+	writer, err := recorder.CreateFile("/dir/out.txt", 0o644)
+	assert.NoError(t, err)
+	_, _ = io.WriteString(writer, "content")
+	assert.NoError(t, memory.DeleteDir("/dir"))
+	assert.ErrorIs(t, writer.Close(), fs.ErrNotExist)
+
+	cassette := recorder.Cassette()
+	assert.Nil(t, cassette.Calls[0].Result.Error)
+	assert.NotNil(t, cassette.Calls[0].Result.CloseError)
+
+	replay := NewReplayFileIo(cassette)
+	writer, err = replay.CreateFile("/dir/out.txt", 0o644)
+	assert.NoError(t, err)
+	_, _ = io.WriteString(writer, "content")
+	assert.ErrorIs(t, writer.Close(), fs.ErrNotExist)
+	assert.NoError(t, replay.Verify())
+}
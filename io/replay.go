@@ -0,0 +1,321 @@
+package io
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ReplayReporter receives the unexpected calls of a ReplayFileIo,
+// *testing.T satisfies it
+type ReplayReporter interface {
+	Errorf(format string, args ...any)
+}
+
+// ReplayOption configures a ReplayFileIo
+type ReplayOption func(*ReplayFileIo)
+
+// WithReplayReporter reports every unexpected call to reporter, usually the
+// running test
+func WithReplayReporter(reporter ReplayReporter) ReplayOption {
+	return func(f *ReplayFileIo) {
+		f.reporter = reporter
+	}
+}
+
+// ReplayFileIo serves the calls recorded in a Cassette without touching the
+// file system. A call is answered by the first recorded call with the same
+// method and arguments that was not replayed yet, so identical calls replay
+// in their recorded order while different ones may come in any order. Calls
+// the cassette does not hold fail with ErrUnexpectedCall, or return false or
+// an empty value when the method has no error, and are reported. Path helpers
+// answer for the recorded operating system.
+type ReplayFileIo struct {
+	cassette *Cassette
+	paths    DefaultFileIo
+	reporter ReplayReporter
+
+	mu         sync.Mutex
+	replayed   []bool
+	unexpected []string
+}
+
+// NewReplayFileIo returns a FileIo replaying cassette
+func NewReplayFileIo(cassette *Cassette, options ...ReplayOption) *ReplayFileIo {
+	result := &ReplayFileIo{
+		cassette: cassette,
+		paths:    Default(WithOperatingSystem(cassette.OperatingSystem)),
+		replayed: make([]bool, len(cassette.Calls)),
+	}
+
+	for _, option := range options {
+		option(result)
+	}
+
+	return result
+}
+
+// Unexpected returns the calls the cassette could not answer
+func (f *ReplayFileIo) Unexpected() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]string{}, f.unexpected...)
+}
+
+// Remaining returns the recorded calls that were not replayed
+func (f *ReplayFileIo) Remaining() []CassetteCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var remaining []CassetteCall
+	for i, call := range f.cassette.Calls {
+		if !f.replayed[i] {
+			remaining = append(remaining, call)
+		}
+	}
+
+	return remaining
+}
+
+// Verify returns an error listing the unexpected calls and the recorded
+// calls that were never replayed, nil when the replay matched the cassette
+func (f *ReplayFileIo) Verify() error {
+	var problems []string
+	for _, call := range f.Unexpected() {
+		problems = append(problems, "unexpected "+call)
+	}
+	for _, call := range f.Remaining() {
+		problems = append(problems, "not replayed "+describeCall(call.Method, call.Args))
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("replay does not match cassette:\n%s", strings.Join(problems, "\n"))
+}
+
+func (f *ReplayFileIo) GetOperatingSystem() OperatingSystem {
+	return f.cassette.OperatingSystem
+}
+
+func (f *ReplayFileIo) FileExists(path string) bool {
+	result, _ := f.play("FileExists", path, path)
+	return result.Bool
+}
+
+func (f *ReplayFileIo) DirExists(folderPath string) bool {
+	result, _ := f.play("DirExists", folderPath, folderPath)
+	return result.Bool
+}
+
+func (f *ReplayFileIo) CreateDir(folderPath string, mode fs.FileMode) error {
+	_, err := f.play("CreateDir", folderPath, folderPath, mode)
+	return err
+}
+
+func (f *ReplayFileIo) CreateDirAll(folderPath string, mode fs.FileMode, parentMode ...fs.FileMode) error {
+	_, err := f.play("CreateDirAll", folderPath, folderPath, mode, parentMode)
+	return err
+}
+
+func (f *ReplayFileIo) EnsureDir(folderPath string, mode fs.FileMode) error {
+	_, err := f.play("EnsureDir", folderPath, folderPath, mode)
+	return err
+}
+
+func (f *ReplayFileIo) GetExecutionPath() string {
+	return f.cassette.ExecutionPath
+}
+
+func (f *ReplayFileIo) ToOsPath(path string) string {
+	return f.paths.ToOsPath(path)
+}
+
+func (f *ReplayFileIo) GetOsPathSeparator() string {
+	return f.paths.GetOsPathSeparator()
+}
+
+func (f *ReplayFileIo) ReadFile(path string) ([]byte, error) {
+	result, err := f.play("ReadFile", path, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{}, result.Data...), nil
+}
+
+func (f *ReplayFileIo) ReadBufferedFile(path string, from, to int) ([]byte, error) {
+	result, err := f.play("ReadBufferedFile", path, path, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{}, result.Data...), nil
+}
+
+func (f *ReplayFileIo) WriteFile(path string, data []byte, mode os.FileMode) error {
+	_, err := f.play("WriteFile", path, path, data, mode)
+	return err
+}
+
+func (f *ReplayFileIo) WriteBufferedFile(path string, data []byte, bufferSize int, mode os.FileMode) error {
+	_, err := f.play("WriteBufferedFile", path, path, data, bufferSize, mode)
+	return err
+}
+
+func (f *ReplayFileIo) ReadDir(path string) ([]fs.DirEntry, error) {
+	result, err := f.play("ReadDir", path, path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]fs.DirEntry, 0, len(result.Entries))
+	for _, entry := range result.Entries {
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func (f *ReplayFileIo) JoinPath(parts ...string) string {
+	return f.paths.JoinPath(parts...)
+}
+
+func (f *ReplayFileIo) CopyFile(source, destination string) error {
+	_, err := f.play("CopyFile", source, source, destination)
+	return err
+}
+
+func (f *ReplayFileIo) DeleteFile(path string) error {
+	_, err := f.play("DeleteFile", path, path)
+	return err
+}
+
+func (f *ReplayFileIo) Move(source, destination string, policy ...OverwritePolicy) error {
+	_, err := f.play("Move", source, source, destination, policy)
+	return err
+}
+
+func (f *ReplayFileIo) CopyDir(source, destination string) error {
+	_, err := f.play("CopyDir", source, source, destination)
+	return err
+}
+
+func (f *ReplayFileIo) DeleteDir(path string) error {
+	_, err := f.play("DeleteDir", path, path)
+	return err
+}
+
+func (f *ReplayFileIo) Checksum(path string, method ChecksumMethod) (string, error) {
+	result, err := f.play("Checksum", path, path, method)
+	return result.String, err
+}
+
+func (f *ReplayFileIo) FileInfo(path string) (os.FileInfo, error) {
+	result, err := f.play("FileInfo", path, path)
+	if err != nil {
+		return nil, err
+	}
+	if result.Info == nil {
+		return nil, NewFileIoError(BackendReplay, "FileInfo", path, fs.ErrNotExist)
+	}
+
+	return *result.Info, nil
+}
+
+func (f *ReplayFileIo) TempDir(dir, pattern string) (string, CleanupFunc, error) {
+	result, err := f.play("TempDir", dir, dir, pattern)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return result.String, f.cleanup(result.String), nil
+}
+
+func (f *ReplayFileIo) TempFile(dir, pattern string) (string, CleanupFunc, error) {
+	result, err := f.play("TempFile", dir, dir, pattern)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return result.String, f.cleanup(result.String), nil
+}
+
+func (f *ReplayFileIo) OpenFile(path string) (io.ReadCloser, error) {
+	result, err := f.play("OpenFile", path, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(bytes.NewReader(result.Data)), nil
+}
+
+// CreateFile replays the creation of path, closing the writer fails with
+// ErrUnexpectedCall when the content written differs from the recording
+func (f *ReplayFileIo) CreateFile(path string, mode fs.FileMode) (io.WriteCloser, error) {
+	result, err := f.play("CreateFile", path, path, mode)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Bool {
+		return nil, NewFileIoError(BackendReplay, "CreateFile", path, ErrUnexpectedCall)
+	}
+
+	return &bufferedWriter{
+		close: func(content []byte) error {
+			if !bytes.Equal(content, result.Data) {
+				f.report(fmt.Sprintf("CreateFile(%q) writing %d bytes, %d recorded", path, len(content), len(result.Data)))
+				return NewFileIoError(BackendReplay, "CreateFile", path, ErrUnexpectedCall)
+			}
+			return result.CloseError.err()
+		},
+	}, nil
+}
+
+// play answers a call from the cassette, returning the recorded error or
+// ErrUnexpectedCall
+func (f *ReplayFileIo) play(method, path string, args ...any) (CassetteResult, error) {
+	encoded := encodeArgs(args...)
+
+	f.mu.Lock()
+	for i, call := range f.cassette.Calls {
+		if f.replayed[i] || call.Method != method || !bytes.Equal(call.Args, encoded) {
+			continue
+		}
+
+		f.replayed[i] = true
+		f.mu.Unlock()
+		return call.Result, call.Result.Error.err()
+	}
+	f.mu.Unlock()
+
+	f.report(describeCall(method, encoded))
+	return CassetteResult{}, NewFileIoError(BackendReplay, method, path, ErrUnexpectedCall)
+}
+
+func (f *ReplayFileIo) report(call string) {
+	f.mu.Lock()
+	f.unexpected = append(f.unexpected, call)
+	f.mu.Unlock()
+
+	if f.reporter != nil {
+		f.reporter.Errorf("unexpected FileIo call %s", call)
+	}
+}
+
+func (f *ReplayFileIo) cleanup(path string) CleanupFunc {
+	return func() error {
+		_, err := f.play("Cleanup", path, path)
+		return err
+	}
+}
+
+func describeCall(method string, args []byte) string {
+	return method + "(" + strings.TrimSuffix(strings.TrimPrefix(string(args), "["), "]") + ")"
+}
@@ -0,0 +1,387 @@
+package io
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"math"
+	"math/rand"
+	"os"
+	"syscall"
+	"time"
+)
+
+// RetryOption configures a RetryFileIo
+type RetryOption func(*RetryFileIo)
+
+// WithMaxAttempts sets how many times a call is attempted in total, values
+// below one are ignored
+func WithMaxAttempts(attempts int) RetryOption {
+	return func(f *RetryFileIo) {
+		if attempts > 0 {
+			f.maxAttempts = attempts
+		}
+	}
+}
+
+// WithBackoff waits initial before the first retry and multiplies the wait by
+// multiplier after each retry, never waiting longer than max
+func WithBackoff(initial, max time.Duration, multiplier float64) RetryOption {
+	return func(f *RetryFileIo) {
+		f.initialDelay = initial
+		f.maxDelay = max
+		f.multiplier = multiplier
+	}
+}
+
+// WithJitter randomizes every wait by up to fraction of its value in either
+// direction, fraction is clamped to [0, 1]
+func WithJitter(fraction float64) RetryOption {
+	return func(f *RetryFileIo) {
+		f.jitter = math.Max(0, math.Min(1, fraction))
+	}
+}
+
+// WithRetryable replaces IsTransientError as the predicate deciding which
+// errors are retried
+func WithRetryable(retryable func(err error) bool) RetryOption {
+	return func(f *RetryFileIo) {
+		f.retryable = retryable
+	}
+}
+
+// WithRetryWrites also retries operations that are not idempotent, like
+// Move, CreateDir or DeleteFile, a retried call may then fail because its
+// first attempt partially succeeded
+func WithRetryWrites() RetryOption {
+	return func(f *RetryFileIo) {
+		f.retryWrites = true
+	}
+}
+
+// RetryFileIo wraps a FileIo and retries the calls failing with a retryable
+// error, waiting with exponential backoff and jitter between attempts. Only
+// idempotent operations are retried unless WithRetryWrites is given. Waits
+// stop as soon as the context set with WithContext is done.
+type RetryFileIo struct {
+	fileIo       FileIo
+	ctx          context.Context
+	maxAttempts  int
+	initialDelay time.Duration
+	maxDelay     time.Duration
+	multiplier   float64
+	jitter       float64
+	retryable    func(err error) bool
+	retryWrites  bool
+	random       func() float64
+	sleep        func(ctx context.Context, delay time.Duration) error
+}
+
+// NewRetryFileIo returns a FileIo retrying the calls made to fileIo, by
+// default three attempts waiting 50ms then 100ms, with 20% jitter
+func NewRetryFileIo(fileIo FileIo, options ...RetryOption) RetryFileIo {
+	result := RetryFileIo{
+		fileIo:       fileIo,
+		ctx:          context.Background(),
+		maxAttempts:  3,
+		initialDelay: 50 * time.Millisecond,
+		maxDelay:     2 * time.Second,
+		multiplier:   2,
+		jitter:       0.2,
+		retryable:    IsTransientError,
+		random:       rand.Float64,
+		sleep:        sleepContext,
+	}
+
+	for _, option := range options {
+		option(&result)
+	}
+
+	return result
+}
+
+// WithContext returns a copy of f whose attempts and waits are bounded by
+// ctx, a call gives up once ctx is done or its deadline would pass before the
+// next attempt
+func (f RetryFileIo) WithContext(ctx context.Context) RetryFileIo {
+	f.ctx = ctx
+	return f
+}
+
+// IsTransientError reports whether err is a failure that may go away when
+// retried, like EAGAIN, EIO or ESTALE on network file systems
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	for _, transient := range []error{
+		syscall.EAGAIN,
+		syscall.EINTR,
+		syscall.EIO,
+		syscall.EBUSY,
+		syscall.ESTALE,
+		syscall.ETIMEDOUT,
+	} {
+		if errors.Is(err, transient) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f RetryFileIo) GetOperatingSystem() OperatingSystem {
+	return f.fileIo.GetOperatingSystem()
+}
+
+func (f RetryFileIo) FileExists(path string) bool {
+	return f.fileIo.FileExists(path)
+}
+
+func (f RetryFileIo) DirExists(folderPath string) bool {
+	return f.fileIo.DirExists(folderPath)
+}
+
+func (f RetryFileIo) CreateDir(folderPath string, mode fs.FileMode) error {
+	return f.retry("CreateDir", folderPath, func() error {
+		return f.fileIo.CreateDir(folderPath, mode)
+	})
+}
+
+func (f RetryFileIo) CreateDirAll(folderPath string, mode fs.FileMode, parentMode ...fs.FileMode) error {
+	return f.retry("CreateDirAll", folderPath, func() error {
+		return f.fileIo.CreateDirAll(folderPath, mode, parentMode...)
+	})
+}
+
+func (f RetryFileIo) EnsureDir(folderPath string, mode fs.FileMode) error {
+	return f.retry("EnsureDir", folderPath, func() error {
+		return f.fileIo.EnsureDir(folderPath, mode)
+	})
+}
+
+func (f RetryFileIo) GetExecutionPath() string {
+	return f.fileIo.GetExecutionPath()
+}
+
+func (f RetryFileIo) ToOsPath(path string) string {
+	return f.fileIo.ToOsPath(path)
+}
+
+func (f RetryFileIo) GetOsPathSeparator() string {
+	return f.fileIo.GetOsPathSeparator()
+}
+
+func (f RetryFileIo) ReadFile(path string) ([]byte, error) {
+	var content []byte
+	err := f.retry("ReadFile", path, func() (err error) {
+		content, err = f.fileIo.ReadFile(path)
+		return err
+	})
+
+	return content, err
+}
+
+func (f RetryFileIo) ReadBufferedFile(path string, from, to int) ([]byte, error) {
+	var content []byte
+	err := f.retry("ReadBufferedFile", path, func() (err error) {
+		content, err = f.fileIo.ReadBufferedFile(path, from, to)
+		return err
+	})
+
+	return content, err
+}
+
+func (f RetryFileIo) WriteFile(path string, data []byte, mode os.FileMode) error {
+	return f.retry("WriteFile", path, func() error {
+		return f.fileIo.WriteFile(path, data, mode)
+	})
+}
+
+func (f RetryFileIo) WriteBufferedFile(path string, data []byte, bufferSize int, mode os.FileMode) error {
+	return f.retry("WriteBufferedFile", path, func() error {
+		return f.fileIo.WriteBufferedFile(path, data, bufferSize, mode)
+	})
+}
+
+func (f RetryFileIo) ReadDir(path string) ([]fs.DirEntry, error) {
+	var entries []fs.DirEntry
+	err := f.retry("ReadDir", path, func() (err error) {
+		entries, err = f.fileIo.ReadDir(path)
+		return err
+	})
+
+	return entries, err
+}
+
+func (f RetryFileIo) JoinPath(parts ...string) string {
+	return f.fileIo.JoinPath(parts...)
+}
+
+func (f RetryFileIo) CopyFile(source, destination string) error {
+	return f.retry("CopyFile", source, func() error {
+		return f.fileIo.CopyFile(source, destination)
+	})
+}
+
+func (f RetryFileIo) DeleteFile(path string) error {
+	return f.retry("DeleteFile", path, func() error {
+		return f.fileIo.DeleteFile(path)
+	})
+}
+
+func (f RetryFileIo) Move(source, destination string, policy ...OverwritePolicy) error {
+	return f.retry("Move", source, func() error {
+		return f.fileIo.Move(source, destination, policy...)
+	})
+}
+
+func (f RetryFileIo) CopyDir(source, destination string) error {
+	return f.retry("CopyDir", source, func() error {
+		return f.fileIo.CopyDir(source, destination)
+	})
+}
+
+func (f RetryFileIo) DeleteDir(path string) error {
+	return f.retry("DeleteDir", path, func() error {
+		return f.fileIo.DeleteDir(path)
+	})
+}
+
+func (f RetryFileIo) Checksum(path string, method ChecksumMethod) (string, error) {
+	var checksum string
+	err := f.retry("Checksum", path, func() (err error) {
+		checksum, err = f.fileIo.Checksum(path, method)
+		return err
+	})
+
+	return checksum, err
+}
+
+func (f RetryFileIo) FileInfo(path string) (os.FileInfo, error) {
+	var info os.FileInfo
+	err := f.retry("FileInfo", path, func() (err error) {
+		info, err = f.fileIo.FileInfo(path)
+		return err
+	})
+
+	return info, err
+}
+
+func (f RetryFileIo) TempDir(dir, pattern string) (string, CleanupFunc, error) {
+	var path string
+	var cleanup CleanupFunc
+	err := f.retry("TempDir", dir, func() (err error) {
+		path, cleanup, err = f.fileIo.TempDir(dir, pattern)
+		return err
+	})
+
+	return path, cleanup, err
+}
+
+func (f RetryFileIo) TempFile(dir, pattern string) (string, CleanupFunc, error) {
+	var path string
+	var cleanup CleanupFunc
+	err := f.retry("TempFile", dir, func() (err error) {
+		path, cleanup, err = f.fileIo.TempFile(dir, pattern)
+		return err
+	})
+
+	return path, cleanup, err
+}
+
+// OpenFile retries opening path, reads from the returned reader are not
+// retried
+func (f RetryFileIo) OpenFile(path string) (io.ReadCloser, error) {
+	var reader io.ReadCloser
+	err := f.retry("OpenFile", path, func() (err error) {
+		reader, err = OpenReader(f.fileIo, path)
+		return err
+	})
+
+	return reader, err
+}
+
+// CreateFile creates path for writing, it is only retried with
+// WithRetryWrites and writes to the returned writer never are
+func (f RetryFileIo) CreateFile(path string, mode fs.FileMode) (io.WriteCloser, error) {
+	var writer io.WriteCloser
+	err := f.retry("CreateFile", path, func() (err error) {
+		writer, err = CreateWriter(f.fileIo, path, mode)
+		return err
+	})
+
+	return writer, err
+}
+
+// retry runs call until it succeeds, fails with an error that is not
+// retryable or runs out of attempts, the last error is returned as is
+func (f RetryFileIo) retry(method, path string, call func() error) error {
+	if err := f.ctx.Err(); err != nil {
+		return NewFileIoError(BackendRetry, method, path, err)
+	}
+
+	attempts := f.maxAttempts
+	if !f.retryWrites && !isIdempotent(method) {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			delay := f.delay(attempt - 1)
+			if deadline, ok := f.ctx.Deadline(); ok && time.Until(deadline) < delay {
+				return NewFileIoError(BackendRetry, method, path, errors.Join(err, context.DeadlineExceeded))
+			}
+			if sleepErr := f.sleep(f.ctx, delay); sleepErr != nil {
+				return NewFileIoError(BackendRetry, method, path, errors.Join(err, sleepErr))
+			}
+		}
+
+		err = call()
+		if err == nil || f.retryable == nil || !f.retryable(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// delay returns the wait before retry number retry, counting from zero
+func (f RetryFileIo) delay(retry int) time.Duration {
+	delay := float64(f.initialDelay) * math.Pow(f.multiplier, float64(retry))
+	if f.maxDelay > 0 && delay > float64(f.maxDelay) {
+		delay = float64(f.maxDelay)
+	}
+
+	if f.jitter > 0 {
+		delay += delay * f.jitter * (2*f.random() - 1)
+	}
+
+	return time.Duration(delay)
+}
+
+// isIdempotent reports whether repeating the FileIo method named method after
+// a partial failure leaves the file system as a single success would
+func isIdempotent(method string) bool {
+	switch method {
+	case "WriteFile", "WriteBufferedFile", "CopyFile", "CopyDir", "CreateDirAll", "EnsureDir":
+		return true
+	default:
+		return MethodClassOf(method) == MethodClassRead
+	}
+}
+
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,192 @@
+package io
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyFileIo fails the first failures calls of ReadFile, CopyFile and Move
+// with err
+type flakyFileIo struct {
+	FileIo
+	failures int
+	err      error
+	calls    map[string]int
+}
+
+func newFlakyFileIo(failures int, err error) *flakyFileIo {
+	memory := NewMemoryFileIo()
+	_ = memory.WriteFile("/file.txt", []byte("content"), 0o644)
+
+	return &flakyFileIo{
+		FileIo:   memory,
+		failures: failures,
+		err:      err,
+		calls:    map[string]int{},
+	}
+}
+
+func (f *flakyFileIo) fail(method, path string) error {
+	f.calls[method]++
+	if f.calls[method] <= f.failures {
+		return NewFileIoError(BackendMemory, method, path, f.err)
+	}
+
+	return nil
+}
+
+func (f *flakyFileIo) ReadFile(path string) ([]byte, error) {
+	if err := f.fail("ReadFile", path); err != nil {
+		return nil, err
+	}
+
+	return f.FileIo.ReadFile(path)
+}
+
+func (f *flakyFileIo) CopyFile(source, destination string) error {
+	if err := f.fail("CopyFile", source); err != nil {
+		return err
+	}
+
+	return f.FileIo.CopyFile(source, destination)
+}
+
+func (f *flakyFileIo) Move(source, destination string, policy ...OverwritePolicy) error {
+	if err := f.fail("Move", source); err != nil {
+		return err
+	}
+
+	return f.FileIo.Move(source, destination, policy...)
+}
+
+func newTestRetryFileIo(fileIo FileIo, delays *[]time.Duration, options ...RetryOption) RetryFileIo {
+	retry := NewRetryFileIo(fileIo, options...)
+	retry.sleep = func(ctx context.Context, delay time.Duration) error {
+		*delays = append(*delays, delay)
+		return ctx.Err()
+	}
+
+	return retry
+}
+
+func TestRetryFileIo_RetriesTransientErrors(t *testing.T) {
+	flaky := newFlakyFileIo(2, syscall.EIO)
+	var delays []time.Duration
+	retry := newTestRetryFileIo(flaky, &delays, WithJitter(0), WithBackoff(10*time.Millisecond, time.Second, 3))
+
+	content, err := retry.ReadFile("/file.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "content", string(content))
+	assert.Equal(t, 3, flaky.calls["ReadFile"])
+	assert.Equal(t, []time.Duration{10 * time.Millisecond, 30 * time.Millisecond}, delays)
+
+	assert.NoError(t, retry.CopyFile("/file.txt", "/copy.txt"))
+	assert.Equal(t, 3, flaky.calls["CopyFile"])
+}
+
+func TestRetryFileIo_GivesUp(t *testing.T) {
+	flaky := newFlakyFileIo(5, syscall.ESTALE)
+	var delays []time.Duration
+	retry := newTestRetryFileIo(flaky, &delays, WithMaxAttempts(4))
+
+	_, err := retry.ReadFile("/file.txt")
+	assert.ErrorIs(t, err, syscall.ESTALE)
+	assert.Equal(t, 4, flaky.calls["ReadFile"])
+	assert.Len(t, delays, 3)
+}
+
+func TestRetryFileIo_PermanentErrors(t *testing.T) {
+	flaky := newFlakyFileIo(5, fs.ErrNotExist)
+	var delays []time.Duration
+	retry := newTestRetryFileIo(flaky, &delays)
+
+	_, err := retry.ReadFile("/file.txt")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.Equal(t, 1, flaky.calls["ReadFile"])
+	assert.Empty(t, delays)
+
+	permanent := errors.New("permanent")
+	flaky = newFlakyFileIo(1, permanent)
+	retry = newTestRetryFileIo(flaky, &delays, WithRetryable(func(err error) bool {
+		return errors.Is(err, permanent)
+	}))
+	_, err = retry.ReadFile("/file.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, flaky.calls["ReadFile"])
+}
+
+func TestRetryFileIo_NonIdempotentWrites(t *testing.T) {
+	flaky := newFlakyFileIo(1, syscall.EAGAIN)
+	var delays []time.Duration
+	retry := newTestRetryFileIo(flaky, &delays)
+
+	err := retry.Move("/file.txt", "/moved.txt")
+	assert.ErrorIs(t, err, syscall.EAGAIN)
+	assert.Equal(t, 1, flaky.calls["Move"])
+
+	flaky = newFlakyFileIo(1, syscall.EAGAIN)
+	retry = newTestRetryFileIo(flaky, &delays, WithRetryWrites())
+	assert.NoError(t, retry.Move("/file.txt", "/moved.txt"))
+	assert.Equal(t, 2, flaky.calls["Move"])
+	assert.True(t, flaky.FileExists("/moved.txt"))
+}
+
+func TestRetryFileIo_Context(t *testing.T) {
+	t.Run("Cancelled Before Call", func(t *testing.T) {
+		flaky := newFlakyFileIo(0, nil)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := NewRetryFileIo(flaky).WithContext(ctx).ReadFile("/file.txt")
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Zero(t, flaky.calls["ReadFile"])
+	})
+
+	t.Run("Deadline Before Next Attempt", func(t *testing.T) {
+		flaky := newFlakyFileIo(5, syscall.EIO)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		retry := NewRetryFileIo(flaky, WithBackoff(time.Minute, time.Minute, 1)).WithContext(ctx)
+
+		started := time.Now()
+		_, err := retry.ReadFile("/file.txt")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.ErrorIs(t, err, syscall.EIO)
+		assert.Equal(t, 1, flaky.calls["ReadFile"])
+		assert.Less(t, time.Since(started), 10*time.Second)
+	})
+
+	t.Run("Cancelled While Waiting", func(t *testing.T) {
+		flaky := newFlakyFileIo(5, syscall.EIO)
+		ctx, cancel := context.WithCancel(context.Background())
+		retry := NewRetryFileIo(flaky, WithBackoff(time.Minute, time.Minute, 1)).WithContext(ctx)
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		_, err := retry.ReadFile("/file.txt")
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.ErrorIs(t, err, syscall.EIO)
+	})
+}
+
+func TestRetryFileIo_Jitter(t *testing.T) {
+	retry := NewRetryFileIo(NewMemoryFileIo(), WithBackoff(100*time.Millisecond, 150*time.Millisecond, 2), WithJitter(0.5))
+
+	retry.random = func() float64 { return 0 }
+	assert.Equal(t, 50*time.Millisecond, retry.delay(0))
+	retry.random = func() float64 { return 1 }
+	assert.Equal(t, 150*time.Millisecond, retry.delay(0))
+	assert.Equal(t, 225*time.Millisecond, retry.delay(3))
+}
+
+func TestIsTransientError(t *testing.T) {
+	assert.True(t, IsTransientError(NewFileIoError(BackendDefault, "ReadFile", "/x", syscall.EAGAIN)))
+	assert.True(t, IsTransientError(syscall.ESTALE))
+	assert.False(t, IsTransientError(fs.ErrNotExist))
+	assert.False(t, IsTransientError(nil))
+}
@@ -0,0 +1,493 @@
+package io
+
+import (
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/cjlapao/common-go-helpers/io/paths"
+)
+
+// maxSymlinkDepth limits how many dangling links are followed while checking
+// a path, it matches the usual ELOOP limit
+const maxSymlinkDepth = 40
+
+// RootedFileIo confines another FileIo to a root directory. Every path is
+// resolved relative to the root, absolute paths included, and paths that
+// escape it with ".." or through a symbolic link pointing outside fail with
+// ErrPathEscapesRoot. Paths returned to the caller, in results and errors,
+// are relative to the root.
+//
+// Symbolic links are only checked when the wrapped FileIo implements
+// SymlinkResolver, CopyDir and Move check every entry of the tree they work
+// on. The check happens before each operation, so it does not
+// protect against links being swapped concurrently by another process.
+type RootedFileIo struct {
+	fileIo FileIo
+	root   string
+}
+
+// NewRootedFileIo returns a FileIo that confines fileIo to root
+func NewRootedFileIo(fileIo FileIo, root string) RootedFileIo {
+	return RootedFileIo{
+		fileIo: fileIo,
+		root:   fileIo.GetOperatingSystem().PathFlavour().Clean(root),
+	}
+}
+
+// Root returns the directory the FileIo is confined to
+func (f RootedFileIo) Root() string {
+	return f.root
+}
+
+// Resolve returns the path in the wrapped FileIo for a path relative to the
+// root, or an ErrPathEscapesRoot error if it would leave the root
+func (f RootedFileIo) Resolve(path string) (string, error) {
+	return f.resolve("Resolve", path)
+}
+
+// Rel returns a path of the wrapped FileIo relative to the root, paths
+// outside of the root are returned unchanged
+func (f RootedFileIo) Rel(path string) string {
+	flavour := f.flavour()
+	if !isWithin(flavour, f.root, path) {
+		return path
+	}
+
+	relative, err := flavour.Rel(f.root, path)
+	if err != nil {
+		return path
+	}
+
+	return relative
+}
+
+func (f RootedFileIo) GetOperatingSystem() OperatingSystem {
+	return f.fileIo.GetOperatingSystem()
+}
+
+func (f RootedFileIo) FileExists(path string) bool {
+	resolved, err := f.resolve("FileExists", path)
+	if err != nil {
+		return false
+	}
+
+	return f.fileIo.FileExists(resolved)
+}
+
+func (f RootedFileIo) DirExists(folderPath string) bool {
+	resolved, err := f.resolve("DirExists", folderPath)
+	if err != nil {
+		return false
+	}
+
+	return f.fileIo.DirExists(resolved)
+}
+
+func (f RootedFileIo) CreateDir(folderPath string, mode fs.FileMode) error {
+	resolved, err := f.resolve("CreateDir", folderPath)
+	if err != nil {
+		return err
+	}
+
+	return f.relError(f.fileIo.CreateDir(resolved, mode))
+}
+
+func (f RootedFileIo) CreateDirAll(folderPath string, mode fs.FileMode, parentMode ...fs.FileMode) error {
+	resolved, err := f.resolve("CreateDirAll", folderPath)
+	if err != nil {
+		return err
+	}
+
+	return f.relError(f.fileIo.CreateDirAll(resolved, mode, parentMode...))
+}
+
+func (f RootedFileIo) EnsureDir(folderPath string, mode fs.FileMode) error {
+	resolved, err := f.resolve("EnsureDir", folderPath)
+	if err != nil {
+		return err
+	}
+
+	return f.relError(f.fileIo.EnsureDir(resolved, mode))
+}
+
+func (f RootedFileIo) GetExecutionPath() string {
+	return f.fileIo.GetExecutionPath()
+}
+
+func (f RootedFileIo) ToOsPath(path string) string {
+	return f.fileIo.ToOsPath(path)
+}
+
+func (f RootedFileIo) GetOsPathSeparator() string {
+	return f.fileIo.GetOsPathSeparator()
+}
+
+func (f RootedFileIo) ReadFile(path string) ([]byte, error) {
+	resolved, err := f.resolve("ReadFile", path)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := f.fileIo.ReadFile(resolved)
+	return content, f.relError(err)
+}
+
+func (f RootedFileIo) ReadBufferedFile(path string, from, to int) ([]byte, error) {
+	resolved, err := f.resolve("ReadBufferedFile", path)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := f.fileIo.ReadBufferedFile(resolved, from, to)
+	return content, f.relError(err)
+}
+
+func (f RootedFileIo) WriteFile(path string, data []byte, mode os.FileMode) error {
+	resolved, err := f.resolve("WriteFile", path)
+	if err != nil {
+		return err
+	}
+
+	return f.relError(f.fileIo.WriteFile(resolved, data, mode))
+}
+
+func (f RootedFileIo) WriteBufferedFile(path string, data []byte, bufferSize int, mode os.FileMode) error {
+	resolved, err := f.resolve("WriteBufferedFile", path)
+	if err != nil {
+		return err
+	}
+
+	return f.relError(f.fileIo.WriteBufferedFile(resolved, data, bufferSize, mode))
+}
+
+func (f RootedFileIo) ReadDir(path string) ([]fs.DirEntry, error) {
+	resolved, err := f.resolve("ReadDir", path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := f.fileIo.ReadDir(resolved)
+	return entries, f.relError(err)
+}
+
+func (f RootedFileIo) JoinPath(parts ...string) string {
+	return f.fileIo.JoinPath(parts...)
+}
+
+func (f RootedFileIo) CopyFile(source, destination string) error {
+	resolvedSource, resolvedDestination, err := f.resolveLink("CopyFile", source, destination)
+	if err != nil {
+		return err
+	}
+
+	return f.relError(f.fileIo.CopyFile(resolvedSource, resolvedDestination))
+}
+
+func (f RootedFileIo) DeleteFile(path string) error {
+	resolved, err := f.resolve("DeleteFile", path)
+	if err != nil {
+		return err
+	}
+
+	return f.relError(f.fileIo.DeleteFile(resolved))
+}
+
+func (f RootedFileIo) Move(source, destination string, policy ...OverwritePolicy) error {
+	resolvedSource, resolvedDestination, err := f.resolveLink("Move", source, destination)
+	if err != nil {
+		return err
+	}
+
+	// a cross device move copies through the links of the source tree and a
+	// merge writes through the links of the destination tree
+	if err := f.checkTree("Move", source, resolvedSource); err != nil {
+		return err
+	}
+	if err := f.checkTree("Move", destination, resolvedDestination); err != nil {
+		return err
+	}
+
+	return f.relError(f.fileIo.Move(resolvedSource, resolvedDestination, policy...))
+}
+
+// CopyDir walks the tree itself so every entry is resolved and checked
+// against the root right before it is copied
+func (f RootedFileIo) CopyDir(source, destination string) error {
+	resolvedSource, resolvedDestination, err := f.resolveLink("CopyDir", source, destination)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.fileIo.FileInfo(resolvedSource)
+	if err != nil {
+		return f.relError(err)
+	}
+	if !info.IsDir() {
+		return NewFileIoLinkError(BackendRooted, "CopyDir", source, destination, ErrNotDirectory)
+	}
+
+	if err := f.fileIo.CreateDirAll(resolvedDestination, info.Mode().Perm()); err != nil {
+		return f.relError(err)
+	}
+
+	entries, err := f.fileIo.ReadDir(resolvedSource)
+	if err != nil {
+		return f.relError(err)
+	}
+
+	flavour := f.flavour()
+	for _, entry := range entries {
+		sourcePath := flavour.Join(source, entry.Name())
+		destinationPath := flavour.Join(destination, entry.Name())
+
+		if entry.IsDir() {
+			err = f.CopyDir(sourcePath, destinationPath)
+		} else {
+			err = f.CopyFile(sourcePath, destinationPath)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f RootedFileIo) DeleteDir(path string) error {
+	resolved, err := f.resolve("DeleteDir", path)
+	if err != nil {
+		return err
+	}
+
+	// deleting the root itself would leave the FileIo without a root
+	if resolved == f.root {
+		return NewFileIoError(BackendRooted, "DeleteDir", path, fs.ErrPermission)
+	}
+
+	return f.relError(f.fileIo.DeleteDir(resolved))
+}
+
+func (f RootedFileIo) Checksum(path string, method ChecksumMethod) (string, error) {
+	resolved, err := f.resolve("Checksum", path)
+	if err != nil {
+		return "", err
+	}
+
+	checksum, err := f.fileIo.Checksum(resolved, method)
+	return checksum, f.relError(err)
+}
+
+func (f RootedFileIo) FileInfo(path string) (os.FileInfo, error) {
+	resolved, err := f.resolve("FileInfo", path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.fileIo.FileInfo(resolved)
+	return info, f.relError(err)
+}
+
+// TempDir creates the directory inside dir, or inside the root when dir is
+// empty, and returns its path relative to the root
+func (f RootedFileIo) TempDir(dir, pattern string) (string, CleanupFunc, error) {
+	resolved, err := f.resolve("TempDir", dir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	path, cleanup, err := f.fileIo.TempDir(resolved, pattern)
+	if err != nil {
+		return "", nil, f.relError(err)
+	}
+
+	return f.Rel(path), cleanup, nil
+}
+
+// TempFile creates the file inside dir, or inside the root when dir is empty,
+// and returns its path relative to the root
+func (f RootedFileIo) TempFile(dir, pattern string) (string, CleanupFunc, error) {
+	resolved, err := f.resolve("TempFile", dir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	path, cleanup, err := f.fileIo.TempFile(resolved, pattern)
+	if err != nil {
+		return "", nil, f.relError(err)
+	}
+
+	return f.Rel(path), cleanup, nil
+}
+
+func (f RootedFileIo) flavour() paths.Flavour {
+	return f.fileIo.GetOperatingSystem().PathFlavour()
+}
+
+func (f RootedFileIo) resolve(op, path string) (string, error) {
+	flavour := f.flavour()
+
+	// absolute paths are taken as relative to the root
+	relative := path[len(flavour.VolumeName(path)):]
+	for relative != "" && flavour.IsSeparator(relative[0]) {
+		relative = relative[1:]
+	}
+
+	relative = flavour.Clean(relative)
+	if relative == ".." || strings.HasPrefix(relative, ".."+flavour.Separator()) {
+		return "", NewFileIoError(BackendRooted, op, path, ErrPathEscapesRoot)
+	}
+
+	resolved := flavour.Join(f.root, relative)
+	if err := f.checkSymlinks(resolved); err != nil {
+		return "", NewFileIoError(BackendRooted, op, path, f.relError(err))
+	}
+
+	return resolved, nil
+}
+
+func (f RootedFileIo) resolveLink(op, source, destination string) (string, string, error) {
+	resolvedSource, err := f.resolve(op, source)
+	if err != nil {
+		return "", "", err
+	}
+
+	resolvedDestination, err := f.resolve(op, destination)
+	if err != nil {
+		return "", "", err
+	}
+
+	return resolvedSource, resolvedDestination, nil
+}
+
+// checkTree runs checkSymlinks on every entry below the resolved directory,
+// files and paths that do not exist have nothing to check
+func (f RootedFileIo) checkTree(op, path, resolved string) error {
+	if _, ok := f.fileIo.(SymlinkResolver); !ok {
+		return nil
+	}
+	if info, err := f.fileIo.FileInfo(resolved); err != nil || !info.IsDir() {
+		return nil
+	}
+
+	entries, err := f.fileIo.ReadDir(resolved)
+	if err != nil {
+		return f.relError(err)
+	}
+
+	flavour := f.flavour()
+	for _, entry := range entries {
+		entryPath := flavour.Join(resolved, entry.Name())
+		if err := f.checkSymlinks(entryPath); err != nil {
+			return NewFileIoError(BackendRooted, op, flavour.Join(path, entry.Name()), f.relError(err))
+		}
+
+		if entry.IsDir() {
+			if err := f.checkTree(op, flavour.Join(path, entry.Name()), entryPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkSymlinks makes sure path, once its links are resolved, stays inside
+// the root, paths that do not exist yet are checked through their deepest
+// existing parent
+func (f RootedFileIo) checkSymlinks(path string) error {
+	resolver, ok := f.fileIo.(SymlinkResolver)
+	if !ok {
+		return nil
+	}
+
+	root, err := resolver.EvalSymlinks(f.root)
+	if err != nil {
+		return err
+	}
+
+	return f.checkInside(resolver, root, path, maxSymlinkDepth)
+}
+
+func (f RootedFileIo) checkInside(resolver SymlinkResolver, root, path string, depth int) error {
+	if depth == 0 {
+		return ErrPathEscapesRoot
+	}
+
+	flavour := f.flavour()
+	current, missing := path, ""
+	for {
+		resolved, err := resolver.EvalSymlinks(current)
+		if err == nil {
+			if !isWithin(flavour, root, resolved) {
+				return ErrPathEscapesRoot
+			}
+			if missing == "" {
+				return nil
+			}
+
+			// the first missing element may be a dangling link, writing
+			// through it would create its target
+			target, err := resolver.Readlink(flavour.Join(resolved, missing))
+			if err != nil {
+				return nil
+			}
+			if !flavour.IsAbs(target) {
+				target = flavour.Join(resolved, target)
+			}
+
+			return f.checkInside(resolver, root, target, depth-1)
+		}
+
+		parent := flavour.Dir(current)
+		if parent == current {
+			return nil
+		}
+
+		missing = flavour.Base(current)
+		current = parent
+	}
+}
+
+// relError rewrites the paths reported by err to be relative to the root
+func (f RootedFileIo) relError(err error) error {
+	fileIoErr, ok := err.(*FileIoError)
+	if !ok {
+		return err
+	}
+
+	relErr := *fileIoErr
+	relErr.Path = f.relPath(relErr.Path)
+	relErr.Destination = f.relPath(relErr.Destination)
+
+	switch cause := relErr.Err.(type) {
+	case *fs.PathError:
+		relPathErr := *cause
+		relPathErr.Path = f.relPath(relPathErr.Path)
+		relErr.Err = &relPathErr
+	case *os.LinkError:
+		relLinkErr := *cause
+		relLinkErr.Old = f.relPath(relLinkErr.Old)
+		relLinkErr.New = f.relPath(relLinkErr.New)
+		relErr.Err = &relLinkErr
+	}
+
+	return &relErr
+}
+
+func (f RootedFileIo) relPath(path string) string {
+	if path == "" {
+		return path
+	}
+
+	return f.Rel(path)
+}
+
+func isWithin(flavour paths.Flavour, root, path string) bool {
+	relative, err := flavour.Rel(root, path)
+	if err != nil {
+		return false
+	}
+
+	return relative != ".." && !strings.HasPrefix(relative, ".."+flavour.Separator())
+}
@@ -0,0 +1,208 @@
+package io
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newRootedFixture(t *testing.T) (RootedFileIo, string, string) {
+	t.Helper()
+
+	parent := t.TempDir()
+	root := filepath.Join(parent, "root")
+	outside := filepath.Join(parent, "outside")
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "data"), 0o755))
+	assert.NoError(t, os.MkdirAll(outside, 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "data", "file.txt"), []byte("inside"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("outside"), 0o644))
+
+	return NewRootedFileIo(Default(), root), root, outside
+}
+
+func TestRootedFileIo_Resolve(t *testing.T) {
+	rooted, root, _ := newRootedFixture(t)
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"Relative", "data/file.txt", filepath.Join(root, "data", "file.txt")},
+		{"Absolute Is Root Relative", "/data/file.txt", filepath.Join(root, "data", "file.txt")},
+		{"Inner Parent", "data/../data/file.txt", filepath.Join(root, "data", "file.txt")},
+		{"Root", "", root},
+		{"Root Separator", "/", root},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := rooted.Resolve(tt.path)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+
+	for _, path := range []string{"..", "../outside/secret.txt", "data/../../outside", "/../outside"} {
+		t.Run("Escape "+path, func(t *testing.T) {
+			_, err := rooted.Resolve(path)
+			assert.ErrorIs(t, err, ErrPathEscapesRoot)
+
+			var fileIoErr *FileIoError
+			assert.ErrorAs(t, err, &fileIoErr)
+			assert.Equal(t, BackendRooted, fileIoErr.Backend)
+			assert.Equal(t, path, fileIoErr.Path)
+		})
+	}
+}
+
+func TestRootedFileIo_Operations(t *testing.T) {
+	rooted, root, _ := newRootedFixture(t)
+
+	content, err := rooted.ReadFile("/data/file.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "inside", string(content))
+
+	assert.NoError(t, rooted.WriteFile("data/new.txt", []byte("new"), 0o644))
+	assert.FileExists(t, filepath.Join(root, "data", "new.txt"))
+	assert.True(t, rooted.FileExists("data/new.txt"))
+	assert.False(t, rooted.FileExists("../root/data/new.txt"))
+
+	assert.NoError(t, rooted.CopyFile("data/new.txt", "copy.txt"))
+	assert.NoError(t, rooted.Move("copy.txt", "data/moved.txt"))
+	assert.FileExists(t, filepath.Join(root, "data", "moved.txt"))
+
+	err = rooted.CopyFile("data/new.txt", "../stolen.txt")
+	assert.ErrorIs(t, err, ErrPathEscapesRoot)
+
+	entries, err := rooted.ReadDir("data")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+
+	err = rooted.DeleteDir("/")
+	assert.ErrorIs(t, err, fs.ErrPermission)
+	assert.DirExists(t, root)
+}
+
+func TestRootedFileIo_RelativeErrors(t *testing.T) {
+	rooted, root, _ := newRootedFixture(t)
+
+	_, err := rooted.ReadFile("data/missing.txt")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.NotContains(t, err.Error(), root)
+
+	var fileIoErr *FileIoError
+	assert.ErrorAs(t, err, &fileIoErr)
+	assert.Equal(t, filepath.Join("data", "missing.txt"), fileIoErr.Path)
+
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		assert.Equal(t, filepath.Join("data", "missing.txt"), pathErr.Path)
+	}
+}
+
+func TestRootedFileIo_Temp(t *testing.T) {
+	rooted, root, _ := newRootedFixture(t)
+
+	path, cleanup, err := rooted.TempDir("", "work-")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(path, "work-"))
+	assert.DirExists(t, filepath.Join(root, path))
+	assert.NoError(t, cleanup())
+
+	path, cleanup, err = rooted.TempFile("data", "file-")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(path, filepath.Join("data", "file-")))
+	assert.True(t, rooted.FileExists(path))
+	assert.NoError(t, cleanup())
+}
+
+func TestRootedFileIo_Symlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need elevated rights on windows")
+	}
+
+	rooted, root, outside := newRootedFixture(t)
+	assert.NoError(t, os.Symlink(outside, filepath.Join(root, "escape")))
+	assert.NoError(t, os.Symlink(filepath.Join(outside, "new.txt"), filepath.Join(root, "dangling")))
+	assert.NoError(t, os.Symlink("data", filepath.Join(root, "alias")))
+	assert.NoError(t, os.Symlink("../data/file.txt", filepath.Join(root, "data", "relative")))
+
+	t.Run("Link Outside", func(t *testing.T) {
+		_, err := rooted.ReadFile("escape/secret.txt")
+		assert.ErrorIs(t, err, ErrPathEscapesRoot)
+
+		err = rooted.WriteFile("escape/created.txt", []byte("x"), 0o644)
+		assert.ErrorIs(t, err, ErrPathEscapesRoot)
+		assert.NoFileExists(t, filepath.Join(outside, "created.txt"))
+	})
+
+	t.Run("Dangling Link Outside", func(t *testing.T) {
+		err := rooted.WriteFile("dangling", []byte("x"), 0o644)
+		assert.ErrorIs(t, err, ErrPathEscapesRoot)
+		assert.NoFileExists(t, filepath.Join(outside, "new.txt"))
+	})
+
+	t.Run("Link Inside", func(t *testing.T) {
+		content, err := rooted.ReadFile("alias/file.txt")
+		assert.NoError(t, err)
+		assert.Equal(t, "inside", string(content))
+
+		content, err = rooted.ReadFile("data/relative")
+		assert.NoError(t, err)
+		assert.Equal(t, "inside", string(content))
+	})
+
+	t.Run("Copy And Move Through Link Outside", func(t *testing.T) {
+		tree := filepath.Join(root, "tree", "nested")
+		assert.NoError(t, os.MkdirAll(tree, 0o755))
+		assert.NoError(t, os.WriteFile(filepath.Join(tree, "plain.txt"), []byte("plain"), 0o644))
+		assert.NoError(t, os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(tree, "leak")))
+
+		err := rooted.CopyDir("tree", "copy")
+		assert.ErrorIs(t, err, ErrPathEscapesRoot)
+		assert.NoFileExists(t, filepath.Join(root, "copy", "nested", "leak"))
+
+		err = rooted.CopyFile("tree/nested/leak", "leak.txt")
+		assert.ErrorIs(t, err, ErrPathEscapesRoot)
+		assert.NoFileExists(t, filepath.Join(root, "leak.txt"))
+
+		simulateCrossDevice(t)
+		err = rooted.Move("tree", "moved")
+		assert.ErrorIs(t, err, ErrPathEscapesRoot)
+		assert.DirExists(t, tree)
+		assert.NoDirExists(t, filepath.Join(root, "moved"))
+
+		assert.NoError(t, os.Remove(filepath.Join(tree, "leak")))
+		assert.NoError(t, rooted.CopyDir("tree", "copy"))
+		assertFileContent(t, filepath.Join(root, "copy", "nested", "plain.txt"), "plain")
+		assert.NoError(t, rooted.Move("tree", "moved"))
+		assertFileContent(t, filepath.Join(root, "moved", "nested", "plain.txt"), "plain")
+	})
+
+	t.Run("Merge Into Link Outside", func(t *testing.T) {
+		writeTestTree(t, root, map[string]string{"src/sub/evil.txt": "evil"})
+		assert.NoError(t, os.MkdirAll(filepath.Join(root, "dst"), 0o755))
+		assert.NoError(t, os.Symlink("../../outside", filepath.Join(root, "dst", "sub")))
+
+		err := rooted.Move("src", "dst", OverwriteMerge)
+		assert.ErrorIs(t, err, ErrPathEscapesRoot)
+		assert.NoFileExists(t, filepath.Join(outside, "evil.txt"))
+		assertFileContent(t, filepath.Join(root, "src", "sub", "evil.txt"), "evil")
+	})
+
+	t.Run("Root Behind Link", func(t *testing.T) {
+		link := filepath.Join(t.TempDir(), "root-link")
+		assert.NoError(t, os.Symlink(root, link))
+
+		content, err := NewRootedFileIo(Default(), link).ReadFile("data/file.txt")
+		assert.NoError(t, err)
+		assert.Equal(t, "inside", string(content))
+	})
+}
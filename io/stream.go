@@ -0,0 +1,156 @@
+package io
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// StreamFileIo is implemented by FileIo backends that can read and write
+// files without holding their whole content in memory
+type StreamFileIo interface {
+	// OpenFile opens path for reading
+	OpenFile(path string) (io.ReadCloser, error)
+	// CreateFile creates or truncates path for writing, the content is only
+	// guaranteed to be stored once the writer is closed
+	CreateFile(path string, mode fs.FileMode) (io.WriteCloser, error)
+}
+
+func (f DefaultFileIo) OpenFile(path string) (io.ReadCloser, error) {
+	if err := requireFile("OpenFile", path); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, NewFileIoError(BackendDefault, "OpenFile", path, err)
+	}
+
+	return file, nil
+}
+
+func (f DefaultFileIo) CreateFile(path string, mode fs.FileMode) (io.WriteCloser, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return nil, NewFileIoError(BackendDefault, "CreateFile", path, err)
+	}
+
+	return file, nil
+}
+
+// OpenReader opens path on fileIo for reading, streaming when fileIo
+// implements StreamFileIo and reading the whole file otherwise
+func OpenReader(fileIo FileIo, path string) (io.ReadCloser, error) {
+	if stream, ok := fileIo.(StreamFileIo); ok {
+		return stream.OpenFile(path)
+	}
+
+	content, err := fileIo.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+// CreateWriter creates path on fileIo for writing, streaming when fileIo
+// implements StreamFileIo and buffering the content until Close otherwise
+func CreateWriter(fileIo FileIo, path string, mode fs.FileMode) (io.WriteCloser, error) {
+	if stream, ok := fileIo.(StreamFileIo); ok {
+		return stream.CreateFile(path, mode)
+	}
+
+	return &bufferedWriter{
+		close: func(content []byte) error {
+			return fileIo.WriteFile(path, content, mode)
+		},
+	}, nil
+}
+
+// CopyStream copies a file between two FileIo, possibly different backends,
+// streaming the content and keeping the source permissions
+func CopyStream(source FileIo, sourcePath string, destination FileIo, destinationPath string) error {
+	return copyStream(BackendStream, source, sourcePath, destination, destinationPath)
+}
+
+// copyStream is CopyStream reporting its own errors under backend, so a
+// decorator copying through it labels them with its name
+func copyStream(backend string, source FileIo, sourcePath string, destination FileIo, destinationPath string) error {
+	info, err := source.FileInfo(sourcePath)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return NewFileIoLinkError(backend, "CopyStream", sourcePath, destinationPath, ErrIsDirectory)
+	}
+
+	reader, err := OpenReader(source, sourcePath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	writer, err := CreateWriter(destination, destinationPath, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(writer, reader); err != nil {
+		writer.Close()
+		return NewFileIoLinkError(backend, "CopyStream", sourcePath, destinationPath, err)
+	}
+
+	return writer.Close()
+}
+
+// bufferedWriter collects everything written and hands it to close
+type bufferedWriter struct {
+	buffer bytes.Buffer
+	closed bool
+	close  func(content []byte) error
+}
+
+func (w *bufferedWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, fs.ErrClosed
+	}
+
+	return w.buffer.Write(p)
+}
+
+func (w *bufferedWriter) Close() error {
+	if w.closed {
+		return fs.ErrClosed
+	}
+
+	w.closed = true
+	return w.close(w.buffer.Bytes())
+}
+
+// readRange returns the bytes between from and to like ReadBufferedFile, a to
+// of zero or past the end reads up to the end
+func readRange(content []byte, from, to int) ([]byte, error) {
+	from, to, err := rangeBounds(len(content), from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := make([]byte, to-from)
+	copy(buffer, content[from:to])
+	return buffer, nil
+}
+
+// rangeBounds returns the bounds ReadBufferedFile reads in a file of size
+// bytes, ErrInvalidRange when from is negative or past to
+func rangeBounds(size, from, to int) (int, int, error) {
+	if to == 0 || to > size {
+		to = size
+	}
+
+	if from < 0 || from > to {
+		return 0, 0, ErrInvalidRange
+	}
+
+	return from, to, nil
+}
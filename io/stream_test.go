@@ -0,0 +1,75 @@
+package io
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// plainFileIo hides the streaming methods of the FileIo it wraps
+type plainFileIo struct {
+	FileIo
+}
+
+func TestStream_DefaultFileIo(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.txt")
+
+	writer, err := Default().CreateFile(path, 0o600)
+	assert.NoError(t, err)
+	_, err = io.WriteString(writer, "streamed")
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+	assertFileContent(t, path, "streamed")
+
+	reader, err := Default().OpenFile(path)
+	assert.NoError(t, err)
+	content, _ := io.ReadAll(reader)
+	assert.NoError(t, reader.Close())
+	assert.Equal(t, "streamed", string(content))
+
+	_, err = Default().OpenFile(dir)
+	assert.ErrorIs(t, err, ErrIsDirectory)
+}
+
+func TestStream_Fallback(t *testing.T) {
+	memory := plainFileIo{FileIo: NewMemoryFileIo()}
+	_, isStream := FileIo(memory).(StreamFileIo)
+	assert.False(t, isStream)
+
+	writer, err := CreateWriter(memory, "/file.txt", 0o644)
+	assert.NoError(t, err)
+	_, _ = io.WriteString(writer, "buffered")
+	assert.False(t, memory.FileExists("/file.txt"))
+	assert.NoError(t, writer.Close())
+	assert.Error(t, writer.Close())
+
+	reader, err := OpenReader(memory, "/file.txt")
+	assert.NoError(t, err)
+	content, _ := io.ReadAll(reader)
+	assert.Equal(t, "buffered", string(content))
+}
+
+func TestCopyStream(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "source.txt")
+	assert.NoError(t, os.WriteFile(source, []byte("between backends"), 0o640))
+	memory := NewMemoryFileIo()
+
+	assert.NoError(t, CopyStream(Default(), source, memory, "/copy.txt"))
+	content, err := memory.ReadFile("/copy.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "between backends", string(content))
+
+	info, _ := memory.FileInfo("/copy.txt")
+	assert.Equal(t, os.FileMode(0o640), info.Mode())
+
+	err = CopyStream(Default(), dir, memory, "/dir")
+	assert.ErrorIs(t, err, ErrIsDirectory)
+	var fileIoErr *FileIoError
+	assert.ErrorAs(t, err, &fileIoErr)
+	assert.Equal(t, BackendStream, fileIoErr.Backend)
+}
@@ -0,0 +1,35 @@
+package io
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// SymlinkResolver is implemented by FileIo backends that support symbolic
+// links, decorators use it to find where a path really points to
+type SymlinkResolver interface {
+	// EvalSymlinks returns path with every symbolic link resolved, the path
+	// must exist
+	EvalSymlinks(path string) (string, error)
+	// Readlink returns the target of the symbolic link at path as stored,
+	// it fails if path is not a link
+	Readlink(path string) (string, error)
+}
+
+func (f DefaultFileIo) EvalSymlinks(path string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", NewFileIoError(BackendDefault, "EvalSymlinks", path, err)
+	}
+
+	return resolved, nil
+}
+
+func (f DefaultFileIo) Readlink(path string) (string, error) {
+	target, err := os.Readlink(path)
+	if err != nil {
+		return "", NewFileIoError(BackendDefault, "Readlink", path, err)
+	}
+
+	return target, nil
+}
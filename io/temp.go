@@ -0,0 +1,89 @@
+package io
+
+import (
+	"errors"
+	"os"
+)
+
+// CleanupFunc removes a temporary path created by TempDir or TempFile, calling
+// it more than once is safe
+type CleanupFunc func() error
+
+// TestingT is the subset of testing.TB needed to tie temporary paths to the
+// lifetime of a test
+type TestingT interface {
+	Helper()
+	Cleanup(func())
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
+
+func (f DefaultFileIo) TempDir(dir, pattern string) (string, CleanupFunc, error) {
+	path, err := os.MkdirTemp(dir, pattern)
+	if err != nil {
+		return "", nil, NewFileIoError(BackendDefault, "TempDir", dir, err)
+	}
+
+	return path, func() error {
+		return f.DeleteDir(path)
+	}, nil
+}
+
+func (f DefaultFileIo) TempFile(dir, pattern string) (string, CleanupFunc, error) {
+	file, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return "", nil, NewFileIoError(BackendDefault, "TempFile", dir, err)
+	}
+
+	path := file.Name()
+	if err := file.Close(); err != nil {
+		_ = os.Remove(path)
+		return "", nil, NewFileIoError(BackendDefault, "TempFile", path, err)
+	}
+
+	return path, func() error {
+		err := f.DeleteFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}, nil
+}
+
+// TempDirT creates a temporary directory in the default temp location that
+// is removed when the test and its subtests complete
+func TempDirT(t TestingT, fileIo FileIo, pattern string) string {
+	t.Helper()
+	path, cleanup, err := fileIo.TempDir("", pattern)
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+		return ""
+	}
+
+	t.Cleanup(func() {
+		if err := cleanup(); err != nil {
+			t.Errorf("failed to remove temporary directory %s: %v", path, err)
+		}
+	})
+
+	return path
+}
+
+// TempFileT creates an empty temporary file in the default temp location
+// that is removed when the test and its subtests complete
+func TempFileT(t TestingT, fileIo FileIo, pattern string) string {
+	t.Helper()
+	path, cleanup, err := fileIo.TempFile("", pattern)
+	if err != nil {
+		t.Fatalf("failed to create temporary file: %v", err)
+		return ""
+	}
+
+	t.Cleanup(func() {
+		if err := cleanup(); err != nil {
+			t.Errorf("failed to remove temporary file %s: %v", path, err)
+		}
+	})
+
+	return path
+}
@@ -0,0 +1,103 @@
+package io
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeTestingT struct {
+	cleanups []func()
+	errors   []string
+	fatals   []string
+}
+
+func (t *fakeTestingT) Helper() {}
+
+func (t *fakeTestingT) Cleanup(fn func()) {
+	t.cleanups = append(t.cleanups, fn)
+}
+
+func (t *fakeTestingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeTestingT) Fatalf(format string, args ...interface{}) {
+	t.fatals = append(t.fatals, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeTestingT) runCleanups() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
+
+func TestTempDir(t *testing.T) {
+	t.Run("Create And Cleanup", func(t *testing.T) {
+		defaultClient := Default()
+		path, cleanup, err := defaultClient.TempDir(t.TempDir(), "scratch-*-dir")
+		assert.NoError(t, err)
+		assert.DirExists(t, path)
+		assert.True(t, strings.HasPrefix(filepath.Base(path), "scratch-"))
+		assert.True(t, strings.HasSuffix(path, "-dir"))
+
+		err = defaultClient.WriteFile(filepath.Join(path, "file.txt"), []byte("data"), 0o644)
+		assert.NoError(t, err)
+
+		assert.NoError(t, cleanup())
+		assert.NoDirExists(t, path)
+		assert.NoError(t, cleanup())
+	})
+
+	t.Run("Invalid Parent", func(t *testing.T) {
+		defaultClient := Default()
+		_, _, err := defaultClient.TempDir(filepath.Join(t.TempDir(), "missing"), "scratch")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestTempFile(t *testing.T) {
+	t.Run("Create And Cleanup", func(t *testing.T) {
+		defaultClient := Default()
+		path, cleanup, err := defaultClient.TempFile(t.TempDir(), "*.json")
+		assert.NoError(t, err)
+		assert.FileExists(t, path)
+		assert.Equal(t, ".json", filepath.Ext(path))
+
+		assert.NoError(t, cleanup())
+		assert.NoFileExists(t, path)
+		assert.NoError(t, cleanup())
+	})
+}
+
+func TestTempDirT(t *testing.T) {
+	t.Run("Removed On Cleanup", func(t *testing.T) {
+		fakeT := &fakeTestingT{}
+		path := TempDirT(fakeT, Default(), "scratch")
+		assert.DirExists(t, path)
+		assert.Len(t, fakeT.cleanups, 1)
+
+		fakeT.runCleanups()
+		assert.NoDirExists(t, path)
+		assert.Empty(t, fakeT.errors)
+	})
+
+	t.Run("Real Testing T", func(t *testing.T) {
+		path := TempFileT(t, Default(), "scratch")
+		assert.FileExists(t, path)
+	})
+}
+
+func TestTempFileT(t *testing.T) {
+	fakeT := &fakeTestingT{}
+	path := TempFileT(fakeT, Default(), "scratch")
+	assert.FileExists(t, path)
+
+	fakeT.runCleanups()
+	assert.NoFileExists(t, path)
+	assert.Empty(t, fakeT.fatals)
+}